@@ -1,57 +1,675 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"net/url"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/jasonzheng/carrag/models"
+	"github.com/jasonzheng/carrag/rag"
+	"github.com/jasonzheng/carrag/tracing"
 	"github.com/jasonzheng/carrag/utils"
 )
 
 // AppConfig 应用配置
+//
+// 配置按以下顺序逐层覆盖：默认值、配置文件(YAML/TOML)、CARRAG_*环境变量、命令行参数。
+// env标签同时决定环境变量名（CARRAG_前缀）和命令行参数名（小写并以连字符连接），
+// secret标签标记的字段在打印配置时会被掩码，reload标签标记的字段支持热加载。
 type AppConfig struct {
 	// 服务器配置
-	ServerPort int
-	GinMode    string
+	ServerPort int    `yaml:"serverPort" env:"SERVER_PORT"`
+	GinMode    string `yaml:"ginMode" env:"GIN_MODE"`
+
+	// HTTP服务器超时配置
+	ReadTimeout     time.Duration `yaml:"readTimeout" env:"READ_TIMEOUT"`         // 读取整个请求（含请求体）的超时时间
+	WriteTimeout    time.Duration `yaml:"writeTimeout" env:"WRITE_TIMEOUT"`       // 写入响应的超时时间
+	IdleTimeout     time.Duration `yaml:"idleTimeout" env:"IDLE_TIMEOUT"`         // keep-alive连接的空闲超时时间
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout" env:"SHUTDOWN_TIMEOUT"` // 优雅关闭的最长等待时间
+
+	// 单个请求的处理超时时间，超时后取消存储和缓存操作并返回504，0表示不限制
+	RequestTimeout time.Duration `yaml:"requestTimeout" env:"REQUEST_TIMEOUT" reload:"true"`
 
 	// 路径配置
-	LogDir  string
-	DataDir string
+	LogDir  string `yaml:"logDir" env:"LOG_DIR"`
+	DataDir string `yaml:"dataDir" env:"DATA_DIR"`
 
 	// Redis配置
-	RedisAddr     string
-	RedisPassword string
-	RedisDB       int
-	RedisPrefix   string
+	RedisAddr     string `yaml:"redisAddr" env:"REDIS_ADDR"`
+	RedisPassword string `yaml:"redisPassword" env:"REDIS_PASSWORD" secret:"true"`
+	RedisDB       int    `yaml:"redisDB" env:"REDIS_DB"`
+	RedisPrefix   string `yaml:"redisPrefix" env:"REDIS_PREFIX"`
+
+	// Redis熔断配置
+	RedisBreaker RedisBreakerConfig `yaml:"redisBreaker" env:"REDIS_BREAKER"`
+
+	// 日志级别和输出格式(text/json)
+	LogLevel  utils.LogLevel  `yaml:"logLevel" env:"LOG_LEVEL" reload:"true"`
+	LogFormat utils.LogFormat `yaml:"logFormat" env:"LOG_FORMAT" reload:"true"`
+
+	// 日志轮转配置
+	LogRotation LogRotationConfig `yaml:"logRotation" env:"LOG_ROTATION"`
+
+	// 允许跨域访问的来源
+	CorsOrigins []string `yaml:"corsOrigins" env:"CORS_ORIGINS" reload:"true"`
+
+	// 限流配置
+	RateLimit RateLimitConfig `yaml:"rateLimit" env:"RATE_LIMIT" reload:"true"`
+
+	// 车辆信息缓存过期时间
+	CacheTTL time.Duration `yaml:"cacheTTL" env:"CACHE_TTL" reload:"true"`
+
+	// 缓存过期和回源策略
+	CachePolicy CachePolicyConfig `yaml:"cachePolicy" env:"CACHE_POLICY" reload:"true"`
+
+	// 缓存实现: redis、lru、tiered 或 none
+	CacheBackend string `yaml:"cacheBackend" env:"CACHE_BACKEND"`
+
+	// 进程内缓存配置，用于lru和tiered缓存
+	LocalCache LocalCacheConfig `yaml:"localCache" env:"LOCAL_CACHE"`
+
+	// 链路追踪配置
+	Tracing TracingConfig `yaml:"tracing" env:"TRACING"`
+
+	// 保养计划，用于计算即将到期的保养
+	MaintenanceSchedule MaintenanceScheduleConfig `yaml:"maintenanceSchedule" reload:"true"`
+
+	// 油耗计算配置
+	FuelLog FuelLogConfig `yaml:"fuelLog" env:"FUEL_LOG" reload:"true"`
+
+	// 里程表读数配置
+	Odometer OdometerConfig `yaml:"odometer" env:"ODOMETER" reload:"true"`
+
+	// 总拥有成本计算配置
+	TCO TCOConfig `yaml:"tco" env:"TCO" reload:"true"`
+
+	// 全文搜索配置
+	Search SearchConfig `yaml:"search" env:"SEARCH"`
+
+	// 语义检索配置
+	RAG RAGConfig `yaml:"rag" env:"RAG"`
+
+	// 车型推荐配置
+	Recommendation RecommendationConfig `yaml:"recommendation" env:"RECOMMENDATION" reload:"true"`
+}
+
+// LogRotationConfig 日志轮转配置，日志文件每天轮转，并可按大小提前轮转
+type LogRotationConfig struct {
+	MaxSizeMB  int           `yaml:"maxSizeMB" env:"MAX_SIZE_MB"`  // 单个日志文件的最大大小(MB)，0表示不按大小轮转
+	MaxBackups int           `yaml:"maxBackups" env:"MAX_BACKUPS"` // 保留的历史日志文件数，0表示不限制
+	MaxAge     time.Duration `yaml:"maxAge" env:"MAX_AGE"`         // 历史日志文件的保留时长，0表示不限制
+	Compress   bool          `yaml:"compress" env:"COMPRESS"`      // 是否使用gzip压缩历史日志文件
+}
+
+// Options 转换为日志记录器的轮转选项
+func (c LogRotationConfig) Options() utils.RotateOptions {
+	return utils.RotateOptions{
+		MaxSize:    int64(c.MaxSizeMB) << 20,
+		MaxBackups: c.MaxBackups,
+		MaxAge:     c.MaxAge,
+		Compress:   c.Compress,
+	}
+}
+
+// RedisBreakerConfig Redis熔断配置
+type RedisBreakerConfig struct {
+	FailureThreshold int           `yaml:"failureThreshold" env:"FAILURE_THRESHOLD"` // 连续失败多少次后熔断
+	ProbeInterval    time.Duration `yaml:"probeInterval" env:"PROBE_INTERVAL"`       // 熔断期间尝试重连的间隔
+}
+
+// Options 转换为熔断器选项
+func (c RedisBreakerConfig) Options() utils.BreakerOptions {
+	return utils.BreakerOptions{
+		FailureThreshold: c.FailureThreshold,
+		ProbeInterval:    c.ProbeInterval,
+	}
+}
+
+// RateLimitRule 令牌桶限流规则
+type RateLimitRule struct {
+	Rate  float64 `yaml:"rate" env:"RATE"`   // 每秒补充的令牌数
+	Burst int     `yaml:"burst" env:"BURST"` // 令牌桶容量，即允许的突发请求数
+}
+
+// RateLimitConfig 限流配置
+type RateLimitConfig struct {
+	Enabled   bool                     `yaml:"enabled" env:"ENABLED"`      // 是否启用限流
+	KeyHeader string                   `yaml:"keyHeader" env:"KEY_HEADER"` // API Key请求头，请求携带该头时按API Key限流，否则按客户端IP限流
+	UseRedis  bool                     `yaml:"useRedis" env:"USE_REDIS"`   // 是否使用Redis计数，使多个实例共享限额
+	Default   RateLimitRule            `yaml:"default" env:"DEFAULT"`      // 默认规则
+	Routes    map[string]RateLimitRule `yaml:"routes"`                     // 按路由设置的规则，键格式为 "方法 路由模板"，如 "GET /api/cars"
+}
+
+// CachePolicyConfig 缓存过期和回源策略配置
+type CachePolicyConfig struct {
+	NegativeTTL          time.Duration `yaml:"negativeTTL" env:"NEGATIVE_TTL"`                    // 缓存"数据不存在"结果的时长，0表示不缓存
+	TTLJitter            float64       `yaml:"ttlJitter" env:"TTL_JITTER"`                        // 过期时间的随机浮动比例(0-1)
+	StaleWhileRevalidate time.Duration `yaml:"staleWhileRevalidate" env:"STALE_WHILE_REVALIDATE"` // 过期后仍返回旧数据并后台刷新的时长，0表示不启用
+}
+
+// Policy 转换为缓存策略
+func (c CachePolicyConfig) Policy() utils.CachePolicy {
+	return utils.CachePolicy{
+		NegativeTTL: c.NegativeTTL,
+		Jitter:      c.TTLJitter,
+		StaleTTL:    c.StaleWhileRevalidate,
+	}
+}
+
+// 缓存实现
+const (
+	CacheBackendRedis  = "redis"  // Redis缓存，多个实例共享
+	CacheBackendLRU    = "lru"    // 进程内LRU缓存
+	CacheBackendTiered = "tiered" // 进程内LRU缓存在前、Redis在后的两级缓存
+	CacheBackendNone   = "none"   // 不使用缓存
+)
+
+// LocalCacheConfig 进程内缓存配置
+type LocalCacheConfig struct {
+	MaxEntries   int           `yaml:"maxEntries" env:"MAX_ENTRIES"`    // 最大条目数，超出后淘汰最久未访问的条目
+	MaxTTL       time.Duration `yaml:"maxTTL" env:"MAX_TTL"`            // 单个条目的最长保留时间，错过失效事件时其他实例写入的数据最多在这段时间内不可见
+	Invalidation bool          `yaml:"invalidation" env:"INVALIDATION"` // 是否通过Redis发布订阅在实例之间传播失效事件，单实例部署可关闭
+}
+
+// MaintenanceScheduleConfig 保养计划配置
+type MaintenanceScheduleConfig struct {
+	Default   []models.ServiceInterval            `yaml:"default"`   // 默认保养间隔
+	Overrides map[string][]models.ServiceInterval `yaml:"overrides"` // 按品牌或"品牌/车型"覆盖的保养间隔，如 "丰田" 或 "丰田/卡罗拉"
+}
+
+// Schedule 转换为保养计划
+func (c MaintenanceScheduleConfig) Schedule() models.MaintenanceSchedule {
+	return models.MaintenanceSchedule{
+		Default:   c.Default,
+		Overrides: c.Overrides,
+	}
+}
+
+// FuelLogConfig 油耗计算配置
+type FuelLogConfig struct {
+	RollingWindow        int  `yaml:"rollingWindow" env:"ROLLING_WINDOW"`                // 滚动平均油耗包含的满箱区间数
+	UpdateCarConsumption bool `yaml:"updateCarConsumption" env:"UPDATE_CAR_CONSUMPTION"` // 加油记录变更后是否将平均油耗写回车辆的fuelConsumption
+}
+
+// Options 转换为油耗计算选项
+func (c FuelLogConfig) Options() models.FuelLogOptions {
+	return models.FuelLogOptions{
+		RollingWindow:        c.RollingWindow,
+		UpdateCarConsumption: c.UpdateCarConsumption,
+	}
+}
+
+// OdometerConfig 里程表读数配置
+type OdometerConfig struct {
+	RollbackPolicy      string `yaml:"rollbackPolicy" env:"ROLLBACK_POLICY"`            // 读数低于之前读数时的处理方式: reject 拒绝保存，flag 保存并标记
+	DeriveAnnualMileage bool   `yaml:"deriveAnnualMileage" env:"DERIVE_ANNUAL_MILEAGE"` // 是否根据里程表读数推算车辆的年均行驶里程，开启后覆盖用户填写的值
+	MinSpanDays         int    `yaml:"minSpanDays" env:"MIN_SPAN_DAYS"`                 // 推算年均行驶里程所需的最短读数跨度(天)
+}
+
+// Options 转换为里程表读数选项
+func (c OdometerConfig) Options() models.OdometerOptions {
+	return models.OdometerOptions{
+		RollbackPolicy:      c.RollbackPolicy,
+		DeriveAnnualMileage: c.DeriveAnnualMileage,
+		MinSpan:             time.Duration(c.MinSpanDays) * 24 * time.Hour,
+	}
+}
+
+// TCOConfig 总拥有成本计算配置
+type TCOConfig struct {
+	FuelPrices       map[string]float64 `yaml:"fuelPrices"`                                // 能源单价，键为 92、95、diesel(元/L) 和 electricity(元/kWh)
+	FuelTypes        map[string]string  `yaml:"fuelTypes"`                                 // 车辆燃油类型对应的能源种类，如 "汽油": "92"
+	InsurancePerYear float64            `yaml:"insurancePerYear" env:"INSURANCE_PER_YEAR"` // 车辆未填写保险费用时使用的每年保险费用(元)
+	DepreciationRate float64            `yaml:"depreciationRate" env:"DEPRECIATION_RATE"`  // 每年折旧率(0-1)，按余额递减法计算
+}
+
+// Options 转换为总拥有成本计算选项
+func (c TCOConfig) Options() models.TCOOptions {
+	return models.TCOOptions{
+		FuelPrices:       c.FuelPrices,
+		FuelTypes:        c.FuelTypes,
+		InsurancePerYear: c.InsurancePerYear,
+		DepreciationRate: c.DepreciationRate,
+	}
+}
+
+// SearchConfig 全文搜索配置
+type SearchConfig struct {
+	RefreshInterval time.Duration `yaml:"refreshInterval" env:"REFRESH_INTERVAL"` // 定期重建索引的间隔，使其他实例写入的数据可被搜索到，0表示不重建
+	MaxResults      int           `yaml:"maxResults" env:"MAX_RESULTS"`           // 单次搜索返回的最大结果数
+}
+
+// RAGConfig 语义检索配置
+type RAGConfig struct {
+	Embedder        string        `yaml:"embedder" env:"EMBEDDER"`                // 向量化方式：hash(本地特征哈希) 或 http(调用模型服务)
+	Dimensions      int           `yaml:"dimensions" env:"DIMENSIONS"`            // 特征哈希的向量维度
+	Endpoint        string        `yaml:"endpoint" env:"ENDPOINT"`                // 模型服务兼容OpenAI的embeddings接口地址
+	Model           string        `yaml:"model" env:"MODEL"`                      // 模型名称
+	APIKey          string        `yaml:"apiKey" env:"API_KEY" secret:"true"`     // 模型服务的API Key，本地模型一般无需设置
+	Timeout         time.Duration `yaml:"timeout" env:"TIMEOUT"`                  // 单次调用模型服务的超时时间
+	BatchSize       int           `yaml:"batchSize" env:"BATCH_SIZE"`             // 重建索引和后台向量化时每次向量化的文档数
+	RefreshInterval time.Duration `yaml:"refreshInterval" env:"REFRESH_INTERVAL"` // 定期重建索引的间隔，使其他实例写入的数据可被检索到，0表示不重建
+	MaxTopK         int           `yaml:"maxTopK" env:"MAX_TOP_K"`                // 单次检索返回的最大结果数
+}
+
+// Options 转换为向量化配置
+func (c RAGConfig) Options() rag.Options {
+	return rag.Options{
+		Embedder:   c.Embedder,
+		Dimensions: c.Dimensions,
+		Endpoint:   c.Endpoint,
+		Model:      c.Model,
+		APIKey:     c.APIKey,
+		Timeout:    c.Timeout,
+	}
+}
+
+// RecommendationConfig 车型推荐配置
+type RecommendationConfig struct {
+	Weights RecommendationWeightsConfig `yaml:"weights" env:"WEIGHTS"` // 各因素的权重，用户未提供对应信息的因素不参与评分
+}
+
+// RecommendationWeightsConfig 车型推荐各因素的权重
+type RecommendationWeightsConfig struct {
+	FuelEconomy        float64 `yaml:"fuelEconomy" env:"FUEL_ECONOMY"`               // 实际油耗在同类车型中的排名
+	UsageScenario      float64 `yaml:"usageScenario" env:"USAGE_SCENARIO"`           // 使用场景的相似度
+	AnnualMileage      float64 `yaml:"annualMileage" env:"ANNUAL_MILEAGE"`           // 年均行驶里程的接近程度
+	StorageEnvironment float64 `yaml:"storageEnvironment" env:"STORAGE_ENVIRONMENT"` // 存放环境相同的比例
+}
+
+// Options 转换为车型推荐选项
+func (c RecommendationConfig) Options() models.RecommendationOptions {
+	return models.RecommendationOptions{
+		Weights: models.RecommendationWeights{
+			FuelEconomy:        c.Weights.FuelEconomy,
+			UsageScenario:      c.Weights.UsageScenario,
+			AnnualMileage:      c.Weights.AnnualMileage,
+			StorageEnvironment: c.Weights.StorageEnvironment,
+		},
+	}
+}
 
-	// 日志级别
-	LogLevel utils.LogLevel
+// TracingConfig 链路追踪配置
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter" env:"EXPORTER"`        // 导出方式: none、stdout 或 otlp
+	Endpoint    string  `yaml:"endpoint" env:"ENDPOINT"`        // OTLP/HTTP接收端地址(host:port)
+	Insecure    bool    `yaml:"insecure" env:"INSECURE"`        // OTLP是否使用HTTP而非HTTPS
+	SampleRatio float64 `yaml:"sampleRatio" env:"SAMPLE_RATIO"` // 采样比例(0-1)，上游已采样的请求始终采样
+	ServiceName string  `yaml:"serviceName" env:"SERVICE_NAME"` // 上报的服务名称
+}
+
+// Options 转换为追踪初始化选项
+func (c TracingConfig) Options() tracing.Options {
+	return tracing.Options{
+		Exporter:    c.Exporter,
+		Endpoint:    c.Endpoint,
+		Insecure:    c.Insecure,
+		SampleRatio: c.SampleRatio,
+		ServiceName: c.ServiceName,
+	}
 }
 
 // NewDefaultConfig 创建默认配置
 func NewDefaultConfig() *AppConfig {
 	return &AppConfig{
-		ServerPort:    8080,
-		GinMode:       gin.ReleaseMode,
-		LogDir:        filepath.Join("logs"),
-		DataDir:       filepath.Join("data"),
-		RedisAddr:     "localhost:6379",
-		RedisPassword: "",
-		RedisDB:       0,
-		RedisPrefix:   "carrag",
-		LogLevel:      utils.INFO,
+		ServerPort:      8080,
+		GinMode:         gin.ReleaseMode,
+		ReadTimeout:     15 * time.Second,
+		WriteTimeout:    30 * time.Second,
+		IdleTimeout:     60 * time.Second,
+		ShutdownTimeout: 20 * time.Second,
+		RequestTimeout:  10 * time.Second,
+		LogDir:          filepath.Join("logs"),
+		DataDir:         filepath.Join("data"),
+		RedisAddr:       "localhost:6379",
+		RedisPassword:   "",
+		RedisDB:         0,
+		RedisPrefix:     "carrag",
+		LogLevel:        utils.INFO,
+		LogFormat:       utils.LogFormatText,
+		LogRotation: LogRotationConfig{
+			MaxSizeMB:  100,
+			MaxBackups: 30,
+			MaxAge:     30 * 24 * time.Hour,
+			Compress:   true,
+		},
+		RedisBreaker: RedisBreakerConfig{
+			FailureThreshold: 5,
+			ProbeInterval:    5 * time.Second,
+		},
+		CorsOrigins: []string{"http://localhost:3000", "http://localhost:5173"},
+		RateLimit: RateLimitConfig{
+			Enabled:   true,
+			KeyHeader: "X-API-Key",
+			UseRedis:  true,
+			Default:   RateLimitRule{Rate: 20, Burst: 40},
+			Routes: map[string]RateLimitRule{
+				// 列表接口每次都会读取整个数据文件，限制更严格
+				"GET /api/cars": {Rate: 5, Burst: 10},
+			},
+		},
+		CacheTTL: 24 * time.Hour,
+		CachePolicy: CachePolicyConfig{
+			NegativeTTL: 30 * time.Second,
+			TTLJitter:   0.1,
+		},
+		CacheBackend: CacheBackendRedis,
+		LocalCache: LocalCacheConfig{
+			MaxEntries:   10000,
+			MaxTTL:       time.Minute,
+			Invalidation: true,
+		},
+		Tracing: TracingConfig{
+			Exporter:    tracing.ExporterNone,
+			SampleRatio: 1,
+			ServiceName: "carrag",
+		},
+		MaintenanceSchedule: MaintenanceScheduleConfig{
+			Default: []models.ServiceInterval{
+				{Type: models.MaintenanceOilChange, Km: 5000, Months: 6},
+				{Type: models.MaintenanceInspection, Km: 10000, Months: 12},
+				{Type: models.MaintenanceTire, Km: 10000},
+				{Type: models.MaintenanceBrake, Km: 30000, Months: 24},
+			},
+		},
+		FuelLog: FuelLogConfig{
+			RollingWindow: 3,
+		},
+		Odometer: OdometerConfig{
+			RollbackPolicy:      models.OdometerRollbackReject,
+			DeriveAnnualMileage: false,
+			MinSpanDays:         30,
+		},
+		TCO: TCOConfig{
+			FuelPrices: map[string]float64{
+				models.Energy92:          7.3,
+				models.Energy95:          7.8,
+				models.EnergyDiesel:      7.0,
+				models.EnergyElectricity: 0.8,
+			},
+			FuelTypes: map[string]string{
+				"汽油":   models.Energy92,
+				"柴油":   models.EnergyDiesel,
+				"电动":   models.EnergyElectricity,
+				"混合动力": models.Energy92,
+				"插电混动": models.Energy92,
+			},
+			InsurancePerYear: 4000,
+			DepreciationRate: 0.15,
+		},
+		Search: SearchConfig{
+			RefreshInterval: 5 * time.Minute,
+			MaxResults:      100,
+		},
+		RAG: RAGConfig{
+			Embedder:        rag.EmbedderHash,
+			Dimensions:      rag.DefaultHashDimensions,
+			Timeout:         10 * time.Second,
+			BatchSize:       models.DefaultRAGBatchSize,
+			RefreshInterval: 5 * time.Minute,
+			MaxTopK:         50,
+		},
+		Recommendation: RecommendationConfig{
+			Weights: RecommendationWeightsConfig{
+				FuelEconomy:        0.3,
+				UsageScenario:      0.35,
+				AnnualMileage:      0.25,
+				StorageEnvironment: 0.1,
+			},
+		},
+	}
+}
+
+// Validate 校验配置是否合法，返回所有不合法的配置项
+func (c *AppConfig) Validate() error {
+	var errs []error
+
+	if c.ServerPort < 1 || c.ServerPort > 65535 {
+		errs = append(errs, fmt.Errorf("serverPort 必须在 1-65535 之间: %d", c.ServerPort))
+	}
+	switch c.GinMode {
+	case gin.DebugMode, gin.ReleaseMode, gin.TestMode:
+	default:
+		errs = append(errs, fmt.Errorf("ginMode 必须是 debug、release 或 test: %q", c.GinMode))
+	}
+	if c.ReadTimeout < 0 || c.WriteTimeout < 0 || c.IdleTimeout < 0 {
+		errs = append(errs, errors.New("readTimeout、writeTimeout、idleTimeout 不能为负数"))
+	}
+	if c.RequestTimeout < 0 {
+		errs = append(errs, fmt.Errorf("requestTimeout 不能为负数: %v", c.RequestTimeout))
+	}
+	if c.ShutdownTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("shutdownTimeout 必须大于0: %v", c.ShutdownTimeout))
+	}
+	if c.LogDir == "" {
+		errs = append(errs, errors.New("logDir 不能为空"))
+	}
+	if c.DataDir == "" {
+		errs = append(errs, errors.New("dataDir 不能为空"))
+	}
+	if c.RedisAddr == "" {
+		errs = append(errs, errors.New("redisAddr 不能为空"))
+	}
+	if c.RedisDB < 0 {
+		errs = append(errs, fmt.Errorf("redisDB 不能为负数: %d", c.RedisDB))
+	}
+	if c.RedisBreaker.FailureThreshold <= 0 {
+		errs = append(errs, fmt.Errorf("redisBreaker.failureThreshold 必须大于0: %d", c.RedisBreaker.FailureThreshold))
+	}
+	if c.RedisBreaker.ProbeInterval <= 0 {
+		errs = append(errs, fmt.Errorf("redisBreaker.probeInterval 必须大于0: %v", c.RedisBreaker.ProbeInterval))
+	}
+	if c.LogLevel < utils.DEBUG || c.LogLevel > utils.FATAL {
+		errs = append(errs, fmt.Errorf("logLevel 无效: %d", c.LogLevel))
+	}
+	if _, err := utils.ParseLogFormat(string(c.LogFormat)); err != nil {
+		errs = append(errs, fmt.Errorf("logFormat 必须是 text 或 json: %q", c.LogFormat))
+	}
+
+	if c.LogRotation.MaxSizeMB < 0 || c.LogRotation.MaxBackups < 0 || c.LogRotation.MaxAge < 0 {
+		errs = append(errs, errors.New("logRotation 的 maxSizeMB、maxBackups、maxAge 不能为负数"))
+	}
+
+	if len(c.CorsOrigins) == 0 {
+		errs = append(errs, errors.New("corsOrigins 不能为空"))
+	}
+	for _, origin := range c.CorsOrigins {
+		if origin == "*" {
+			continue
+		}
+		if u, err := url.Parse(origin); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("corsOrigins 包含无效来源: %q", origin))
+		}
+	}
+	// cors.New 遇到不支持的来源(如 ftp://)会panic，热加载时需在替换中间件前拒绝
+	if err := c.GetCorsConfig().Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("corsOrigins 不合法: %w", err))
+	}
+
+	if c.CacheTTL <= 0 {
+		errs = append(errs, fmt.Errorf("cacheTTL 必须大于0: %v", c.CacheTTL))
+	}
+
+	if c.CachePolicy.NegativeTTL < 0 || c.CachePolicy.StaleWhileRevalidate < 0 {
+		errs = append(errs, errors.New("cachePolicy 的 negativeTTL、staleWhileRevalidate 不能为负数"))
+	}
+	if c.CachePolicy.TTLJitter < 0 || c.CachePolicy.TTLJitter >= 1 {
+		errs = append(errs, fmt.Errorf("cachePolicy.ttlJitter 必须在 [0, 1) 之间: %v", c.CachePolicy.TTLJitter))
+	}
+
+	switch c.CacheBackend {
+	case CacheBackendRedis, CacheBackendLRU, CacheBackendTiered, CacheBackendNone:
+	default:
+		errs = append(errs, fmt.Errorf("cacheBackend 必须是 redis、lru、tiered 或 none: %q", c.CacheBackend))
+	}
+	if c.LocalCache.MaxEntries <= 0 {
+		errs = append(errs, fmt.Errorf("localCache.maxEntries 必须大于0: %d", c.LocalCache.MaxEntries))
+	}
+	if c.LocalCache.MaxTTL < 0 {
+		errs = append(errs, fmt.Errorf("localCache.maxTTL 不能为负数: %v", c.LocalCache.MaxTTL))
+	}
+
+	switch c.Tracing.Exporter {
+	case tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOTLP:
+	default:
+		errs = append(errs, fmt.Errorf("tracing.exporter 必须是 none、stdout 或 otlp: %q", c.Tracing.Exporter))
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		errs = append(errs, fmt.Errorf("tracing.sampleRatio 必须在 0-1 之间: %v", c.Tracing.SampleRatio))
 	}
+	if c.Tracing.ServiceName == "" {
+		errs = append(errs, errors.New("tracing.serviceName 不能为空"))
+	}
+
+	errs = append(errs, validateServiceIntervals("maintenanceSchedule.default", c.MaintenanceSchedule.Default)...)
+	for key, intervals := range c.MaintenanceSchedule.Overrides {
+		errs = append(errs, validateServiceIntervals(fmt.Sprintf("maintenanceSchedule.overrides[%q]", key), intervals)...)
+	}
+
+	if c.FuelLog.RollingWindow <= 0 {
+		errs = append(errs, fmt.Errorf("fuelLog.rollingWindow 必须大于0: %d", c.FuelLog.RollingWindow))
+	}
+
+	switch c.Odometer.RollbackPolicy {
+	case models.OdometerRollbackReject, models.OdometerRollbackFlag:
+	default:
+		errs = append(errs, fmt.Errorf("odometer.rollbackPolicy 必须是 reject 或 flag: %q", c.Odometer.RollbackPolicy))
+	}
+	if c.Odometer.MinSpanDays <= 0 {
+		errs = append(errs, fmt.Errorf("odometer.minSpanDays 必须大于0: %d", c.Odometer.MinSpanDays))
+	}
+
+	for energy, price := range c.TCO.FuelPrices {
+		if price < 0 {
+			errs = append(errs, fmt.Errorf("tco.fuelPrices[%q] 不能为负数: %v", energy, price))
+		}
+	}
+	for fuelType, energy := range c.TCO.FuelTypes {
+		if _, ok := c.TCO.FuelPrices[energy]; !ok {
+			errs = append(errs, fmt.Errorf("tco.fuelTypes[%q] 对应的能源 %q 未配置单价", fuelType, energy))
+		}
+	}
+	if c.TCO.InsurancePerYear < 0 {
+		errs = append(errs, fmt.Errorf("tco.insurancePerYear 不能为负数: %v", c.TCO.InsurancePerYear))
+	}
+	if c.TCO.DepreciationRate < 0 || c.TCO.DepreciationRate >= 1 {
+		errs = append(errs, fmt.Errorf("tco.depreciationRate 必须在 [0, 1) 之间: %v", c.TCO.DepreciationRate))
+	}
+
+	if c.Search.RefreshInterval < 0 {
+		errs = append(errs, fmt.Errorf("search.refreshInterval 不能为负数: %v", c.Search.RefreshInterval))
+	}
+	if c.Search.MaxResults < models.DefaultSearchLimit {
+		errs = append(errs, fmt.Errorf("search.maxResults 不能小于默认结果数 %d: %d", models.DefaultSearchLimit, c.Search.MaxResults))
+	}
+
+	switch c.RAG.Embedder {
+	case rag.EmbedderHash:
+		if c.RAG.Dimensions <= 0 {
+			errs = append(errs, fmt.Errorf("rag.dimensions 必须大于0: %d", c.RAG.Dimensions))
+		}
+	case rag.EmbedderHTTP:
+		if c.RAG.Endpoint == "" {
+			errs = append(errs, fmt.Errorf("rag.embedder 为 %s 时必须配置 rag.endpoint", rag.EmbedderHTTP))
+		}
+		if c.RAG.Model == "" {
+			errs = append(errs, fmt.Errorf("rag.embedder 为 %s 时必须配置 rag.model", rag.EmbedderHTTP))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("rag.embedder 必须是 %s 或 %s: %q", rag.EmbedderHash, rag.EmbedderHTTP, c.RAG.Embedder))
+	}
+	if c.RAG.Timeout <= 0 {
+		errs = append(errs, fmt.Errorf("rag.timeout 必须大于0: %v", c.RAG.Timeout))
+	}
+	if c.RAG.BatchSize <= 0 {
+		errs = append(errs, fmt.Errorf("rag.batchSize 必须大于0: %d", c.RAG.BatchSize))
+	}
+	if c.RAG.RefreshInterval < 0 {
+		errs = append(errs, fmt.Errorf("rag.refreshInterval 不能为负数: %v", c.RAG.RefreshInterval))
+	}
+	if c.RAG.MaxTopK < models.DefaultRAGTopK {
+		errs = append(errs, fmt.Errorf("rag.maxTopK 不能小于默认结果数 %d: %d", models.DefaultRAGTopK, c.RAG.MaxTopK))
+	}
+
+	weights := c.Recommendation.Weights
+	for _, w := range []struct {
+		name  string
+		value float64
+	}{
+		{"fuelEconomy", weights.FuelEconomy},
+		{"usageScenario", weights.UsageScenario},
+		{"annualMileage", weights.AnnualMileage},
+		{"storageEnvironment", weights.StorageEnvironment},
+	} {
+		if w.value < 0 {
+			errs = append(errs, fmt.Errorf("recommendation.weights.%s 不能为负数: %v", w.name, w.value))
+		}
+	}
+	if weights.FuelEconomy+weights.UsageScenario+weights.AnnualMileage+weights.StorageEnvironment <= 0 {
+		errs = append(errs, fmt.Errorf("recommendation.weights 至少有一项必须大于0"))
+	}
+
+	errs = append(errs, validateRateLimitRule("rateLimit.default", c.RateLimit.Default)...)
+	for route, rule := range c.RateLimit.Routes {
+		errs = append(errs, validateRateLimitRule(fmt.Sprintf("rateLimit.routes[%q]", route), rule)...)
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateRateLimitRule 校验单条限流规则
+func validateRateLimitRule(name string, rule RateLimitRule) []error {
+	var errs []error
+	if rule.Rate < 0 {
+		errs = append(errs, fmt.Errorf("%s.rate 不能为负数: %v", name, rule.Rate))
+	}
+	if rule.Burst < 0 {
+		errs = append(errs, fmt.Errorf("%s.burst 不能为负数: %d", name, rule.Burst))
+	}
+	return errs
 }
 
 // GetCorsConfig 获取CORS配置
-func GetCorsConfig() cors.Config {
+func (c *AppConfig) GetCorsConfig() cors.Config {
 	return cors.Config{
-		AllowOrigins:     []string{"http://localhost:3000", "http://localhost:5173"},
+		AllowOrigins:     c.CorsOrigins,
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     c.corsAllowHeaders(),
+		ExposeHeaders:    []string{"Content-Length", "X-Request-ID"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}
 }
+
+// corsAllowHeaders 返回允许跨域请求携带的请求头，包含限流使用的API Key请求头，使浏览器客户端可以按API Key限流
+func (c *AppConfig) corsAllowHeaders() []string {
+	headers := []string{"Origin", "Content-Type", "Accept", "X-Request-ID"}
+	if key := c.RateLimit.KeyHeader; key != "" {
+		for _, header := range headers {
+			if strings.EqualFold(header, key) {
+				return headers
+			}
+		}
+		headers = append(headers, key)
+	}
+	return headers
+}
+
+// validateServiceIntervals 校验保养间隔
+func validateServiceIntervals(name string, intervals []models.ServiceInterval) []error {
+	var errs []error
+	for i, interval := range intervals {
+		if !models.IsMaintenanceType(interval.Type) {
+			errs = append(errs, fmt.Errorf("%s[%d].type 不是支持的保养类型: %q", name, i, interval.Type))
+		}
+		if interval.Km < 0 || interval.Months < 0 {
+			errs = append(errs, fmt.Errorf("%s[%d] 的 km、months 不能为负数", name, i))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gin-contrib/cors"
+)
+
+func TestValidateCorsOrigins(t *testing.T) {
+	tests := []struct {
+		name    string
+		origins []string
+		wantErr bool
+	}{
+		{"http和https来源", []string{"http://localhost:5173", "https://cars.example.com"}, false},
+		{"允许所有来源", []string{"*"}, false},
+		{"缺少协议", []string{"localhost:5173"}, true},
+		{"跨域中间件不支持的协议", []string{"ftp://x"}, true},
+		{"为空", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := NewDefaultConfig()
+			cfg.CorsOrigins = tt.origins
+			err := cfg.Validate()
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("Validate() 错误为 %v，期望出错=%v", err, tt.wantErr)
+			}
+			if err == nil {
+				// 校验通过的配置必须能创建跨域中间件而不panic
+				cors.New(cfg.GetCorsConfig())
+			}
+		})
+	}
+}
+
+func TestCorsAllowsRateLimitKeyHeader(t *testing.T) {
+	tests := []struct {
+		name      string
+		keyHeader string
+		want      []string
+	}{
+		{"默认的API Key请求头", "X-API-Key", []string{"Origin", "Content-Type", "Accept", "X-Request-ID", "X-API-Key"}},
+		{"自定义请求头", "X-Client-Token", []string{"Origin", "Content-Type", "Accept", "X-Request-ID", "X-Client-Token"}},
+		{"未配置时不添加", "", []string{"Origin", "Content-Type", "Accept", "X-Request-ID"}},
+		{"已包含的请求头不重复添加", "x-request-id", []string{"Origin", "Content-Type", "Accept", "X-Request-ID"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := NewDefaultConfig()
+			cfg.RateLimit.KeyHeader = tt.keyHeader
+			if got := cfg.GetCorsConfig().AllowHeaders; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AllowHeaders = %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,265 @@
+package config
+
+import (
+	"encoding"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix 环境变量前缀
+const EnvPrefix = "CARRAG_"
+
+// defaultConfigFiles 未指定配置文件时依次尝试加载的文件
+var defaultConfigFiles = []string{"config.yaml", "config.yml", "config.toml"}
+
+// field 可通过环境变量和命令行参数覆盖的配置项
+type field struct {
+	name   string        // 以下划线连接的名称，如 RATE_LIMIT_DEFAULT_RATE
+	value  reflect.Value // 字段值
+	secret bool          // 是否为敏感信息
+}
+
+// envName 返回配置项对应的环境变量名
+func (f field) envName() string {
+	return EnvPrefix + f.name
+}
+
+// flagName 返回配置项对应的命令行参数名
+func (f field) flagName() string {
+	return strings.ToLower(strings.ReplaceAll(f.name, "_", "-"))
+}
+
+// flagValue 将命令行参数暂存，待配置文件和环境变量加载后再应用
+type flagValue struct {
+	field  field
+	isBool bool
+	raw    *string
+}
+
+func (v *flagValue) String() string {
+	if v.raw == nil {
+		return ""
+	}
+	return *v.raw
+}
+
+func (v *flagValue) Set(s string) error {
+	v.raw = &s
+	return nil
+}
+
+// IsBoolFlag 布尔参数允许省略取值
+func (v *flagValue) IsBoolFlag() bool {
+	return v.isBool
+}
+
+// Load 按默认值、配置文件、环境变量、命令行参数的顺序加载配置并校验
+// 配置文件路径由 -config 参数或 CARRAG_CONFIG 环境变量指定，
+// 均未指定时尝试加载工作目录下的 config.yaml、config.yml 或 config.toml
+func Load(name string, args []string) (*AppConfig, string, error) {
+	cfg := NewDefaultConfig()
+	fields := collectFields(reflect.ValueOf(cfg).Elem(), "")
+
+	// 注册命令行参数
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv(EnvPrefix+"CONFIG"), "配置文件路径(YAML/TOML)")
+	flagValues := make([]*flagValue, 0, len(fields))
+	for _, f := range fields {
+		fv := &flagValue{field: f, isBool: f.value.Kind() == reflect.Bool}
+		fs.Var(fv, f.flagName(), fmt.Sprintf("覆盖配置项，对应环境变量 %s", f.envName()))
+		flagValues = append(flagValues, fv)
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, "", err
+	}
+
+	// 加载配置文件
+	path := *configFile
+	if path == "" {
+		path = findDefaultConfigFile()
+	}
+	if path != "" {
+		if err := loadFile(path, cfg); err != nil {
+			return nil, "", err
+		}
+	}
+
+	// 环境变量覆盖配置文件
+	for _, f := range fields {
+		raw, ok := os.LookupEnv(f.envName())
+		if !ok {
+			continue
+		}
+		if err := setValue(f.value, raw); err != nil {
+			return nil, "", fmt.Errorf("环境变量 %s 无效: %w", f.envName(), err)
+		}
+	}
+
+	// 命令行参数优先级最高
+	for _, fv := range flagValues {
+		if fv.raw == nil {
+			continue
+		}
+		if err := setValue(fv.field.value, *fv.raw); err != nil {
+			return nil, "", fmt.Errorf("命令行参数 -%s 无效: %w", fv.field.flagName(), err)
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, "", fmt.Errorf("配置校验失败: %w", err)
+	}
+
+	return cfg, path, nil
+}
+
+// findDefaultConfigFile 查找工作目录下的默认配置文件
+func findDefaultConfigFile() string {
+	for _, name := range defaultConfigFiles {
+		if _, err := os.Stat(name); err == nil {
+			return name
+		}
+	}
+	return ""
+}
+
+// loadFile 从配置文件加载配置，根据扩展名选择格式
+func loadFile(path string, cfg *AppConfig) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("读取配置文件失败: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, cfg)
+	case ".toml":
+		// TOML先解析为通用结构再经YAML转换，与YAML共用字段名和取值规则（如 "24h" 形式的时长）
+		var raw map[string]interface{}
+		if err = toml.Unmarshal(data, &raw); err == nil {
+			var converted []byte
+			if converted, err = yaml.Marshal(raw); err == nil {
+				err = yaml.Unmarshal(converted, cfg)
+			}
+		}
+	default:
+		return fmt.Errorf("不支持的配置文件格式: %s", path)
+	}
+	if err != nil {
+		return fmt.Errorf("解析配置文件 %s 失败: %w", path, err)
+	}
+	return nil
+}
+
+// collectFields 递归收集带有env标签的配置项
+func collectFields(v reflect.Value, prefix string) []field {
+	var fields []field
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("env")
+		if tag == "" || !sf.IsExported() {
+			continue
+		}
+
+		name := prefix + tag
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct && !isTextValue(fv) {
+			fields = append(fields, collectFields(fv, name+"_")...)
+			continue
+		}
+
+		fields = append(fields, field{
+			name:   name,
+			value:  fv,
+			secret: sf.Tag.Get("secret") == "true",
+		})
+	}
+	return fields
+}
+
+// isTextValue 判断字段是否自行实现了文本解析
+func isTextValue(v reflect.Value) bool {
+	_, ok := v.Addr().Interface().(encoding.TextUnmarshaler)
+	return ok
+}
+
+// setValue 将字符串解析后写入配置项
+func setValue(v reflect.Value, raw string) error {
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(raw))
+	}
+
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("不支持的配置类型: %s", v.Type())
+		}
+		// 以逗号分隔的列表
+		items := make([]string, 0)
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("不支持的配置类型: %s", v.Type())
+	}
+	return nil
+}
+
+// Print 以YAML格式输出生效的配置，敏感信息会被掩码
+func Print(w io.Writer, cfg *AppConfig) error {
+	masked := *cfg
+	for _, f := range collectFields(reflect.ValueOf(&masked).Elem(), "") {
+		if f.secret && f.value.Kind() == reflect.String && f.value.String() != "" {
+			f.value.SetString("******")
+		}
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&masked); err != nil {
+		return fmt.Errorf("输出配置失败: %w", err)
+	}
+	return encoder.Close()
+}
@@ -0,0 +1,200 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/jasonzheng/carrag/utils"
+)
+
+// watchInterval 检查配置文件是否变更的间隔
+const watchInterval = 2 * time.Second
+
+// Change 配置项变更
+type Change struct {
+	Field      string      // 配置项名称
+	Old        interface{} // 旧值
+	New        interface{} // 新值
+	Reloadable bool        // 是否支持热加载
+
+	index int // 字段在AppConfig中的位置
+}
+
+// String 返回变更描述
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %v -> %v", c.Field, c.Old, c.New)
+}
+
+// Diff 比较两份配置的顶层配置项，返回发生变更的配置项
+func Diff(oldCfg, newCfg *AppConfig) []Change {
+	var changes []Change
+	oldValue := reflect.ValueOf(oldCfg).Elem()
+	newValue := reflect.ValueOf(newCfg).Elem()
+	t := oldValue.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		oldField := oldValue.Field(i).Interface()
+		newField := newValue.Field(i).Interface()
+		if reflect.DeepEqual(oldField, newField) {
+			continue
+		}
+
+		name := strings.Split(sf.Tag.Get("yaml"), ",")[0]
+		if name == "" {
+			name = sf.Name
+		}
+		change := Change{
+			Field:      name,
+			Old:        oldField,
+			New:        newField,
+			Reloadable: sf.Tag.Get("reload") == "true",
+			index:      i,
+		}
+		if sf.Tag.Get("secret") == "true" {
+			change.Old, change.New = "******", "******"
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// Watcher 监听配置文件变更和SIGHUP信号，重新加载配置并应用可热加载的配置项
+type Watcher struct {
+	name    string
+	args    []string
+	path    string
+	logger  *utils.Logger
+	current *AppConfig
+	modTime time.Time
+
+	mu       sync.Mutex
+	handlers []func(*AppConfig)
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewWatcher 创建配置监听器，name和args与启动时传给Load的参数一致，
+// 以保证重新加载时环境变量和命令行参数的覆盖关系不变
+func NewWatcher(name string, args []string, path string, current *AppConfig, logger *utils.Logger) *Watcher {
+	w := &Watcher{
+		name:    name,
+		args:    args,
+		path:    path,
+		logger:  logger,
+		current: current,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	w.modTime = w.fileModTime()
+	return w
+}
+
+// OnReload 注册配置重新加载后的回调，回调只会收到校验通过的新配置
+// 回调在持有锁时执行，不能在回调中再调用Watcher的方法
+func (w *Watcher) OnReload(handler func(*AppConfig)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.handlers = append(w.handlers, handler)
+}
+
+// Start 在后台开始监听
+func (w *Watcher) Start() {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		defer close(w.done)
+		defer signal.Stop(hup)
+
+		ticker := time.NewTicker(watchInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-w.stop:
+				return
+			case <-hup:
+				w.logger.Info("收到SIGHUP信号，重新加载配置")
+				w.Reload()
+			case <-ticker.C:
+				if w.path == "" {
+					continue
+				}
+				if modTime := w.fileModTime(); !modTime.Equal(w.modTime) {
+					w.modTime = modTime
+					w.logger.Info("检测到配置文件变更: %s", w.path)
+					w.Reload()
+				}
+			}
+		}
+	}()
+
+	if w.path != "" {
+		w.logger.Info("开始监听配置文件: %s", w.path)
+	}
+}
+
+// Stop 停止监听
+func (w *Watcher) Stop() {
+	close(w.stop)
+	<-w.done
+}
+
+// Reload 重新加载并校验配置，校验失败时保留当前配置
+func (w *Watcher) Reload() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	newCfg, _, err := Load(w.name, w.args)
+	if err != nil {
+		w.logger.Error("重新加载配置失败，继续使用当前配置: %v", err)
+		return
+	}
+
+	changes := Diff(w.current, newCfg)
+	if len(changes) == 0 {
+		w.logger.Info("配置未发生变化")
+		return
+	}
+
+	// 需要重启才能生效的配置项保持原值，避免与实际运行状态不一致
+	applied := *w.current
+	appliedValue := reflect.ValueOf(&applied).Elem()
+	newValue := reflect.ValueOf(newCfg).Elem()
+	reloaded := 0
+	for _, change := range changes {
+		if !change.Reloadable {
+			w.logger.Warning("配置项 %s 需要重启才能生效", change.Field)
+			continue
+		}
+		appliedValue.Field(change.index).Set(newValue.Field(change.index))
+		reloaded++
+		w.logger.Info("配置项已更新: %s", change)
+	}
+	if reloaded == 0 {
+		return
+	}
+	w.current = &applied
+
+	for _, handler := range w.handlers {
+		handler(&applied)
+	}
+}
+
+// fileModTime 获取配置文件的修改时间
+func (w *Watcher) fileModTime() time.Time {
+	if w.path == "" {
+		return time.Time{}
+	}
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
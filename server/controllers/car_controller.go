@@ -1,13 +1,19 @@
 package controllers
 
 import (
+	"context"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jasonzheng/carrag/middleware"
 	"github.com/jasonzheng/carrag/models"
 	"github.com/jasonzheng/carrag/utils"
 )
 
+// statusClientClosedRequest 客户端在响应前断开连接时记录的状态码(沿用nginx的约定)
+const statusClientClosedRequest = 499
+
 // CarController 车辆控制器
 type CarController struct {
 	CarService *models.CarService // 车辆服务
@@ -32,12 +38,52 @@ func (c *CarController) RegisterRoutes(router *gin.RouterGroup) {
 	router.GET("/cars/brand/:brand", c.GetCarsByBrand)
 }
 
+// abortIfContextDone 请求被取消或处理超时时写入响应并返回true
+// 客户端已断开时无需返回内容，仅记录状态码499
+func abortIfContextDone(ctx *gin.Context, logger *utils.Logger, err error) bool {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		logger.Warning("请求处理超时: %v", err)
+		ctx.JSON(http.StatusGatewayTimeout, gin.H{"error": "请求处理超时"})
+		return true
+	case errors.Is(err, context.Canceled):
+		logger.Info("客户端已断开，停止处理: %v", err)
+		ctx.AbortWithStatus(statusClientClosedRequest)
+		return true
+	}
+	return false
+}
+
+// respondError 按错误类型写入响应：数据无效返回400，数据不存在返回404，其他错误记录日志并返回500
+// notFound为数据不存在时返回的说明，action为其他错误时返回的说明
+func respondError(ctx *gin.Context, logger *utils.Logger, err error, notFound, action string) {
+	if abortIfContextDone(ctx, logger, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, utils.ErrInvalid):
+		logger.Warning("%s: %v", action, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, utils.ErrNotFound):
+		logger.Warning("%s: %v", action, err)
+		ctx.JSON(http.StatusNotFound, gin.H{"error": notFound})
+	default:
+		logger.Error("%s: %v", action, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": action})
+	}
+}
+
 // GetCars 获取所有车辆信息
 func (c *CarController) GetCars(ctx *gin.Context) {
+	logger := middleware.RequestLogger(ctx, c.Logger)
+
 	// 使用服务层获取所有车辆信息
-	cars, err := c.CarService.GetAllCars()
+	cars, err := c.CarService.GetAllCars(ctx.Request.Context())
 	if err != nil {
-		c.Logger.Error("获取车辆信息失败: %v", err)
+		if abortIfContextDone(ctx, logger, err) {
+			return
+		}
+		logger.Error("获取车辆信息失败: %v", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "获取车辆信息失败"})
 		return
 	}
@@ -47,12 +93,22 @@ func (c *CarController) GetCars(ctx *gin.Context) {
 
 // GetCarByID 根据ID获取车辆信息
 func (c *CarController) GetCarByID(ctx *gin.Context) {
+	logger := middleware.RequestLogger(ctx, c.Logger)
+
 	id := ctx.Param("id")
 
 	// 使用服务层获取车辆信息
-	car, err := c.CarService.GetCarByID(id)
+	car, err := c.CarService.GetCarByID(ctx.Request.Context(), id)
 	if err != nil {
-		c.Logger.Warning("获取车辆信息失败: %v", err)
+		if abortIfContextDone(ctx, logger, err) {
+			return
+		}
+		if !errors.Is(err, utils.ErrNotFound) {
+			logger.Error("获取车辆信息失败: %v", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "获取车辆信息失败"})
+			return
+		}
+		logger.Warning("获取车辆信息失败: %v", err)
 		ctx.JSON(http.StatusNotFound, gin.H{"error": "车辆信息不存在"})
 		return
 	}
@@ -62,11 +118,13 @@ func (c *CarController) GetCarByID(ctx *gin.Context) {
 
 // CreateCar 创建车辆信息
 func (c *CarController) CreateCar(ctx *gin.Context) {
+	logger := middleware.RequestLogger(ctx, c.Logger)
+
 	var car models.Car
 
 	// 解析请求体
 	if err := ctx.ShouldBindJSON(&car); err != nil {
-		c.Logger.Warning("解析请求体失败: %v", err)
+		logger.Warning("解析请求体失败: %v", err)
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "请求数据格式错误"})
 		return
 	}
@@ -75,9 +133,8 @@ func (c *CarController) CreateCar(ctx *gin.Context) {
 	// 不需要在控制器中设置
 
 	// 使用服务层创建车辆信息
-	if err := c.CarService.CreateCar(&car); err != nil {
-		c.Logger.Error("创建车辆信息失败: %v", err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "创建车辆信息失败"})
+	if err := c.CarService.CreateCar(ctx.Request.Context(), &car); err != nil {
+		respondError(ctx, logger, err, "车辆信息不存在", "创建车辆信息失败")
 		return
 	}
 
@@ -86,25 +143,35 @@ func (c *CarController) CreateCar(ctx *gin.Context) {
 
 // UpdateCar 更新车辆信息
 func (c *CarController) UpdateCar(ctx *gin.Context) {
+	logger := middleware.RequestLogger(ctx, c.Logger)
+
 	id := ctx.Param("id")
-	var car models.Car
+
+	// 以现有车辆信息为基础解析请求体，请求中未包含的字段(如购车价格、保险费用)保留原值
+	car, err := c.CarService.GetCarByID(ctx.Request.Context(), id)
+	if err != nil {
+		respondError(ctx, logger, err, "车辆信息不存在", "更新车辆信息失败")
+		return
+	}
+	createdAt := car.CreatedAt
 
 	// 解析请求体
-	if err := ctx.ShouldBindJSON(&car); err != nil {
-		c.Logger.Warning("解析请求体失败: %v", err)
+	if err := ctx.ShouldBindJSON(car); err != nil {
+		logger.Warning("解析请求体失败: %v", err)
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "请求数据格式错误"})
 		return
 	}
 
-	// 确保ID一致
+	// 确保ID和创建时间不被请求修改
 	car.ID = id
+	car.CreatedAt = createdAt
 
 	// 更新时间会在服务层设置
 
 	// 使用服务层更新车辆信息
-	if err := c.CarService.UpdateCar(&car); err != nil {
-		c.Logger.Error("更新车辆信息失败: %v", err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "更新车辆信息失败"})
+	// 行驶里程低于之前的里程表读数时返回400
+	if err := c.CarService.UpdateCar(ctx.Request.Context(), car); err != nil {
+		respondError(ctx, logger, err, "车辆信息不存在", "更新车辆信息失败")
 		return
 	}
 
@@ -113,11 +180,16 @@ func (c *CarController) UpdateCar(ctx *gin.Context) {
 
 // DeleteCar 删除车辆信息
 func (c *CarController) DeleteCar(ctx *gin.Context) {
+	logger := middleware.RequestLogger(ctx, c.Logger)
+
 	id := ctx.Param("id")
 
 	// 使用服务层删除车辆信息
-	if err := c.CarService.DeleteCar(id); err != nil {
-		c.Logger.Error("删除车辆信息失败: %v", err)
+	if err := c.CarService.DeleteCar(ctx.Request.Context(), id); err != nil {
+		if abortIfContextDone(ctx, logger, err) {
+			return
+		}
+		logger.Error("删除车辆信息失败: %v", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "删除车辆信息失败"})
 		return
 	}
@@ -127,12 +199,17 @@ func (c *CarController) DeleteCar(ctx *gin.Context) {
 
 // GetCarsByBrand 根据品牌获取车辆信息
 func (c *CarController) GetCarsByBrand(ctx *gin.Context) {
+	logger := middleware.RequestLogger(ctx, c.Logger)
+
 	brand := ctx.Param("brand")
 
 	// 使用服务层获取车辆信息
-	cars, err := c.CarService.FindCarsByBrand(brand)
+	cars, err := c.CarService.FindCarsByBrand(ctx.Request.Context(), brand)
 	if err != nil {
-		c.Logger.Error("获取车辆信息失败: %v", err)
+		if abortIfContextDone(ctx, logger, err) {
+			return
+		}
+		logger.Error("获取车辆信息失败: %v", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "获取车辆信息失败"})
 		return
 	}
@@ -0,0 +1,136 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jasonzheng/carrag/models"
+	"github.com/jasonzheng/carrag/repositories"
+	"github.com/jasonzheng/carrag/utils"
+)
+
+// newTestLogger 创建输出到临时目录的日志记录器，只输出致命错误
+func newTestLogger(t *testing.T) *utils.Logger {
+	t.Helper()
+	logger, err := utils.NewLogger(t.TempDir(), utils.FATAL, utils.RotateOptions{})
+	if err != nil {
+		t.Fatalf("创建日志记录器失败: %v", err)
+	}
+	t.Cleanup(func() { logger.Close() })
+	return logger
+}
+
+// newTestCarRouter 创建使用临时目录存储车辆信息的路由
+func newTestCarRouter(t *testing.T) (*gin.Engine, *models.CarService) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	logger := newTestLogger(t)
+	storage, err := utils.NewStorage(t.TempDir(), logger)
+	if err != nil {
+		t.Fatalf("创建存储失败: %v", err)
+	}
+	service := models.NewCarService(repositories.NewFileCarRepository(storage, logger, "cars.json"), logger, nil)
+
+	router := gin.New()
+	NewCarController(service, logger).RegisterRoutes(router.Group("/api"))
+	return router, service
+}
+
+func TestUpdateCarKeepsUnsentFields(t *testing.T) {
+	purchaseDate := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		body  string
+		check func(t *testing.T, car *models.Car)
+	}{
+		{
+			name: "未包含的字段保留原值",
+			body: `{"brand": "丰田", "model": "凯美瑞"}`,
+			check: func(t *testing.T, car *models.Car) {
+				if car.Model != "凯美瑞" {
+					t.Errorf("Model = %q，期望 凯美瑞", car.Model)
+				}
+				if car.PurchasePrice != 150000 || car.AnnualInsurance != 4000 || car.PurchaseDate == nil || car.Remarks != "通勤" {
+					t.Errorf("未包含的字段被修改: %+v", car)
+				}
+			},
+		},
+		{
+			name: "显式传入空值时清空字段",
+			body: `{"purchasePrice": 0, "purchaseDate": null, "remarks": ""}`,
+			check: func(t *testing.T, car *models.Car) {
+				if car.PurchasePrice != 0 || car.PurchaseDate != nil || car.Remarks != "" {
+					t.Errorf("字段未被清空: %+v", car)
+				}
+				if car.Model != "卡罗拉" || car.AnnualInsurance != 4000 {
+					t.Errorf("未包含的字段被修改: %+v", car)
+				}
+			},
+		},
+		{
+			name: "不能修改ID和创建时间",
+			body: `{"id": "other", "createdAt": "2000-01-01T00:00:00Z"}`,
+			check: func(t *testing.T, car *models.Car) {
+				if car.CreatedAt.Year() == 2000 {
+					t.Errorf("CreatedAt 被修改为 %v", car.CreatedAt)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, service := newTestCarRouter(t)
+			existing := &models.Car{
+				Brand:           "丰田",
+				Model:           "卡罗拉",
+				PurchasePrice:   150000,
+				PurchaseDate:    &purchaseDate,
+				AnnualInsurance: 4000,
+				Remarks:         "通勤",
+			}
+			ctx := context.Background()
+			if err := service.CreateCar(ctx, existing); err != nil {
+				t.Fatalf("CreateCar 失败: %v", err)
+			}
+
+			req := httptest.NewRequest(http.MethodPut, "/api/cars/"+existing.ID, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("PUT 返回 %d: %s", w.Code, w.Body.String())
+			}
+			var response models.Car
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("解析响应失败: %v", err)
+			}
+			if response.ID != existing.ID {
+				t.Errorf("响应中的 ID = %q，期望 %q", response.ID, existing.ID)
+			}
+
+			saved, err := service.GetCarByID(ctx, existing.ID)
+			if err != nil {
+				t.Fatalf("GetCarByID 失败: %v", err)
+			}
+			tt.check(t, saved)
+		})
+	}
+}
+
+func TestUpdateCarNotFound(t *testing.T) {
+	router, _ := newTestCarRouter(t)
+	req := httptest.NewRequest(http.MethodPut, "/api/cars/missing", strings.NewReader(`{"brand": "丰田"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("PUT 不存在的车辆返回 %d，期望 404", w.Code)
+	}
+}
@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jasonzheng/carrag/middleware"
+	"github.com/jasonzheng/carrag/models"
+	"github.com/jasonzheng/carrag/utils"
+)
+
+// FuelController 加油记录控制器
+type FuelController struct {
+	FuelService *models.FuelService // 加油记录服务
+	Logger      *utils.Logger       // 日志记录器
+}
+
+// NewFuelController 创建新的加油记录控制器
+func NewFuelController(fuelService *models.FuelService, logger *utils.Logger) *FuelController {
+	return &FuelController{
+		FuelService: fuelService,
+		Logger:      logger,
+	}
+}
+
+// RegisterRoutes 注册路由
+func (c *FuelController) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/cars/:id/fuel", c.ListRecords)
+	router.POST("/cars/:id/fuel", c.CreateRecord)
+	router.GET("/cars/:id/fuel/stats", c.Stats)
+	router.GET("/cars/:id/fuel/:recordId", c.GetRecord)
+	router.PUT("/cars/:id/fuel/:recordId", c.UpdateRecord)
+	router.DELETE("/cars/:id/fuel/:recordId", c.DeleteRecord)
+}
+
+// respond 写入错误响应，区分车辆不存在和加油记录不存在
+func (c *FuelController) respond(ctx *gin.Context, logger *utils.Logger, err error, action string) {
+	notFound := "车辆信息不存在"
+	if errors.Is(err, models.ErrFuelRecordNotFound) {
+		notFound = "加油记录不存在"
+	}
+	respondError(ctx, logger, err, notFound, action)
+}
+
+// ListRecords 获取车辆的所有加油记录
+func (c *FuelController) ListRecords(ctx *gin.Context) {
+	logger := middleware.RequestLogger(ctx, c.Logger)
+
+	records, err := c.FuelService.ListRecords(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		c.respond(ctx, logger, err, "获取加油记录失败")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, records)
+}
+
+// GetRecord 获取指定的加油记录
+func (c *FuelController) GetRecord(ctx *gin.Context) {
+	logger := middleware.RequestLogger(ctx, c.Logger)
+
+	record, err := c.FuelService.GetRecord(ctx.Request.Context(), ctx.Param("id"), ctx.Param("recordId"))
+	if err != nil {
+		c.respond(ctx, logger, err, "获取加油记录失败")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, record)
+}
+
+// CreateRecord 为车辆创建加油记录
+func (c *FuelController) CreateRecord(ctx *gin.Context) {
+	logger := middleware.RequestLogger(ctx, c.Logger)
+
+	var record models.FuelRecord
+
+	// 解析请求体
+	if err := ctx.ShouldBindJSON(&record); err != nil {
+		logger.Warning("解析请求体失败: %v", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "请求数据格式错误"})
+		return
+	}
+	record.CarID = ctx.Param("id")
+
+	if err := c.FuelService.CreateRecord(ctx.Request.Context(), &record); err != nil {
+		c.respond(ctx, logger, err, "创建加油记录失败")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, record)
+}
+
+// UpdateRecord 更新加油记录
+func (c *FuelController) UpdateRecord(ctx *gin.Context) {
+	logger := middleware.RequestLogger(ctx, c.Logger)
+
+	var record models.FuelRecord
+
+	// 解析请求体
+	if err := ctx.ShouldBindJSON(&record); err != nil {
+		logger.Warning("解析请求体失败: %v", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "请求数据格式错误"})
+		return
+	}
+
+	// 确保车辆ID和记录ID与路径一致
+	record.CarID = ctx.Param("id")
+	record.ID = ctx.Param("recordId")
+
+	if err := c.FuelService.UpdateRecord(ctx.Request.Context(), &record); err != nil {
+		c.respond(ctx, logger, err, "更新加油记录失败")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, record)
+}
+
+// DeleteRecord 删除加油记录
+func (c *FuelController) DeleteRecord(ctx *gin.Context) {
+	logger := middleware.RequestLogger(ctx, c.Logger)
+
+	if err := c.FuelService.DeleteRecord(ctx.Request.Context(), ctx.Param("id"), ctx.Param("recordId")); err != nil {
+		c.respond(ctx, logger, err, "删除加油记录失败")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "加油记录已删除"})
+}
+
+// Stats 获取车辆的油耗统计
+func (c *FuelController) Stats(ctx *gin.Context) {
+	logger := middleware.RequestLogger(ctx, c.Logger)
+
+	stats, err := c.FuelService.Stats(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		c.respond(ctx, logger, err, "计算油耗失败")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, stats)
+}
@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jasonzheng/carrag/utils"
+)
+
+// 健康状态
+const (
+	StatusOK          = "ok"          // 正常
+	StatusDegraded    = "degraded"    // 降级运行，不影响对外服务
+	StatusUnavailable = "unavailable" // 不可用
+)
+
+// checkTimeout 单项检查的超时时间
+const checkTimeout = 2 * time.Second
+
+// ComponentStatus 组件状态
+type ComponentStatus struct {
+	Name      string  `json:"name"`              // 组件名称
+	Status    string  `json:"status"`            // 状态
+	LatencyMs float64 `json:"latencyMs"`         // 检查耗时(毫秒)
+	Message   string  `json:"message,omitempty"` // 异常说明
+}
+
+// HealthReport 健康检查结果
+type HealthReport struct {
+	Status     string            `json:"status"`     // 总体状态
+	Components []ComponentStatus `json:"components"` // 各组件状态
+}
+
+// HealthController 健康检查控制器
+type HealthController struct {
+	Storage   *utils.Storage    // 文件存储管理器
+	Cache     *utils.RedisCache // Redis缓存
+	DataFiles []string          // 需要检查能否解析的数据文件
+	Logger    *utils.Logger     // 日志记录器
+}
+
+// NewHealthController 创建健康检查控制器
+func NewHealthController(storage *utils.Storage, cache *utils.RedisCache, dataFiles []string, logger *utils.Logger) *HealthController {
+	return &HealthController{
+		Storage:   storage,
+		Cache:     cache,
+		DataFiles: dataFiles,
+		Logger:    logger,
+	}
+}
+
+// RegisterRoutes 注册路由
+func (c *HealthController) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/healthz", c.Liveness)
+	router.GET("/readyz", c.Readiness)
+}
+
+// Liveness 存活探针，进程能处理请求即视为存活
+func (c *HealthController) Liveness(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"status": StatusOK})
+}
+
+// Readiness 就绪探针，检查数据目录、数据文件和Redis
+// 数据目录或数据文件异常时返回503；Redis不可用或熔断时系统降级运行，仍返回200
+func (c *HealthController) Readiness(ctx *gin.Context) {
+	components := []ComponentStatus{
+		c.check(ctx.Request.Context(), "dataDir", true, func(context.Context) error {
+			return c.Storage.CheckWritable()
+		}),
+	}
+	for _, file := range c.DataFiles {
+		file := file
+		components = append(components, c.check(ctx.Request.Context(), "dataFile:"+file, true, func(context.Context) error {
+			return c.Storage.ValidateJSON(file)
+		}))
+	}
+
+	components = append(components, c.checkRedis(ctx.Request.Context()))
+
+	report := HealthReport{Status: StatusOK, Components: components}
+	for _, component := range components {
+		switch component.Status {
+		case StatusUnavailable:
+			report.Status = StatusUnavailable
+		case StatusDegraded:
+			if report.Status == StatusOK {
+				report.Status = StatusDegraded
+			}
+		}
+	}
+
+	code := http.StatusOK
+	if report.Status == StatusUnavailable {
+		c.Logger.Warning("就绪检查未通过: %+v", report.Components)
+		code = http.StatusServiceUnavailable
+	}
+	ctx.JSON(code, report)
+}
+
+// checkRedis 检查Redis，熔断期间不再访问Redis，直接返回熔断状态
+func (c *HealthController) checkRedis(ctx context.Context) ComponentStatus {
+	status := c.Cache.Status()
+	if status.State == utils.BreakerOpen {
+		return ComponentStatus{
+			Name:    "redis",
+			Status:  StatusDegraded,
+			Message: fmt.Sprintf("Redis不可用，已熔断 %v，正在后台重连: %s", time.Since(status.Since).Round(time.Second), status.LastError),
+		}
+	}
+	return c.check(ctx, "redis", false, func(checkCtx context.Context) error {
+		return c.Cache.Ping(checkCtx)
+	})
+}
+
+// check 执行单项检查并记录耗时，critical为false的组件失败时视为降级
+func (c *HealthController) check(parent context.Context, name string, critical bool, fn func(context.Context) error) ComponentStatus {
+	checkCtx, cancel := context.WithTimeout(parent, checkTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := fn(checkCtx)
+	status := ComponentStatus{
+		Name:      name,
+		Status:    StatusOK,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		status.Message = err.Error()
+		status.Status = StatusUnavailable
+		if !critical {
+			status.Status = StatusDegraded
+		}
+	}
+	return status
+}
@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jasonzheng/carrag/middleware"
+	"github.com/jasonzheng/carrag/models"
+	"github.com/jasonzheng/carrag/utils"
+)
+
+// MaintenanceController 保养记录控制器
+type MaintenanceController struct {
+	MaintenanceService *models.MaintenanceService // 保养记录服务
+	Logger             *utils.Logger              // 日志记录器
+}
+
+// NewMaintenanceController 创建新的保养记录控制器
+func NewMaintenanceController(maintenanceService *models.MaintenanceService, logger *utils.Logger) *MaintenanceController {
+	return &MaintenanceController{
+		MaintenanceService: maintenanceService,
+		Logger:             logger,
+	}
+}
+
+// RegisterRoutes 注册路由
+func (c *MaintenanceController) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/cars/:id/maintenance", c.ListRecords)
+	router.POST("/cars/:id/maintenance", c.CreateRecord)
+	router.GET("/cars/:id/maintenance/:recordId", c.GetRecord)
+	router.PUT("/cars/:id/maintenance/:recordId", c.UpdateRecord)
+	router.DELETE("/cars/:id/maintenance/:recordId", c.DeleteRecord)
+	router.GET("/maintenance/due", c.DueServices)
+}
+
+// 查询到期保养的天数范围
+const (
+	defaultDueWithinDays = 30
+	maxDueWithinDays     = 3650
+)
+
+// respond 写入错误响应，区分车辆不存在和保养记录不存在
+func (c *MaintenanceController) respond(ctx *gin.Context, logger *utils.Logger, err error, action string) {
+	notFound := "车辆信息不存在"
+	if errors.Is(err, models.ErrMaintenanceRecordNotFound) {
+		notFound = "保养记录不存在"
+	}
+	respondError(ctx, logger, err, notFound, action)
+}
+
+// ListRecords 获取车辆的所有保养记录
+func (c *MaintenanceController) ListRecords(ctx *gin.Context) {
+	logger := middleware.RequestLogger(ctx, c.Logger)
+
+	records, err := c.MaintenanceService.ListRecords(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		c.respond(ctx, logger, err, "获取保养记录失败")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, records)
+}
+
+// GetRecord 获取指定的保养记录
+func (c *MaintenanceController) GetRecord(ctx *gin.Context) {
+	logger := middleware.RequestLogger(ctx, c.Logger)
+
+	record, err := c.MaintenanceService.GetRecord(ctx.Request.Context(), ctx.Param("id"), ctx.Param("recordId"))
+	if err != nil {
+		c.respond(ctx, logger, err, "获取保养记录失败")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, record)
+}
+
+// CreateRecord 为车辆创建保养记录
+func (c *MaintenanceController) CreateRecord(ctx *gin.Context) {
+	logger := middleware.RequestLogger(ctx, c.Logger)
+
+	var record models.MaintenanceRecord
+
+	// 解析请求体
+	if err := ctx.ShouldBindJSON(&record); err != nil {
+		logger.Warning("解析请求体失败: %v", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "请求数据格式错误"})
+		return
+	}
+	record.CarID = ctx.Param("id")
+
+	if err := c.MaintenanceService.CreateRecord(ctx.Request.Context(), &record); err != nil {
+		c.respond(ctx, logger, err, "创建保养记录失败")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, record)
+}
+
+// UpdateRecord 更新保养记录
+func (c *MaintenanceController) UpdateRecord(ctx *gin.Context) {
+	logger := middleware.RequestLogger(ctx, c.Logger)
+
+	var record models.MaintenanceRecord
+
+	// 解析请求体
+	if err := ctx.ShouldBindJSON(&record); err != nil {
+		logger.Warning("解析请求体失败: %v", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "请求数据格式错误"})
+		return
+	}
+
+	// 确保车辆ID和记录ID与路径一致
+	record.CarID = ctx.Param("id")
+	record.ID = ctx.Param("recordId")
+
+	if err := c.MaintenanceService.UpdateRecord(ctx.Request.Context(), &record); err != nil {
+		c.respond(ctx, logger, err, "更新保养记录失败")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, record)
+}
+
+// DeleteRecord 删除保养记录
+func (c *MaintenanceController) DeleteRecord(ctx *gin.Context) {
+	logger := middleware.RequestLogger(ctx, c.Logger)
+
+	if err := c.MaintenanceService.DeleteRecord(ctx.Request.Context(), ctx.Param("id"), ctx.Param("recordId")); err != nil {
+		c.respond(ctx, logger, err, "删除保养记录失败")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "保养记录已删除"})
+}
+
+// DueServices 获取withinDays天内到期或已逾期的保养
+func (c *MaintenanceController) DueServices(ctx *gin.Context) {
+	logger := middleware.RequestLogger(ctx, c.Logger)
+
+	withinDays := defaultDueWithinDays
+	if raw := ctx.Query("withinDays"); raw != "" {
+		days, err := strconv.Atoi(raw)
+		if err != nil || days < 0 || days > maxDueWithinDays {
+			logger.Warning("withinDays参数无效: %q", raw)
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("withinDays 必须是 0-%d 之间的整数", maxDueWithinDays)})
+			return
+		}
+		withinDays = days
+	}
+
+	due, err := c.MaintenanceService.DueServices(ctx.Request.Context(), withinDays)
+	if err != nil {
+		respondError(ctx, logger, err, "车辆信息不存在", "获取到期保养失败")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, due)
+}
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jasonzheng/carrag/middleware"
+	"github.com/jasonzheng/carrag/models"
+	"github.com/jasonzheng/carrag/utils"
+)
+
+// OdometerController 里程表读数控制器
+type OdometerController struct {
+	OdometerService *models.OdometerService // 里程表读数服务
+	Logger          *utils.Logger           // 日志记录器
+}
+
+// NewOdometerController 创建新的里程表读数控制器
+func NewOdometerController(odometerService *models.OdometerService, logger *utils.Logger) *OdometerController {
+	return &OdometerController{
+		OdometerService: odometerService,
+		Logger:          logger,
+	}
+}
+
+// RegisterRoutes 注册路由
+func (c *OdometerController) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/cars/:id/odometer", c.ListReadings)
+	router.POST("/cars/:id/odometer", c.AddReading)
+	router.DELETE("/cars/:id/odometer/:readingId", c.DeleteReading)
+}
+
+// respond 写入错误响应，区分车辆不存在和里程表读数不存在
+func (c *OdometerController) respond(ctx *gin.Context, logger *utils.Logger, err error, action string) {
+	notFound := "车辆信息不存在"
+	if errors.Is(err, models.ErrOdometerReadingNotFound) {
+		notFound = "里程表读数不存在"
+	}
+	respondError(ctx, logger, err, notFound, action)
+}
+
+// ListReadings 获取车辆的所有里程表读数
+func (c *OdometerController) ListReadings(ctx *gin.Context) {
+	logger := middleware.RequestLogger(ctx, c.Logger)
+
+	readings, err := c.OdometerService.ListReadings(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		c.respond(ctx, logger, err, "获取里程表读数失败")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, readings)
+}
+
+// AddReading 为车辆录入里程表读数
+func (c *OdometerController) AddReading(ctx *gin.Context) {
+	logger := middleware.RequestLogger(ctx, c.Logger)
+
+	var reading models.OdometerReading
+
+	// 解析请求体
+	if err := ctx.ShouldBindJSON(&reading); err != nil {
+		logger.Warning("解析请求体失败: %v", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "请求数据格式错误"})
+		return
+	}
+	reading.CarID = ctx.Param("id")
+
+	if err := c.OdometerService.AddReading(ctx.Request.Context(), &reading); err != nil {
+		c.respond(ctx, logger, err, "录入里程表读数失败")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, reading)
+}
+
+// DeleteReading 删除里程表读数
+func (c *OdometerController) DeleteReading(ctx *gin.Context) {
+	logger := middleware.RequestLogger(ctx, c.Logger)
+
+	if err := c.OdometerService.DeleteReading(ctx.Request.Context(), ctx.Param("id"), ctx.Param("readingId")); err != nil {
+		c.respond(ctx, logger, err, "删除里程表读数失败")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "里程表读数已删除"})
+}
@@ -0,0 +1,42 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jasonzheng/carrag/middleware"
+	"github.com/jasonzheng/carrag/models"
+	"github.com/jasonzheng/carrag/utils"
+)
+
+// QueryController 自然语言查询控制器
+type QueryController struct {
+	QueryService *models.QueryService // 自然语言查询服务
+	Logger       *utils.Logger        // 日志记录器
+}
+
+// NewQueryController 创建新的自然语言查询控制器
+func NewQueryController(queryService *models.QueryService, logger *utils.Logger) *QueryController {
+	return &QueryController{
+		QueryService: queryService,
+		Logger:       logger,
+	}
+}
+
+// RegisterRoutes 注册路由
+func (c *QueryController) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/query", c.Query)
+}
+
+// Query 将自然语言查询解析为筛选条件，返回筛选条件和符合条件的车辆
+func (c *QueryController) Query(ctx *gin.Context) {
+	logger := middleware.RequestLogger(ctx, c.Logger)
+
+	result, err := c.QueryService.Query(ctx.Request.Context(), ctx.Query("q"))
+	if err != nil {
+		respondError(ctx, logger, err, "车辆信息不存在", "自然语言查询失败")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jasonzheng/carrag/middleware"
+	"github.com/jasonzheng/carrag/models"
+	"github.com/jasonzheng/carrag/utils"
+)
+
+// RAGController 车辆语义检索控制器
+type RAGController struct {
+	RAGService *models.RAGService // 车辆语义检索服务
+	Logger     *utils.Logger      // 日志记录器
+}
+
+// NewRAGController 创建新的车辆语义检索控制器
+func NewRAGController(ragService *models.RAGService, logger *utils.Logger) *RAGController {
+	return &RAGController{
+		RAGService: ragService,
+		Logger:     logger,
+	}
+}
+
+// RegisterRoutes 注册路由
+func (c *RAGController) RegisterRoutes(router *gin.RouterGroup) {
+	router.POST("/rag/query", c.Query)
+}
+
+// ragQueryRequest 语义检索请求
+type ragQueryRequest struct {
+	Query    string  `json:"query"`    // 查询内容
+	TopK     int     `json:"topK"`     // 返回的结果数，0使用默认值
+	MinScore float32 `json:"minScore"` // 只返回相似度高于该值的车辆，默认0
+}
+
+// Query 返回与查询语义最相似的车辆
+func (c *RAGController) Query(ctx *gin.Context) {
+	logger := middleware.RequestLogger(ctx, c.Logger)
+
+	var req ragQueryRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Warning("解析请求体失败: %v", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "请求数据格式错误"})
+		return
+	}
+	if req.TopK == 0 {
+		req.TopK = models.DefaultRAGTopK
+	}
+
+	results, err := c.RAGService.Query(ctx.Request.Context(), req.Query, req.TopK, req.MinScore)
+	if err != nil {
+		respondError(ctx, logger, err, "车辆信息不存在", "语义检索失败")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"query":   req.Query,
+		"total":   len(results),
+		"results": results,
+	})
+}
@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jasonzheng/carrag/middleware"
+	"github.com/jasonzheng/carrag/models"
+	"github.com/jasonzheng/carrag/utils"
+)
+
+// RecommendationController 车型推荐控制器
+type RecommendationController struct {
+	RecommendationService *models.RecommendationService // 车型推荐服务
+	Logger                *utils.Logger                 // 日志记录器
+}
+
+// NewRecommendationController 创建新的车型推荐控制器
+func NewRecommendationController(recommendationService *models.RecommendationService, logger *utils.Logger) *RecommendationController {
+	return &RecommendationController{
+		RecommendationService: recommendationService,
+		Logger:                logger,
+	}
+}
+
+// RegisterRoutes 注册路由
+func (c *RecommendationController) RegisterRoutes(router *gin.RouterGroup) {
+	router.POST("/recommendations", c.Recommend)
+}
+
+// Recommend 根据用户的用车情况推荐车型
+func (c *RecommendationController) Recommend(ctx *gin.Context) {
+	logger := middleware.RequestLogger(ctx, c.Logger)
+
+	var req models.RecommendationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Warning("解析请求体失败: %v", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "请求数据格式错误"})
+		return
+	}
+
+	recommendations, err := c.RecommendationService.Recommend(ctx.Request.Context(), req)
+	if err != nil {
+		respondError(ctx, logger, err, "车辆信息不存在", "推荐车型失败")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"total":           len(recommendations),
+		"recommendations": recommendations,
+	})
+}
@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jasonzheng/carrag/middleware"
+	"github.com/jasonzheng/carrag/models"
+	"github.com/jasonzheng/carrag/utils"
+)
+
+// SearchController 车辆搜索控制器
+type SearchController struct {
+	SearchService *models.SearchService // 车辆搜索服务
+	Logger        *utils.Logger         // 日志记录器
+}
+
+// NewSearchController 创建新的车辆搜索控制器
+func NewSearchController(searchService *models.SearchService, logger *utils.Logger) *SearchController {
+	return &SearchController{
+		SearchService: searchService,
+		Logger:        logger,
+	}
+}
+
+// RegisterRoutes 注册路由
+func (c *SearchController) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/search", c.Search)
+}
+
+// Search 按关键词搜索车辆，结果按相关度排序
+func (c *SearchController) Search(ctx *gin.Context) {
+	logger := middleware.RequestLogger(ctx, c.Logger)
+
+	limit, ok := intQuery(ctx, logger, "limit", models.DefaultSearchLimit)
+	if !ok {
+		return
+	}
+
+	query := ctx.Query("q")
+	results, total, err := c.SearchService.Search(ctx.Request.Context(), query, limit)
+	if err != nil {
+		respondError(ctx, logger, err, "车辆信息不存在", "搜索车辆失败")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"query":   query,
+		"total":   total,
+		"results": results,
+	})
+}
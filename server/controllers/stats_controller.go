@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jasonzheng/carrag/middleware"
+	"github.com/jasonzheng/carrag/models"
+	"github.com/jasonzheng/carrag/utils"
+)
+
+// StatsController 车队统计控制器
+type StatsController struct {
+	StatsService *models.StatsService // 车队统计服务
+	Logger       *utils.Logger        // 日志记录器
+}
+
+// NewStatsController 创建新的车队统计控制器
+func NewStatsController(statsService *models.StatsService, logger *utils.Logger) *StatsController {
+	return &StatsController{
+		StatsService: statsService,
+		Logger:       logger,
+	}
+}
+
+// RegisterRoutes 注册路由
+func (c *StatsController) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/stats", c.Overview)
+	router.GET("/stats/counts/:field", c.Counts)
+	router.GET("/stats/distributions/:field", c.Distribution)
+	router.GET("/stats/monthly", c.Monthly)
+}
+
+// Overview 获取车队统计概览
+func (c *StatsController) Overview(ctx *gin.Context) {
+	logger := middleware.RequestLogger(ctx, c.Logger)
+
+	stats, err := c.StatsService.Overview(ctx.Request.Context())
+	if err != nil {
+		respondError(ctx, logger, err, "车辆信息不存在", "计算车队统计失败")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, stats)
+}
+
+// Counts 按字段取值统计车辆数
+func (c *StatsController) Counts(ctx *gin.Context) {
+	logger := middleware.RequestLogger(ctx, c.Logger)
+
+	counts, err := c.StatsService.Counts(ctx.Request.Context(), ctx.Param("field"))
+	if err != nil {
+		respondError(ctx, logger, err, "车辆信息不存在", "统计车辆数失败")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, counts)
+}
+
+// Distribution 获取数值字段的分布
+func (c *StatsController) Distribution(ctx *gin.Context) {
+	logger := middleware.RequestLogger(ctx, c.Logger)
+
+	buckets, ok := intQuery(ctx, logger, "buckets", models.DefaultStatsBuckets)
+	if !ok {
+		return
+	}
+
+	distribution, err := c.StatsService.Distribution(ctx.Request.Context(), ctx.Param("field"), buckets)
+	if err != nil {
+		respondError(ctx, logger, err, "车辆信息不存在", "统计分布失败")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, distribution)
+}
+
+// Monthly 获取最近若干个月每月新增的车辆数
+func (c *StatsController) Monthly(ctx *gin.Context) {
+	logger := middleware.RequestLogger(ctx, c.Logger)
+
+	months, ok := intQuery(ctx, logger, "months", models.DefaultStatsMonths)
+	if !ok {
+		return
+	}
+
+	monthly, err := c.StatsService.Monthly(ctx.Request.Context(), months)
+	if err != nil {
+		respondError(ctx, logger, err, "车辆信息不存在", "统计新增车辆失败")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, monthly)
+}
+
+// intQuery 读取整数查询参数，未提供时返回默认值，格式错误时写入400响应并返回false
+func intQuery(ctx *gin.Context, logger *utils.Logger, name string, defaultValue int) (int, bool) {
+	raw := ctx.Query(name)
+	if raw == "" {
+		return defaultValue, true
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		logger.Warning("%s参数无效: %q", name, raw)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s 必须是整数", name)})
+		return 0, false
+	}
+	return value, true
+}
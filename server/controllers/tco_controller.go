@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jasonzheng/carrag/middleware"
+	"github.com/jasonzheng/carrag/models"
+	"github.com/jasonzheng/carrag/utils"
+)
+
+// tcoDateLayout 统计期间参数的日期格式
+const tcoDateLayout = "2006-01-02"
+
+// TCOController 车辆总拥有成本控制器
+type TCOController struct {
+	TCOService *models.TCOService // 总拥有成本服务
+	Logger     *utils.Logger      // 日志记录器
+}
+
+// NewTCOController 创建新的总拥有成本控制器
+func NewTCOController(tcoService *models.TCOService, logger *utils.Logger) *TCOController {
+	return &TCOController{
+		TCOService: tcoService,
+		Logger:     logger,
+	}
+}
+
+// RegisterRoutes 注册路由
+func (c *TCOController) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/cars/:id/tco", c.Report)
+	router.GET("/tco/ranking", c.Ranking)
+}
+
+// Report 获取车辆在统计期间的总拥有成本
+func (c *TCOController) Report(ctx *gin.Context) {
+	logger := middleware.RequestLogger(ctx, c.Logger)
+
+	from, to, err := parsePeriod(ctx)
+	if err != nil {
+		logger.Warning("统计期间参数无效: %v", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := c.TCOService.Report(ctx.Request.Context(), ctx.Param("id"), from, to)
+	if err != nil {
+		respondError(ctx, logger, err, "车辆信息不存在", "计算总拥有成本失败")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
+
+// Ranking 获取车队的总拥有成本排名
+func (c *TCOController) Ranking(ctx *gin.Context) {
+	logger := middleware.RequestLogger(ctx, c.Logger)
+
+	from, to, err := parsePeriod(ctx)
+	if err != nil {
+		logger.Warning("统计期间参数无效: %v", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	limit := 0
+	if raw := ctx.Query("limit"); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			logger.Warning("limit参数无效: %q", raw)
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "limit 必须是正整数"})
+			return
+		}
+	}
+
+	reports, err := c.TCOService.Ranking(ctx.Request.Context(), from, to, ctx.DefaultQuery("sortBy", models.TCOSortTotalCost))
+	if err != nil {
+		respondError(ctx, logger, err, "车辆信息不存在", "计算总拥有成本排名失败")
+		return
+	}
+	if limit > 0 && len(reports) > limit {
+		reports = reports[:limit]
+	}
+
+	ctx.JSON(http.StatusOK, reports)
+}
+
+// parsePeriod 解析统计期间参数from和to(YYYY-MM-DD，均包含当天)，默认为截至当前的一年
+func parsePeriod(ctx *gin.Context) (time.Time, time.Time, error) {
+	to := time.Now()
+	if raw := ctx.Query("to"); raw != "" {
+		date, err := time.Parse(tcoDateLayout, raw)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("to 必须是 YYYY-MM-DD 格式的日期: %q", raw)
+		}
+		to = date.AddDate(0, 0, 1)
+	}
+
+	from := to.AddDate(-1, 0, 0)
+	if raw := ctx.Query("from"); raw != "" {
+		date, err := time.Parse(tcoDateLayout, raw)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("from 必须是 YYYY-MM-DD 格式的日期: %q", raw)
+		}
+		from = date
+	}
+	return from, to, nil
+}
@@ -1,30 +1,65 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 
-	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/jasonzheng/carrag/config"
 	"github.com/jasonzheng/carrag/controllers"
+	"github.com/jasonzheng/carrag/metrics"
 	"github.com/jasonzheng/carrag/middleware"
 	"github.com/jasonzheng/carrag/models"
+	"github.com/jasonzheng/carrag/rag"
 	"github.com/jasonzheng/carrag/repositories"
+	"github.com/jasonzheng/carrag/tracing"
 	"github.com/jasonzheng/carrag/utils"
 )
 
 func main() {
+	// 子命令: config print
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		runConfigCommand(os.Args[2:])
+		return
+	}
+
 	// 加载配置
-	appConfig := config.NewDefaultConfig()
+	appConfig, configFile, err := config.Load(os.Args[0], os.Args[1:])
+	if err != nil {
+		fmt.Printf("加载配置失败: %v\n", err)
+		os.Exit(2)
+	}
 
 	// 初始化日志记录器
-	logger, err := utils.NewLogger(appConfig.LogDir, appConfig.LogLevel)
+	logger, err := utils.NewLogger(appConfig.LogDir, appConfig.LogLevel, appConfig.LogRotation.Options())
 	if err != nil {
 		fmt.Printf("初始化日志记录器失败: %v\n", err)
 		os.Exit(1)
 	}
 	defer logger.Close()
+	logger.SetFormat(appConfig.LogFormat)
+
+	// 收到SIGUSR1时重新打开日志文件，配合外部logrotate使用
+	stopReopen := logger.ReopenOnSignal()
+	defer stopReopen()
+
+	if configFile != "" {
+		logger.Info("已加载配置文件: %s", configFile)
+	}
+
+	// 初始化链路追踪
+	shutdownTracing, err := tracing.Init(context.Background(), appConfig.Tracing.Options())
+	if err != nil {
+		logger.Fatal("初始化链路追踪失败: %v", err)
+	}
+	if appConfig.Tracing.Exporter != tracing.ExporterNone {
+		logger.Info("链路追踪已启用，导出方式: %s，采样比例: %v", appConfig.Tracing.Exporter, appConfig.Tracing.SampleRatio)
+	}
 
 	// 初始化存储管理器
 	storage, err := utils.NewStorage(appConfig.DataDir, logger)
@@ -33,49 +68,274 @@ func main() {
 	}
 
 	// 初始化文件仓库
-	fileRepo := repositories.NewFileCarRepository(storage, logger, "cars.json")
+	carsFile := "cars.json"
+	maintenanceFile := "maintenance.json"
+	fuelFile := "fuel.json"
+	odometerFile := "odometer.json"
+	fileRepo := repositories.NewFileCarRepository(storage, logger, carsFile)
+	maintenanceRepo := repositories.NewFileMaintenanceRepository(storage, logger, maintenanceFile)
+	fuelRepo := repositories.NewFileFuelRepository(storage, logger, fuelFile)
+	odometerRepo := repositories.NewFileOdometerRepository(storage, logger, odometerFile)
+
+	// 启动时统计车辆总数，之后由仓库在新增和删除车辆时更新
+	if cars, err := fileRepo.FindAll(context.Background()); err != nil {
+		logger.Warning("统计车辆总数失败: %v", err)
+	} else {
+		metrics.CarsTotal.Set(float64(len(cars)))
+	}
 
-	// 初始化Redis缓存
-	var redisCache *utils.RedisCache
-	redisCache, err = utils.NewRedisCache(
+	// 初始化Redis连接，用于缓存、健康检查和共享限流计数
+	// Redis不可用时不阻止启动，相关功能降级运行，并在后台定期重连
+	redisCache := utils.NewRedisCache(
 		appConfig.RedisAddr,
 		appConfig.RedisPassword,
 		appConfig.RedisDB,
 		appConfig.RedisPrefix,
+		appConfig.RedisBreaker.Options(),
 		logger,
 	)
-	if err != nil {
-		logger.Warning("Redis连接失败: %v，系统将降级为仅使用文件存储", err)
-		redisCache = nil
-	} else {
-		defer redisCache.Close()
+
+	// 初始化缓存
+	cache := newCache(appConfig, redisCache, logger)
+	if cache != nil {
+		cache.SetPolicy(appConfig.CachePolicy.Policy())
 	}
 
 	// 初始化车辆服务
-	carService := models.NewCarService(fileRepo, logger, redisCache)
+	carService := models.NewCarService(fileRepo, logger, cache)
+	carService.SetCacheTTL(appConfig.CacheTTL)
+
+	// 缓存在本地保存数据时，通过Redis发布订阅通知其他实例清理旧数据
+	var invalidator *utils.Invalidator
+	if evicter, ok := cache.(utils.Evicter); ok && appConfig.LocalCache.Invalidation {
+		invalidator = utils.NewInvalidator(redisCache, logger)
+		invalidator.Subscribe(evicter)
+		carService.Invalidations = invalidator
+	}
+
+	// 初始化保养记录服务，车辆删除时一并删除其保养记录
+	maintenanceService := models.NewMaintenanceService(maintenanceRepo, carService, logger)
+	maintenanceService.SetSchedule(appConfig.MaintenanceSchedule.Schedule())
+
+	// 初始化加油记录服务，车辆删除时一并删除其加油记录
+	fuelService := models.NewFuelService(fuelRepo, carService, logger)
+	fuelService.SetOptions(appConfig.FuelLog.Options())
+
+	// 初始化里程表读数服务，创建或更新车辆时记录行驶里程，车辆删除时一并删除其读数
+	odometerService := models.NewOdometerService(odometerRepo, carService, logger)
+	odometerService.SetOptions(appConfig.Odometer.Options())
+
+	// 初始化总拥有成本服务
+	tcoService := models.NewTCOService(carService, maintenanceRepo, fuelRepo, logger)
+	tcoService.SetOptions(appConfig.TCO.Options())
+
+	// 初始化车队统计服务
+	statsService := models.NewStatsService(carService, logger)
+	queryService := models.NewQueryService(carService, logger)
+	recommendationService := models.NewRecommendationService(carService, logger)
+	recommendationService.SetOptions(appConfig.Recommendation.Options())
+
+	// 初始化车辆搜索服务，建立初始索引，并定期重建以获取其他实例写入的数据
+	searchService := models.NewSearchService(carService, logger)
+	searchService.MaxResults = appConfig.Search.MaxResults
+	if err := searchService.Rebuild(context.Background()); err != nil {
+		logger.Fatal("%v", err)
+	}
+	searchService.StartRefresh(appConfig.Search.RefreshInterval)
+
+	// 初始化车辆语义检索服务，模型服务不可用时先以空索引启动，由定期重建补齐
+	embedder, err := rag.NewEmbedder(appConfig.RAG.Options())
+	if err != nil {
+		logger.Fatal("初始化向量化失败: %v", err)
+	}
+	ragService := models.NewRAGService(carService, embedder, logger)
+	ragService.MaxTopK = appConfig.RAG.MaxTopK
+	ragService.BatchSize = appConfig.RAG.BatchSize
+	if err := ragService.Rebuild(context.Background()); err != nil {
+		logger.Error("%v", err)
+	}
+	ragService.StartRefresh(appConfig.RAG.RefreshInterval)
+	logger.Info("语义检索已启用，向量化方式: %s，已索引 %d 辆车", embedder.Name(), ragService.Len())
 
-	// 初始化车辆控制器
+	// 初始化控制器
 	carController := controllers.NewCarController(carService, logger)
+	maintenanceController := controllers.NewMaintenanceController(maintenanceService, logger)
+	fuelController := controllers.NewFuelController(fuelService, logger)
+	odometerController := controllers.NewOdometerController(odometerService, logger)
+	tcoController := controllers.NewTCOController(tcoService, logger)
+	statsController := controllers.NewStatsController(statsService, logger)
+	queryController := controllers.NewQueryController(queryService, logger)
+	recommendationController := controllers.NewRecommendationController(recommendationService, logger)
+	searchController := controllers.NewSearchController(searchService, logger)
+	ragController := controllers.NewRAGController(ragService, logger)
+	healthController := controllers.NewHealthController(storage, redisCache, []string{carsFile, maintenanceFile, fuelFile, odometerFile}, logger)
 
 	// 初始化Gin路由
 	gin.SetMode(appConfig.GinMode)
 	r := gin.New()
 	r.Use(gin.Recovery())
+
+	// 健康检查和指标路由，请求频繁，不经过日志、CORS和限流中间件
+	healthController.RegisterRoutes(&r.RouterGroup)
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+
+	r.Use(middleware.RequestID(logger))
+	r.Use(middleware.Tracing(logger))
 	r.Use(middleware.Logger(logger))
+	r.Use(middleware.Metrics())
+
+	// 请求超时，超时后取消请求上下文
+	requestTimeout := middleware.NewTimeout(appConfig.RequestTimeout)
+	r.Use(requestTimeout.Handler())
 
 	// 设置受信任的代理
 	r.SetTrustedProxies([]string{"127.0.0.1"})
 
 	// 配置CORS
-	r.Use(cors.New(config.GetCorsConfig()))
+	corsMiddleware := middleware.NewCORS(appConfig.GetCorsConfig())
+	r.Use(corsMiddleware.Handler())
+
+	// 配置限流
+	rateLimiter := middleware.NewRateLimiter(appConfig.RateLimit, redisCache, logger)
+	r.Use(rateLimiter.Handler())
 
 	// API路由
 	api := r.Group("/api")
 	carController.RegisterRoutes(api)
+	maintenanceController.RegisterRoutes(api)
+	fuelController.RegisterRoutes(api)
+	odometerController.RegisterRoutes(api)
+	tcoController.RegisterRoutes(api)
+	statsController.RegisterRoutes(api)
+	queryController.RegisterRoutes(api)
+	recommendationController.RegisterRoutes(api)
+	searchController.RegisterRoutes(api)
+	ragController.RegisterRoutes(api)
+
+	// 监听配置变更，热加载日志级别、CORS来源、限流规则、缓存过期时间和策略、请求超时时间、保养计划、油耗计算选项、里程表读数选项、总拥有成本计算选项
+	watcher := config.NewWatcher(os.Args[0], os.Args[1:], configFile, appConfig, logger)
+	watcher.OnReload(func(newConfig *config.AppConfig) {
+		logger.SetLevel(newConfig.LogLevel)
+		logger.SetFormat(newConfig.LogFormat)
+		corsMiddleware.Update(newConfig.GetCorsConfig())
+		rateLimiter.Update(newConfig.RateLimit)
+		carService.SetCacheTTL(newConfig.CacheTTL)
+		if cache != nil {
+			cache.SetPolicy(newConfig.CachePolicy.Policy())
+		}
+		requestTimeout.Update(newConfig.RequestTimeout)
+		maintenanceService.SetSchedule(newConfig.MaintenanceSchedule.Schedule())
+		fuelService.SetOptions(newConfig.FuelLog.Options())
+		odometerService.SetOptions(newConfig.Odometer.Options())
+		tcoService.SetOptions(newConfig.TCO.Options())
+		recommendationService.SetOptions(newConfig.Recommendation.Options())
+	})
+	watcher.Start()
 
 	// 启动服务器
-	logger.Info("服务器启动在 http://localhost:%d", appConfig.ServerPort)
-	if err := r.Run(fmt.Sprintf(":%d", appConfig.ServerPort)); err != nil {
-		logger.Fatal("服务器启动失败: %v", err)
+	server := &http.Server{
+		Addr:         fmt.Sprintf(":%d", appConfig.ServerPort),
+		Handler:      r,
+		ReadTimeout:  appConfig.ReadTimeout,
+		WriteTimeout: appConfig.WriteTimeout,
+		IdleTimeout:  appConfig.IdleTimeout,
+	}
+	serverErr := make(chan error, 1)
+	go func() {
+		logger.Info("服务器启动在 http://localhost:%d", appConfig.ServerPort)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	// 资源按注册顺序关闭：停止监听配置 -> 停止接收请求并等待处理中的请求 -> 停止重建搜索索引和语义检索索引、停止后台向量化 -> 等待文件写入 -> 等待异步缓存更新 -> 取消失效事件订阅 -> 关闭Redis连接 -> 导出剩余的追踪数据
+	lifecycle := utils.NewLifecycle(logger)
+	lifecycle.OnShutdown("配置监听", func(ctx context.Context) error {
+		watcher.Stop()
+		return nil
+	})
+	lifecycle.OnShutdown("HTTP服务器", server.Shutdown)
+	lifecycle.OnShutdown("搜索索引重建", func(ctx context.Context) error {
+		searchService.Close()
+		return nil
+	})
+	lifecycle.OnShutdown("语义检索索引更新", func(ctx context.Context) error {
+		ragService.Close()
+		return nil
+	})
+	lifecycle.OnShutdown("文件存储", storage.Flush)
+	if cache != nil {
+		lifecycle.OnShutdown("异步缓存更新", cache.Flush)
+	}
+	if invalidator != nil {
+		lifecycle.OnShutdown("缓存失效订阅", func(ctx context.Context) error {
+			return invalidator.Close()
+		})
+	}
+	lifecycle.OnShutdown("Redis连接", func(ctx context.Context) error {
+		return redisCache.Close()
+	})
+	lifecycle.OnShutdown("链路追踪", shutdownTracing)
+
+	// 等待退出信号
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	exitCode := 0
+	select {
+	case sig := <-quit:
+		logger.Info("收到信号 %v，开始优雅关闭，最长等待 %v", sig, appConfig.ShutdownTimeout)
+	case err := <-serverErr:
+		logger.Error("服务器启动失败: %v", err)
+		exitCode = 1
+	}
+	signal.Stop(quit)
+
+	ctx, cancel := context.WithTimeout(context.Background(), appConfig.ShutdownTimeout)
+	defer cancel()
+	if err := lifecycle.Shutdown(ctx); err != nil {
+		logger.Error("优雅关闭未完全完成: %v", err)
+	}
+	logger.Info("服务器已关闭")
+
+	if exitCode != 0 {
+		logger.Close()
+		os.Exit(exitCode)
+	}
+}
+
+// runConfigCommand 执行config子命令
+func runConfigCommand(args []string) {
+	if len(args) == 0 || args[0] != "print" {
+		fmt.Println("用法: carrag-server config print [参数]")
+		os.Exit(2)
+	}
+
+	appConfig, _, err := config.Load("config print", args[1:])
+	if err != nil {
+		fmt.Printf("加载配置失败: %v\n", err)
+		os.Exit(2)
+	}
+
+	if err := config.Print(os.Stdout, appConfig); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+// newCache 按配置创建缓存，返回nil表示不使用缓存
+func newCache(appConfig *config.AppConfig, redisCache *utils.RedisCache, logger *utils.Logger) utils.Cache {
+	local := appConfig.LocalCache
+	switch appConfig.CacheBackend {
+	case config.CacheBackendNone:
+		logger.Info("已禁用缓存")
+		return nil
+	case config.CacheBackendLRU:
+		logger.Info("使用进程内LRU缓存，最大条目数: %d", local.MaxEntries)
+		return utils.NewLRUCache(local.MaxEntries, local.MaxTTL, logger)
+	case config.CacheBackendTiered:
+		logger.Info("使用两级缓存，本地最大条目数: %d，本地保留时间: %v", local.MaxEntries, local.MaxTTL)
+		return utils.NewTieredCache(local.MaxEntries, local.MaxTTL, redisCache, logger)
 	}
-}
\ No newline at end of file
+	logger.Info("使用Redis缓存")
+	return redisCache
+}
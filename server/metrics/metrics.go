@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace 指标名称前缀
+const namespace = "carrag"
+
+var (
+	// HTTPRequestsTotal HTTP请求数，按方法、路由模板和状态码统计
+	HTTPRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP请求总数",
+	}, []string{"method", "route", "status"})
+
+	// HTTPRequestDuration HTTP请求耗时，按方法、路由模板和状态码统计
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP请求处理耗时(秒)",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// StorageOperationDuration 文件存储读写耗时，按操作、文件和结果统计
+	StorageOperationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "storage_operation_duration_seconds",
+		Help:      "文件存储读写耗时(秒)",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"operation", "file", "result"})
+
+	// CacheRequestsTotal 缓存读取次数，按结果(hit/stale/negative/miss/error)统计
+	CacheRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "缓存读取总数",
+	}, []string{"result"})
+
+	// CacheFallbacksTotal 缓存未命中后的回源次数，shared为true表示合并到了其他请求的回源
+	CacheFallbacksTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_fallbacks_total",
+		Help:      "缓存回源总数",
+	}, []string{"shared"})
+
+	// CacheInvalidationsTotal 实例之间传播的缓存失效事件数，按方向(published/received)统计
+	CacheInvalidationsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_invalidations_total",
+		Help:      "实例之间传播的缓存失效事件总数",
+	}, []string{"direction"})
+
+	// RedisCircuitOpen Redis熔断器状态，1表示已熔断
+	RedisCircuitOpen = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "redis_circuit_open",
+		Help:      "Redis熔断器是否处于熔断状态",
+	})
+
+	// CarsTotal 车辆信息总数
+	CarsTotal = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "cars_total",
+		Help:      "车辆信息总数",
+	})
+)
+
+// 缓存读取结果
+const (
+	CacheHit      = "hit"      // 命中
+	CacheStale    = "stale"    // 命中已过新鲜期的数据
+	CacheNegative = "negative" // 命中"数据不存在"的结果
+	CacheMiss     = "miss"     // 未命中
+	CacheError    = "error"    // 读取失败或数据格式无效
+)
+
+// 缓存失效事件方向
+const (
+	InvalidationPublished = "published" // 本实例发布
+	InvalidationReceived  = "received"  // 收到其他实例发布的事件
+)
+
+// Handler 返回暴露指标的HTTP处理器
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// ObserveStorage 记录一次文件存储操作的耗时
+func ObserveStorage(operation, file string, start time.Time, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	StorageOperationDuration.WithLabelValues(operation, file, result).Observe(time.Since(start).Seconds())
+}
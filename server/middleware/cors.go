@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"sync/atomic"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// CORS 支持运行时替换配置的跨域中间件
+type CORS struct {
+	handler atomic.Value // gin.HandlerFunc
+}
+
+// NewCORS 创建跨域中间件
+func NewCORS(cfg cors.Config) *CORS {
+	m := &CORS{}
+	m.Update(cfg)
+	return m
+}
+
+// Update 替换跨域配置，对之后的请求生效
+func (m *CORS) Update(cfg cors.Config) {
+	m.handler.Store(cors.New(cfg))
+}
+
+// Handler 返回跨域中间件
+func (m *CORS) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		m.handler.Load().(gin.HandlerFunc)(c)
+	}
+}
@@ -30,7 +30,13 @@ func Logger(logger *utils.Logger) gin.HandlerFunc {
 		// 客户端IP
 		clientIP := c.ClientIP()
 
-		// 记录日志
-		logger.Info("%s | %d | %s | %s | %v", method, statusCode, clientIP, path, latency)
+		// 记录日志，同时附带结构化字段便于日志系统解析
+		RequestLogger(c, logger).WithFields(utils.Fields{
+			"method":     method,
+			"status":     statusCode,
+			"client_ip":  clientIP,
+			"path":       path,
+			"latency_ms": float64(latency.Microseconds()) / 1000,
+		}).Info("%s | %d | %s | %s | %v", method, statusCode, clientIP, path, latency)
 	}
 }
@@ -0,0 +1,236 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/jasonzheng/carrag/config"
+	"github.com/jasonzheng/carrag/utils"
+)
+
+// tokenBucketScript 在Redis中原子地执行令牌桶算法
+// 使用Redis服务器时间，避免多个实例之间的时钟偏差
+var tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+local data = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(data[1])
+local ts = tonumber(data[2])
+if tokens == nil or ts == nil then
+	tokens = burst
+	ts = now
+end
+
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate / 1000)
+
+local allowed = 0
+local wait = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	wait = math.ceil((1 - tokens) * 1000 / rate)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst * 1000 / rate) + 1000)
+return {allowed, wait}
+`)
+
+// bucket 本地令牌桶
+type bucket struct {
+	tokens float64       // 当前令牌数
+	last   time.Time     // 上次补充令牌的时间
+	refill time.Duration // 从空桶补满所需的时间
+}
+
+// RateLimiter 基于令牌桶的限流器，按客户端IP或API Key限流
+type RateLimiter struct {
+	cfg    atomic.Pointer[config.RateLimitConfig] // 限流配置，支持运行时替换
+	cache  *utils.RedisCache
+	logger *utils.Logger
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// NewRateLimiter 创建限流器，cache为nil或未启用Redis时使用本地计数
+func NewRateLimiter(cfg config.RateLimitConfig, cache *utils.RedisCache, logger *utils.Logger) *RateLimiter {
+	l := &RateLimiter{
+		cache:     cache,
+		logger:    logger,
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+	}
+	l.Update(cfg)
+	return l
+}
+
+// Update 替换限流配置，对之后的请求生效
+// 只清除规则发生变化的路由的本地令牌桶，使新的桶容量立即生效，规则未变的路由保留已消耗的令牌
+func (l *RateLimiter) Update(cfg config.RateLimitConfig) {
+	old := l.cfg.Swap(&cfg)
+	if old == nil || reflect.DeepEqual(*old, cfg) {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for key := range l.buckets {
+		route, _, _ := strings.Cut(key, "|")
+		if ruleFor(old, route) != ruleFor(&cfg, route) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Handler 返回限流中间件
+func (l *RateLimiter) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cfg := l.cfg.Load()
+		if !cfg.Enabled {
+			c.Next()
+			return
+		}
+
+		route := c.Request.Method + " " + c.FullPath()
+		rule := ruleFor(cfg, route)
+		if rule.Rate <= 0 || rule.Burst <= 0 {
+			// 规则无效视为不限流
+			c.Next()
+			return
+		}
+
+		key := route + "|" + clientKey(cfg, c)
+		allowed, retryAfter := l.allow(c.Request.Context(), cfg.UseRedis, key, rule)
+		if !allowed {
+			seconds := int(math.Ceil(retryAfter.Seconds()))
+			if seconds < 1 {
+				seconds = 1
+			}
+			RequestLogger(c, l.logger).Warning("请求被限流: %s | %s | %s", route, c.ClientIP(), retryAfter)
+			c.Header("Retry-After", strconv.Itoa(seconds))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "请求过于频繁，请稍后再试"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// ruleFor 获取路由对应的限流规则
+func ruleFor(cfg *config.RateLimitConfig, route string) config.RateLimitRule {
+	if rule, ok := cfg.Routes[route]; ok {
+		return rule
+	}
+	return cfg.Default
+}
+
+// clientKey 获取客户端标识，优先使用API Key
+func clientKey(cfg *config.RateLimitConfig, c *gin.Context) string {
+	if cfg.KeyHeader != "" {
+		if apiKey := c.GetHeader(cfg.KeyHeader); apiKey != "" {
+			// 不在计数键中保存API Key明文
+			sum := sha256.Sum256([]byte(apiKey))
+			return "key:" + hex.EncodeToString(sum[:8])
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// allow 判断请求是否放行，被拒绝时返回需要等待的时间
+func (l *RateLimiter) allow(ctx context.Context, useRedis bool, key string, rule config.RateLimitRule) (bool, time.Duration) {
+	if useRedis && l.cache != nil {
+		allowed, retryAfter, err := l.allowRedis(ctx, key, rule)
+		if err == nil {
+			return allowed, retryAfter
+		}
+		// Redis不可用时降级为本地计数，熔断期间每个请求都会失败，不再逐条记录警告
+		if errors.Is(err, utils.ErrCacheUnavailable) {
+			l.logger.Debug("Redis已熔断，使用本地限流")
+		} else {
+			l.logger.Warning("Redis限流失败: %v，降级为本地限流", err)
+		}
+	}
+	return l.allowLocal(key, rule)
+}
+
+// allowRedis 使用Redis计数判断请求是否放行
+func (l *RateLimiter) allowRedis(ctx context.Context, key string, rule config.RateLimitRule) (bool, time.Duration, error) {
+	redisKey := fmt.Sprintf("%s:ratelimit:%s", l.cache.Prefix, key)
+	result, err := tokenBucketScript.Run(ctx, l.cache.Client, []string{redisKey}, rule.Rate, rule.Burst).Slice()
+	if err != nil {
+		return false, 0, err
+	}
+	if len(result) != 2 {
+		return false, 0, fmt.Errorf("限流脚本返回值格式错误: %v", result)
+	}
+
+	allowed, _ := result[0].(int64)
+	wait, _ := result[1].(int64)
+	return allowed == 1, time.Duration(wait) * time.Millisecond, nil
+}
+
+// allowLocal 使用本地令牌桶判断请求是否放行
+func (l *RateLimiter) allowLocal(key string, rule config.RateLimitRule) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{
+			tokens: float64(rule.Burst),
+			last:   now,
+			refill: time.Duration(float64(rule.Burst) / rule.Rate * float64(time.Second)),
+		}
+		l.buckets[key] = b
+	}
+
+	// 按流逝的时间补充令牌
+	b.tokens = math.Min(float64(rule.Burst), b.tokens+now.Sub(b.last).Seconds()*rule.Rate)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	wait := time.Duration((1 - b.tokens) / rule.Rate * float64(time.Second))
+	return false, wait
+}
+
+// sweep 定期清理长时间未使用的令牌桶，避免内存无限增长
+// 调用者需持有锁
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		// 闲置时间足以补满的令牌桶，删除后重建结果相同
+		if now.Sub(b.last) >= b.refill {
+			delete(l.buckets, key)
+		}
+	}
+}
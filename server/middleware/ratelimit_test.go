@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"testing"
+
+	"github.com/jasonzheng/carrag/config"
+	"github.com/jasonzheng/carrag/utils"
+)
+
+func TestRateLimiterUpdateKeepsUnchangedBuckets(t *testing.T) {
+	cfg := config.RateLimitConfig{
+		Enabled: true,
+		Default: config.RateLimitRule{Rate: 1, Burst: 2},
+		Routes: map[string]config.RateLimitRule{
+			"POST /api/cars": {Rate: 1, Burst: 1},
+		},
+	}
+	l := NewRateLimiter(cfg, nil, newTestLogger(t))
+
+	const (
+		listKey   = "GET /api/cars|ip:127.0.0.1"
+		createKey = "POST /api/cars|ip:127.0.0.1"
+	)
+	l.allowLocal(listKey, cfg.Default)
+	l.allowLocal(createKey, cfg.Routes["POST /api/cars"])
+
+	tests := []struct {
+		name    string
+		update  func(cfg *config.RateLimitConfig)
+		wantKey map[string]bool
+	}{
+		{
+			name:    "配置未变化",
+			update:  func(cfg *config.RateLimitConfig) {},
+			wantKey: map[string]bool{listKey: true, createKey: true},
+		},
+		{
+			name: "只修改路由规则",
+			update: func(cfg *config.RateLimitConfig) {
+				cfg.Routes = map[string]config.RateLimitRule{"POST /api/cars": {Rate: 2, Burst: 4}}
+			},
+			wantKey: map[string]bool{listKey: true, createKey: false},
+		},
+		{
+			name: "修改默认规则",
+			update: func(cfg *config.RateLimitConfig) {
+				cfg.Default = config.RateLimitRule{Rate: 5, Burst: 10}
+			},
+			wantKey: map[string]bool{listKey: false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := *l.cfg.Load()
+			tt.update(&next)
+			l.Update(next)
+
+			for key, want := range tt.wantKey {
+				if _, ok := l.buckets[key]; ok != want {
+					t.Errorf("bucket %q 保留=%v，期望 %v", key, ok, want)
+				}
+			}
+		})
+	}
+}
+
+// newTestLogger 创建输出到临时目录的日志记录器，只输出致命错误
+func newTestLogger(t *testing.T) *utils.Logger {
+	t.Helper()
+	logger, err := utils.NewLogger(t.TempDir(), utils.FATAL, utils.RotateOptions{})
+	if err != nil {
+		t.Fatalf("创建日志记录器失败: %v", err)
+	}
+	t.Cleanup(func() { logger.Close() })
+	return logger
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jasonzheng/carrag/utils"
+)
+
+const (
+	// RequestIDHeader 请求ID的请求头和响应头
+	RequestIDHeader = "X-Request-ID"
+	// RequestIDKey 请求ID在gin.Context中的键
+	RequestIDKey = "requestID"
+
+	// maxRequestIDLength 沿用的请求ID最大长度，超出时重新生成
+	maxRequestIDLength = 128
+)
+
+// RequestID 创建一个Gin中间件，沿用请求头中的X-Request-ID或生成新的请求ID，
+// 写入响应头，并将附带请求ID的日志记录器放入请求上下文供后续处理使用
+func RequestID(logger *utils.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.New().String()
+		}
+
+		c.Set(RequestIDKey, requestID)
+		setRequestLogger(c, logger.WithField("request_id", requestID))
+		c.Header(RequestIDHeader, requestID)
+
+		c.Next()
+	}
+}
+
+// RequestLogger 获取当前请求的日志记录器，未经过RequestID中间件时返回fallback
+func RequestLogger(c *gin.Context, fallback *utils.Logger) *utils.Logger {
+	return utils.LoggerFromContext(c.Request.Context(), fallback)
+}
+
+// setRequestLogger 替换当前请求上下文中的日志记录器
+func setRequestLogger(c *gin.Context, logger *utils.Logger) {
+	c.Request = c.Request.WithContext(utils.ContextWithLogger(c.Request.Context(), logger))
+}
+
+// validRequestID 检查客户端传入的请求ID，只接受长度合理的可打印ASCII字符，避免日志注入
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
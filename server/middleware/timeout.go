@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Timeout 为请求上下文设置截止时间的中间件，支持运行时调整超时时间
+// 超时后请求上下文被取消，后续的存储和缓存操作随之结束
+type Timeout struct {
+	timeout atomic.Int64 // time.Duration，0表示不限制
+}
+
+// NewTimeout 创建请求超时中间件
+func NewTimeout(timeout time.Duration) *Timeout {
+	m := &Timeout{}
+	m.Update(timeout)
+	return m
+}
+
+// Update 替换超时时间，对之后的请求生效
+func (m *Timeout) Update(timeout time.Duration) {
+	m.timeout.Store(int64(timeout))
+}
+
+// Handler 返回请求超时中间件
+func (m *Timeout) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timeout := time.Duration(m.timeout.Load())
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/jasonzheng/carrag/utils"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing 创建一个Gin中间件，为每个请求创建服务端span并将上下文传递给后续处理
+// 需在RequestID之后注册，以便在请求日志中附带trace_id
+func Tracing(logger *utils.Logger) gin.HandlerFunc {
+	tracer := otel.Tracer("github.com/jasonzheng/carrag/middleware")
+
+	return func(c *gin.Context) {
+		// 沿用上游传入的追踪上下文
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		ctx, span := tracer.Start(ctx, c.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPMethod(c.Request.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(c.Request.URL.Path),
+				semconv.ClientAddress(c.ClientIP()),
+			),
+		)
+		defer span.End()
+
+		if requestID := c.GetString(RequestIDKey); requestID != "" {
+			span.SetAttributes(attribute.String("request_id", requestID))
+		}
+		c.Request = c.Request.WithContext(ctx)
+		if span.SpanContext().IsValid() {
+			setRequestLogger(c, RequestLogger(c, logger).WithField("trace_id", span.SpanContext().TraceID().String()))
+		}
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPStatusCode(status))
+		if status >= 500 {
+			span.SetStatus(codes.Error, "")
+		}
+		if len(c.Errors) > 0 {
+			span.RecordError(c.Errors.Last())
+		}
+	}
+}
@@ -1,52 +1,143 @@
 package models
 
 import (
+	"context"
+	"errors"
+	"math"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jasonzheng/carrag/tracing"
 	"github.com/jasonzheng/carrag/utils"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // Car 车辆信息模型
 type Car struct {
-	ID                 string    `json:"id"`                           // 车辆唯一标识符
-	Brand              string    `json:"brand"`                        // 品牌
-	Model              string    `json:"model"`                        // 车型
-	FuelConsumption    float64   `json:"fuelConsumption,omitempty"`    // 油耗(L/100km)
-	FuelType           string    `json:"fuelType,omitempty"`           // 燃油类型
-	Mileage            float64   `json:"mileage,omitempty"`            // 行驶里程(km)
-	AnnualMileage      float64   `json:"annualMileage,omitempty"`      // 年均行驶里程(km)
-	StorageEnvironment string    `json:"storageEnvironment,omitempty"` // 存放环境
-	UsageScenario      []string  `json:"usageScenario,omitempty"`      // 使用场景
-	Remarks            string    `json:"remarks,omitempty"`            // 备注
-	CreatedAt          time.Time `json:"createdAt"`                    // 创建时间
-	UpdatedAt          time.Time `json:"updatedAt,omitempty"`          // 更新时间
+	ID                 string     `json:"id"`                           // 车辆唯一标识符
+	Brand              string     `json:"brand"`                        // 品牌
+	Model              string     `json:"model"`                        // 车型
+	FuelConsumption    float64    `json:"fuelConsumption,omitempty"`    // 油耗(L/100km，电动车为kWh/100km)
+	FuelType           string     `json:"fuelType,omitempty"`           // 燃油类型
+	Mileage            float64    `json:"mileage,omitempty"`            // 行驶里程(万km)
+	AnnualMileage      float64    `json:"annualMileage,omitempty"`      // 年均行驶里程(km)
+	PurchasePrice      float64    `json:"purchasePrice,omitempty"`      // 购车价格(元)
+	PurchaseDate       *time.Time `json:"purchaseDate,omitempty"`       // 购车日期，为空时按创建时间计算
+	AnnualInsurance    float64    `json:"annualInsurance,omitempty"`    // 每年保险费用(元)
+	StorageEnvironment string     `json:"storageEnvironment,omitempty"` // 存放环境
+	UsageScenario      []string   `json:"usageScenario,omitempty"`      // 使用场景
+	Remarks            string     `json:"remarks,omitempty"`            // 备注
+	CreatedAt          time.Time  `json:"createdAt"`                    // 创建时间
+	UpdatedAt          time.Time  `json:"updatedAt,omitempty"`          // 更新时间
+}
+
+// KmPerMileageUnit 行驶里程每单位对应的公里数，车辆信息中的行驶里程以万公里填写
+const KmPerMileageUnit = 10000
+
+// MileageKm 返回以公里为单位的行驶里程，保留到米
+// 万公里换算为公里有浮点误差(如30020公里换算后为30019.999999999996)，取整后与按公里记录的读数一致
+func (c *Car) MileageKm() float64 {
+	return math.Round(c.Mileage*KmPerMileageUnit*1000) / 1000
+}
+
+// SetMileageKm 按公里数设置行驶里程
+func (c *Car) SetMileageKm(km float64) {
+	c.Mileage = km / KmPerMileageUnit
+}
+
+// OwnedSince 返回开始持有车辆的日期，未填写购车日期时使用创建时间
+func (c *Car) OwnedSince() time.Time {
+	if c.PurchaseDate != nil {
+		return *c.PurchaseDate
+	}
+	return c.CreatedAt
 }
 
 // CarRepository 车辆信息仓库接口
 type CarRepository interface {
-	FindAll() ([]Car, error)                 // 获取所有车辆信息
-	FindByID(id string) (*Car, error)        // 根据ID获取车辆信息
-	Create(car *Car) error                   // 创建车辆信息
-	Update(car *Car) error                   // 更新车辆信息
-	Delete(id string) error                  // 删除车辆信息
-	FindByBrand(brand string) ([]Car, error) // 根据品牌查找车辆信息
+	FindAll(ctx context.Context) ([]Car, error)                                      // 获取所有车辆信息
+	FindByID(ctx context.Context, id string) (*Car, error)                           // 根据ID获取车辆信息
+	Create(ctx context.Context, car *Car) error                                      // 创建车辆信息
+	Update(ctx context.Context, car *Car) error                                      // 更新车辆信息
+	UpdateFuelConsumption(ctx context.Context, id string, consumption float64) error // 只更新车辆的油耗
+	Delete(ctx context.Context, id string) error                                     // 删除车辆信息
+	FindByBrand(ctx context.Context, brand string) ([]Car, error)                    // 根据品牌查找车辆信息
+}
+
+// ErrCarNotFound 车辆信息不存在，errors.Is(err, utils.ErrNotFound) 同样成立
+const ErrCarNotFound = utils.NotFoundError("车辆信息不存在")
+
+// DefaultCacheTTL 车辆信息缓存的默认过期时间
+const DefaultCacheTTL = 24 * time.Hour
+
+// 缓存键和标签
+const (
+	carCacheKeyPrefix   = "car:"        // 单条车辆信息
+	listCacheKey        = "cars:all"    // 车辆列表
+	brandCacheKeyPrefix = "cars:brand:" // 按品牌查询的结果
+	carsCacheTag        = "cars"        // 任意车辆变更时失效
+	brandCacheTagPrefix = "brand:"      // 该品牌下的车辆变更时失效
+)
+
+// cacheWriteTimeout 写入数据后更新缓存的超时时间
+const cacheWriteTimeout = 3 * time.Second
+
+// MileageTracker 跟踪车辆行驶里程的变化，在保存车辆信息前后调用
+type MileageTracker interface {
+	CheckMileage(ctx context.Context, car *Car) error  // 保存前校验行驶里程并补充由里程推算的字段，返回错误时不保存
+	RecordMileage(ctx context.Context, car *Car) error // 保存后记录行驶里程
 }
 
 // CarService 车辆信息服务
 type CarService struct {
-	Repo   CarRepository     // 车辆信息仓库
-	Logger *utils.Logger     // 日志记录器
-	Cache  *utils.RedisCache // Redis缓存
+	Repo               CarRepository // 车辆信息仓库
+	utils.LoggerHolder               // 日志记录器
+	Cache              utils.Cache   // 缓存，为nil时不使用缓存
+
+	// 缓存失效事件发布器，写入数据后通知其他实例清理本地缓存，为nil时不通知
+	Invalidations utils.InvalidationPublisher
+
+	cacheTTL    atomic.Int64                                    // 缓存过期时间，支持运行时调整
+	saveHooks   []func(ctx context.Context, car *Car) error     // 车辆创建或更新后执行的函数
+	deleteHooks []func(ctx context.Context, carID string) error // 车辆删除后执行的清理函数
+	mileage     MileageTracker                                  // 行驶里程跟踪，为nil时不跟踪
 }
 
 // NewCarService 创建车辆信息服务
-func NewCarService(repo CarRepository, logger *utils.Logger, cache *utils.RedisCache) *CarService {
-	return &CarService{
-		Repo:   repo,
-		Logger: logger,
-		Cache:  cache,
+func NewCarService(repo CarRepository, logger *utils.Logger, cache utils.Cache) *CarService {
+	service := &CarService{
+		Repo:         repo,
+		LoggerHolder: utils.LoggerHolder{Logger: logger},
+		Cache:        cache,
 	}
+	service.SetCacheTTL(DefaultCacheTTL)
+	return service
+}
+
+// SetCacheTTL 设置缓存过期时间，对之后写入的缓存生效
+func (s *CarService) SetCacheTTL(ttl time.Duration) {
+	s.cacheTTL.Store(int64(ttl))
+}
+
+// CacheTTL 获取当前缓存过期时间
+func (s *CarService) CacheTTL() time.Duration {
+	return time.Duration(s.cacheTTL.Load())
+}
+
+// OnSave 注册车辆创建或更新后执行的函数，用于维护依赖车辆信息的派生数据，需在处理请求前注册
+func (s *CarService) OnSave(fn func(ctx context.Context, car *Car) error) {
+	s.saveHooks = append(s.saveHooks, fn)
+}
+
+// OnDelete 注册车辆删除后执行的清理函数，用于删除依附于车辆的数据，需在处理请求前注册
+func (s *CarService) OnDelete(fn func(ctx context.Context, carID string) error) {
+	s.deleteHooks = append(s.deleteHooks, fn)
+}
+
+// TrackMileage 设置创建和更新车辆信息时使用的行驶里程跟踪，需在处理请求前设置
+func (s *CarService) TrackMileage(tracker MileageTracker) {
+	s.mileage = tracker
 }
 
 // GenerateID 生成唯一的车辆标识符
@@ -63,26 +154,31 @@ func GenerateID() string {
 }
 
 // GetAllCars 获取所有车辆信息
-func (s *CarService) GetAllCars() ([]Car, error) {
-	s.Logger.Info("获取所有车辆信息")
-	return s.Repo.FindAll()
+func (s *CarService) GetAllCars(ctx context.Context) (_ []Car, err error) {
+	ctx, span := tracing.Start(ctx, "CarService.GetAllCars")
+	defer tracing.End(span, &err)
+
+	s.LoggerFor(ctx).Info("获取所有车辆信息")
+	return s.cachedList(ctx, listCacheKey, []string{carsCacheTag}, func(ctx context.Context) ([]Car, error) {
+		return s.Repo.FindAll(ctx)
+	})
 }
 
 // GetCarByID 根据ID获取车辆信息
-func (s *CarService) GetCarByID(id string) (*Car, error) {
-	s.Logger.Info("获取车辆信息，ID: %s", id)
+func (s *CarService) GetCarByID(ctx context.Context, id string) (_ *Car, err error) {
+	ctx, span := tracing.Start(ctx, "CarService.GetCarByID", attribute.String("car.id", id))
+	defer tracing.End(span, &err)
+
+	s.LoggerFor(ctx).Info("获取车辆信息，ID: %s", id)
 
 	// 定义一个空的Car指针用于存储结果
 	var car Car
 
 	// 如果缓存可用，尝试从缓存获取
 	if s.Cache != nil {
-		// 创建上下文
-		ctx := s.Cache.Client.Context()
-
 		// 定义回退函数，从数据库获取数据
-		fallback := func() (interface{}, error) {
-			result, err := s.Repo.FindByID(id)
+		fallback := func(ctx context.Context) (interface{}, error) {
+			result, err := s.Repo.FindByID(ctx, id)
 			if err != nil {
 				return nil, err
 			}
@@ -90,9 +186,12 @@ func (s *CarService) GetCarByID(id string) (*Car, error) {
 		}
 
 		// 使用缓存获取，如果缓存不存在则使用回退函数获取并更新缓存
-		err := s.Cache.GetWithFallback(ctx, "car:"+id, &car, fallback, 24*time.Hour)
+		err = s.Cache.GetWithFallback(ctx, carCacheKeyPrefix+id, &car, fallback, s.CacheTTL())
 		if err != nil {
-			s.Logger.Error("获取车辆信息失败: %v", err)
+			// 数据不存在(包括缓存的"不存在"结果)由调用方处理，不记录为错误
+			if !errors.Is(err, utils.ErrNotFound) {
+				s.LoggerFor(ctx).Error("获取车辆信息失败: %v", err)
+			}
 			return nil, err
 		}
 
@@ -100,87 +199,272 @@ func (s *CarService) GetCarByID(id string) (*Car, error) {
 	}
 
 	// 缓存不可用，直接从数据库获取
-	return s.Repo.FindByID(id)
+	return s.Repo.FindByID(ctx, id)
 }
 
 // CreateCar 创建车辆信息
-func (s *CarService) CreateCar(car *Car) error {
-	s.Logger.Info("创建车辆信息: %s %s", car.Brand, car.Model)
+func (s *CarService) CreateCar(ctx context.Context, car *Car) (err error) {
+	ctx, span := tracing.Start(ctx, "CarService.CreateCar")
+	defer tracing.End(span, &err)
+
+	s.LoggerFor(ctx).Info("创建车辆信息: %s %s", car.Brand, car.Model)
 
 	// 设置ID和时间
 	car.ID = GenerateID()
 	car.CreatedAt = time.Now()
 
+	if err := s.checkMileage(ctx, car); err != nil {
+		return err
+	}
+
 	// 保存到数据库
-	err := s.Repo.Create(car)
+	err = s.Repo.Create(ctx, car)
 	if err != nil {
-		s.Logger.Error("创建车辆信息失败: %v", err)
+		s.LoggerFor(ctx).Error("创建车辆信息失败: %v", err)
 		return err
 	}
+	s.recordMileage(ctx, car)
+	s.runSaveHooks(ctx, car)
 
-	// 如果缓存可用，保存到缓存
+	// 如果缓存可用，保存到缓存并使列表缓存失效
 	if s.Cache != nil {
-		ctx := s.Cache.Client.Context()
-		if err := s.Cache.Set(ctx, "car:"+car.ID, car, 24*time.Hour); err != nil {
-			s.Logger.Warning("缓存车辆信息失败: %v", err)
+		cacheCtx, cancel := cacheWriteContext(ctx)
+		defer cancel()
+		if err := s.Cache.Set(cacheCtx, carCacheKeyPrefix+car.ID, car, s.CacheTTL()); err != nil {
+			s.LoggerFor(ctx).Warning("缓存车辆信息失败: %v", err)
 			// 缓存失败不影响正常返回
 		}
+		s.invalidate(cacheCtx, car.ID, car.Brand)
 	}
 
 	return nil
 }
 
 // UpdateCar 更新车辆信息
-func (s *CarService) UpdateCar(car *Car) error {
-	s.Logger.Info("更新车辆信息，ID: %s", car.ID)
+func (s *CarService) UpdateCar(ctx context.Context, car *Car) (err error) {
+	ctx, span := tracing.Start(ctx, "CarService.UpdateCar", attribute.String("car.id", car.ID))
+	defer tracing.End(span, &err)
+
+	s.LoggerFor(ctx).Info("更新车辆信息，ID: %s", car.ID)
 
 	// 设置更新时间
 	car.UpdatedAt = time.Now()
 
+	// 品牌可能被修改，需要同时使原品牌的查询缓存失效
+	brands, err := s.brandsForInvalidation(ctx, car.ID)
+	if err != nil {
+		s.LoggerFor(ctx).Error("更新车辆信息失败: %v", err)
+		return err
+	}
+	if err := s.checkMileage(ctx, car); err != nil {
+		return err
+	}
+
 	// 更新数据库
-	err := s.Repo.Update(car)
+	err = s.Repo.Update(ctx, car)
 	if err != nil {
-		s.Logger.Error("更新车辆信息失败: %v", err)
+		s.LoggerFor(ctx).Error("更新车辆信息失败: %v", err)
 		return err
 	}
+	s.recordMileage(ctx, car)
+	s.runSaveHooks(ctx, car)
 
-	// 如果缓存可用，更新缓存
+	// 如果缓存可用，更新缓存并使列表缓存失效
 	if s.Cache != nil {
-		ctx := s.Cache.Client.Context()
-		if err := s.Cache.Set(ctx, "car:"+car.ID, car, 24*time.Hour); err != nil {
-			s.Logger.Warning("更新缓存失败: %v", err)
+		cacheCtx, cancel := cacheWriteContext(ctx)
+		defer cancel()
+		if err := s.Cache.Set(cacheCtx, carCacheKeyPrefix+car.ID, car, s.CacheTTL()); err != nil {
+			s.LoggerFor(ctx).Warning("更新缓存失败: %v", err)
 			// 缓存失败不影响正常返回
 		}
+		s.invalidate(cacheCtx, car.ID, append(brands, car.Brand)...)
+	}
+
+	return nil
+}
+
+// UpdateFuelConsumption 只更新车辆的油耗，用于写回由加油记录计算的平均油耗
+// 不经过里程校验，也不修改更新时间；保存后重新读取车辆信息并执行保存后的函数，使搜索和语义检索索引中的油耗保持最新
+func (s *CarService) UpdateFuelConsumption(ctx context.Context, id string, consumption float64) (err error) {
+	ctx, span := tracing.Start(ctx, "CarService.UpdateFuelConsumption", attribute.String("car.id", id))
+	defer tracing.End(span, &err)
+
+	brands, err := s.brandsForInvalidation(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.Repo.UpdateFuelConsumption(ctx, id, consumption); err != nil {
+		s.LoggerFor(ctx).Error("更新车辆油耗失败: %v", err)
+		return err
+	}
+	if car, err := s.Repo.FindByID(ctx, id); err != nil {
+		s.LoggerFor(ctx).Warning("更新车辆 %s 的关联数据失败: %v", id, err)
+	} else {
+		s.runSaveHooks(ctx, car)
 	}
 
+	// 如果缓存可用，删除车辆缓存并使列表缓存失效
+	if s.Cache != nil {
+		cacheCtx, cancel := cacheWriteContext(ctx)
+		defer cancel()
+		if err := s.Cache.Delete(cacheCtx, carCacheKeyPrefix+id); err != nil {
+			s.LoggerFor(ctx).Warning("从缓存删除失败: %v", err)
+		}
+		s.invalidate(cacheCtx, id, brands...)
+	}
 	return nil
 }
 
 // DeleteCar 删除车辆信息
-func (s *CarService) DeleteCar(id string) error {
-	s.Logger.Info("删除车辆信息，ID: %s", id)
+func (s *CarService) DeleteCar(ctx context.Context, id string) (err error) {
+	ctx, span := tracing.Start(ctx, "CarService.DeleteCar", attribute.String("car.id", id))
+	defer tracing.End(span, &err)
+
+	s.LoggerFor(ctx).Info("删除车辆信息，ID: %s", id)
+
+	// 记录被删除车辆的品牌，用于使该品牌的查询缓存失效
+	brands, err := s.brandsForInvalidation(ctx, id)
+	if err != nil {
+		s.LoggerFor(ctx).Error("删除车辆信息失败: %v", err)
+		return err
+	}
 
 	// 从数据库删除
-	err := s.Repo.Delete(id)
+	err = s.Repo.Delete(ctx, id)
 	if err != nil {
-		s.Logger.Error("删除车辆信息失败: %v", err)
+		s.LoggerFor(ctx).Error("删除车辆信息失败: %v", err)
 		return err
 	}
 
-	// 如果缓存可用，从缓存删除
+	// 删除依附于车辆的数据，车辆已删除，不随请求取消，失败时只记录警告
+	cleanupCtx := utils.WithoutCancel(ctx)
+	for _, hook := range s.deleteHooks {
+		if err := hook(cleanupCtx, id); err != nil {
+			s.LoggerFor(ctx).Warning("清理车辆 %s 的关联数据失败: %v", id, err)
+		}
+	}
+
+	// 如果缓存可用，从缓存删除并使列表缓存失效
 	if s.Cache != nil {
-		ctx := s.Cache.Client.Context()
-		if err := s.Cache.Delete(ctx, "car:"+id); err != nil {
-			s.Logger.Warning("从缓存删除失败: %v", err)
+		cacheCtx, cancel := cacheWriteContext(ctx)
+		defer cancel()
+		if err := s.Cache.Delete(cacheCtx, carCacheKeyPrefix+id); err != nil {
+			s.LoggerFor(ctx).Warning("从缓存删除失败: %v", err)
 			// 缓存失败不影响正常返回
 		}
+		s.invalidate(cacheCtx, id, brands...)
 	}
 
 	return nil
 }
 
 // FindCarsByBrand 根据品牌查找车辆信息
-func (s *CarService) FindCarsByBrand(brand string) ([]Car, error) {
-	s.Logger.Info("根据品牌查找车辆信息: %s", brand)
-	return s.Repo.FindByBrand(brand)
+func (s *CarService) FindCarsByBrand(ctx context.Context, brand string) (_ []Car, err error) {
+	ctx, span := tracing.Start(ctx, "CarService.FindCarsByBrand", attribute.String("car.brand", brand))
+	defer tracing.End(span, &err)
+
+	s.LoggerFor(ctx).Info("根据品牌查找车辆信息: %s", brand)
+	return s.cachedList(ctx, brandCacheKeyPrefix+brand, []string{brandCacheTagPrefix + brand}, func(ctx context.Context) ([]Car, error) {
+		return s.Repo.FindByBrand(ctx, brand)
+	})
+}
+
+// cachedList 从缓存读取车辆列表，未命中时通过load加载并写入缓存
+// 缓存键附带tags的当前版本号，标签失效后自动回源加载
+func (s *CarService) cachedList(ctx context.Context, key string, tags []string, load func(ctx context.Context) ([]Car, error)) ([]Car, error) {
+	if s.Cache == nil {
+		return load(ctx)
+	}
+
+	taggedKey, err := s.Cache.TaggedKey(ctx, key, tags...)
+	if err != nil {
+		// 无法确认标签版本时不能保证缓存数据最新，直接查询仓库
+		if !errors.Is(err, utils.ErrCacheUnavailable) {
+			s.LoggerFor(ctx).Warning("获取缓存键失败，直接查询: %v", err)
+		}
+		return load(ctx)
+	}
+
+	var cars []Car
+	fallback := func(ctx context.Context) (interface{}, error) {
+		return load(ctx)
+	}
+	if err := s.Cache.GetWithFallback(ctx, taggedKey, &cars, fallback, s.CacheTTL()); err != nil {
+		s.LoggerFor(ctx).Error("获取车辆列表失败: %v", err)
+		return nil, err
+	}
+	return cars, nil
+}
+
+// checkMileage 保存车辆信息前校验行驶里程
+func (s *CarService) checkMileage(ctx context.Context, car *Car) error {
+	if s.mileage == nil {
+		return nil
+	}
+	return s.mileage.CheckMileage(ctx, car)
+}
+
+// recordMileage 保存车辆信息后记录行驶里程，车辆信息已保存，失败时只记录警告
+func (s *CarService) recordMileage(ctx context.Context, car *Car) {
+	if s.mileage == nil {
+		return
+	}
+	if err := s.mileage.RecordMileage(utils.WithoutCancel(ctx), car); err != nil {
+		s.LoggerFor(ctx).Warning("记录车辆 %s 的行驶里程失败: %v", car.ID, err)
+	}
+}
+
+// runSaveHooks 执行车辆保存后的函数，车辆信息已保存，不随请求取消，失败时只记录警告
+func (s *CarService) runSaveHooks(ctx context.Context, car *Car) {
+	hookCtx := utils.WithoutCancel(ctx)
+	for _, hook := range s.saveHooks {
+		if err := hook(hookCtx, car); err != nil {
+			s.LoggerFor(ctx).Warning("更新车辆 %s 的关联数据失败: %v", car.ID, err)
+		}
+	}
+}
+
+// brandsForInvalidation 返回修改或删除车辆前该车辆所属的品牌，缓存不可用时无需查询
+func (s *CarService) brandsForInvalidation(ctx context.Context, id string) ([]string, error) {
+	if s.Cache == nil {
+		return nil, nil
+	}
+	existing, err := s.Repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return []string{existing.Brand}, nil
+}
+
+// invalidate 使车辆列表和指定品牌的查询缓存失效，并通知其他实例清理该车辆和这些列表的本地缓存
+// 失效失败时列表可能在缓存过期前返回旧数据，因此记录为错误
+func (s *CarService) invalidate(ctx context.Context, id string, brands ...string) {
+	tags := []string{carsCacheTag}
+	seen := make(map[string]bool, len(brands))
+	for _, brand := range brands {
+		if !seen[brand] {
+			seen[brand] = true
+			tags = append(tags, brandCacheTagPrefix+brand)
+		}
+	}
+	if err := s.Cache.InvalidateTags(ctx, tags...); err != nil {
+		s.LoggerFor(ctx).Error("使列表缓存失效失败，列表查询可能在缓存过期前返回旧数据: %v", err)
+	}
+
+	if s.Invalidations == nil {
+		return
+	}
+	if err := s.Invalidations.Publish(ctx, []string{carCacheKeyPrefix + id}, tags); err != nil {
+		if errors.Is(err, utils.ErrCacheUnavailable) {
+			s.LoggerFor(ctx).Debug("通知其他实例失败: %v", err)
+			return
+		}
+		s.LoggerFor(ctx).Warning("通知其他实例失败，其他实例的本地缓存可能在过期前返回旧数据: %v", err)
+	}
+}
+
+// cacheWriteContext 返回写入数据后更新缓存使用的上下文
+// 不随请求取消，避免客户端断开导致缓存与文件数据不一致
+func cacheWriteContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(utils.WithoutCancel(ctx), cacheWriteTimeout)
 }
@@ -0,0 +1,80 @@
+package models
+
+// 车身类型
+const (
+	CategorySedan = "轿车"
+	CategorySUV   = "SUV"
+	CategoryMPV   = "MPV"
+)
+
+// CatalogModel 车型目录中的车型
+type CatalogModel struct {
+	Name     string `json:"name"`     // 车型名称
+	Category string `json:"category"` // 车身类型
+}
+
+// CatalogBrand 车型目录中的品牌
+type CatalogBrand struct {
+	Brand   string         `json:"brand"`             // 品牌
+	Aliases []string       `json:"aliases,omitempty"` // 品牌的其他叫法，如英文名
+	Models  []CatalogModel `json:"models"`            // 车型
+}
+
+// DefaultCatalog 内置的车型目录，与前端 src/data/carModels.json 中的品牌和车型一致，并补充了车身类型
+var DefaultCatalog = []CatalogBrand{
+	{Brand: "奥迪", Aliases: []string{"Audi"}, Models: []CatalogModel{
+		{"A3", CategorySedan}, {"A4", CategorySedan}, {"A6", CategorySedan},
+		{"Q3", CategorySUV}, {"Q5", CategorySUV}, {"Q7", CategorySUV},
+	}},
+	{Brand: "宝马", Aliases: []string{"BMW"}, Models: []CatalogModel{
+		{"1系", CategorySedan}, {"3系", CategorySedan}, {"5系", CategorySedan}, {"7系", CategorySedan},
+		{"X1", CategorySUV}, {"X3", CategorySUV}, {"X5", CategorySUV},
+	}},
+	{Brand: "奔驰", Aliases: []string{"Benz", "Mercedes", "梅赛德斯"}, Models: []CatalogModel{
+		{"A级", CategorySedan}, {"C级", CategorySedan}, {"E级", CategorySedan}, {"S级", CategorySedan},
+		{"GLA", CategorySUV}, {"GLC", CategorySUV}, {"GLE", CategorySUV},
+	}},
+	{Brand: "大众", Aliases: []string{"VW", "Volkswagen"}, Models: []CatalogModel{
+		{"高尔夫", CategorySedan}, {"速腾", CategorySedan}, {"帕萨特", CategorySedan},
+		{"途观", CategorySUV}, {"途昂", CategorySUV}, {"迈腾", CategorySedan},
+	}},
+	{Brand: "丰田", Aliases: []string{"Toyota"}, Models: []CatalogModel{
+		{"卡罗拉", CategorySedan}, {"凯美瑞", CategorySedan}, {"汉兰达", CategorySUV},
+		{"RAV4", CategorySUV}, {"普拉多", CategorySUV}, {"兰德酷路泽", CategorySUV},
+	}},
+	{Brand: "本田", Aliases: []string{"Honda"}, Models: []CatalogModel{
+		{"思域", CategorySedan}, {"雅阁", CategorySedan}, {"CR-V", CategorySUV},
+		{"冠道", CategorySUV}, {"缤智", CategorySUV}, {"奥德赛", CategoryMPV},
+	}},
+	{Brand: "日产", Aliases: []string{"Nissan", "尼桑"}, Models: []CatalogModel{
+		{"轩逸", CategorySedan}, {"天籁", CategorySedan}, {"奇骏", CategorySUV},
+		{"楼兰", CategorySUV}, {"逍客", CategorySUV}, {"途达", CategorySUV},
+	}},
+	{Brand: "现代", Aliases: []string{"Hyundai"}, Models: []CatalogModel{
+		{"伊兰特", CategorySedan}, {"索纳塔", CategorySedan}, {"途胜", CategorySUV},
+		{"胜达", CategorySUV}, {"ix35", CategorySUV}, {"KONA", CategorySUV},
+	}},
+	{Brand: "起亚", Aliases: []string{"Kia"}, Models: []CatalogModel{
+		{"K3", CategorySedan}, {"K5", CategorySedan}, {"KX5", CategorySUV},
+		{"KX7", CategorySUV}, {"狮跑", CategorySUV}, {"智跑", CategorySUV},
+	}},
+	{Brand: "福特", Aliases: []string{"Ford"}, Models: []CatalogModel{
+		{"福克斯", CategorySedan}, {"蒙迪欧", CategorySedan}, {"锐界", CategorySUV},
+		{"翼虎", CategorySUV}, {"探险者", CategorySUV}, {"福睿斯", CategorySedan},
+	}},
+	{Brand: "别克", Aliases: []string{"Buick"}, Models: []CatalogModel{
+		{"英朗", CategorySedan}, {"君威", CategorySedan}, {"君越", CategorySedan},
+		{"昂科威", CategorySUV}, {"昂科拉", CategorySUV}, {"GL8", CategoryMPV},
+	}},
+	{Brand: "雪佛兰", Aliases: []string{"Chevrolet", "Chevy"}, Models: []CatalogModel{
+		{"科鲁兹", CategorySedan}, {"迈锐宝", CategorySedan}, {"科帕奇", CategorySUV},
+		{"探界者", CategorySUV}, {"创酷", CategorySUV}, {"赛欧", CategorySedan},
+	}},
+}
+
+// categoryAliases 车身类型的其他叫法
+var categoryAliases = map[string][]string{
+	CategorySedan: {"三厢车", "家用轿车"},
+	CategorySUV:   {"越野车", "城市SUV"},
+	CategoryMPV:   {"商务车", "保姆车"},
+}
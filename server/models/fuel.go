@@ -0,0 +1,400 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/jasonzheng/carrag/tracing"
+	"github.com/jasonzheng/carrag/utils"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// FuelRecord 加油记录
+type FuelRecord struct {
+	ID            string    `json:"id"`                  // 记录唯一标识符
+	CarID         string    `json:"carId"`               // 所属车辆ID
+	Date          time.Time `json:"date"`                // 加油日期
+	Odometer      float64   `json:"odometer"`            // 加油时的里程表读数(km)
+	Litres        float64   `json:"litres"`              // 加油量(L)
+	PricePerLitre float64   `json:"pricePerLitre"`       // 单价(元/L)
+	TotalCost     float64   `json:"totalCost"`           // 总价(元)，由加油量和单价计算
+	FuelGrade     string    `json:"fuelGrade,omitempty"` // 燃油标号，如 "92#"
+	FullTank      bool      `json:"fullTank"`            // 是否加满
+	Notes         string    `json:"notes,omitempty"`     // 备注
+	CreatedAt     time.Time `json:"createdAt"`           // 创建时间
+	UpdatedAt     time.Time `json:"updatedAt,omitempty"` // 更新时间
+}
+
+// Validate 校验加油记录
+func (r *FuelRecord) Validate() error {
+	switch {
+	case r.Date.IsZero():
+		return utils.ValidationError("加油日期不能为空")
+	case r.Odometer < 0:
+		return utils.ValidationError("里程表读数不能为负数")
+	case r.Litres <= 0:
+		return utils.ValidationError("加油量必须大于0")
+	case r.PricePerLitre < 0:
+		return utils.ValidationError("单价不能为负数")
+	}
+	return nil
+}
+
+// FuelRepository 加油记录仓库接口
+type FuelRepository interface {
+	FindAll(ctx context.Context) ([]FuelRecord, error)                   // 获取所有车辆的加油记录
+	FindByCar(ctx context.Context, carID string) ([]FuelRecord, error)   // 获取车辆的所有加油记录
+	FindByID(ctx context.Context, carID, id string) (*FuelRecord, error) // 根据ID获取车辆的加油记录
+	Create(ctx context.Context, record *FuelRecord) error                // 创建加油记录
+	Update(ctx context.Context, record *FuelRecord) error                // 更新加油记录
+	Delete(ctx context.Context, carID, id string) error                  // 删除加油记录
+	DeleteByCar(ctx context.Context, carID string) (int, error)          // 删除车辆的所有加油记录，返回删除的条数
+}
+
+// ErrFuelRecordNotFound 加油记录不存在，errors.Is(err, utils.ErrNotFound) 同样成立
+const ErrFuelRecordNotFound = utils.NotFoundError("加油记录不存在")
+
+// FuelLogOptions 油耗计算选项
+type FuelLogOptions struct {
+	RollingWindow        int  // 滚动平均油耗包含的满箱区间数
+	UpdateCarConsumption bool // 加油记录变更后是否将平均油耗写回车辆信息
+}
+
+// FuelSegment 两次加满之间的区间
+type FuelSegment struct {
+	From           time.Time `json:"from"`           // 区间起点(上次加满)的日期
+	To             time.Time `json:"to"`             // 区间终点(本次加满)的日期
+	Distance       float64   `json:"distance"`       // 行驶里程(km)
+	Litres         float64   `json:"litres"`         // 区间内的加油量(L)
+	Cost           float64   `json:"cost"`           // 区间内的加油费用(元)
+	Consumption    float64   `json:"consumption"`    // 油耗(L/100km)
+	RollingAverage float64   `json:"rollingAverage"` // 截至本区间的滚动平均油耗(L/100km)
+}
+
+// FuelStats 车辆的油耗统计
+type FuelStats struct {
+	CarID              string        `json:"carId"`              // 车辆ID
+	Records            int           `json:"records"`            // 加油记录数
+	TotalLitres        float64       `json:"totalLitres"`        // 总加油量(L)
+	TotalCost          float64       `json:"totalCost"`          // 总加油费用(元)
+	Distance           float64       `json:"distance"`           // 满箱区间的总里程(km)
+	AverageConsumption float64       `json:"averageConsumption"` // 平均油耗(L/100km)，至少两次加满后才能计算
+	RollingAverage     float64       `json:"rollingAverage"`     // 最近若干区间的平均油耗(L/100km)
+	CostPerKm          float64       `json:"costPerKm"`          // 每公里燃油费用(元/km)
+	Segments           []FuelSegment `json:"segments"`           // 满箱区间明细
+}
+
+// FuelService 加油记录服务
+type FuelService struct {
+	Repo               FuelRepository // 加油记录仓库
+	Cars               *CarService    // 车辆服务，用于确认车辆存在和写回平均油耗
+	utils.LoggerHolder                // 日志记录器
+
+	options atomic.Pointer[FuelLogOptions] // 油耗计算选项，支持运行时调整
+}
+
+// NewFuelService 创建加油记录服务，车辆删除时一并删除其加油记录
+func NewFuelService(repo FuelRepository, cars *CarService, logger *utils.Logger) *FuelService {
+	service := &FuelService{
+		Repo:         repo,
+		Cars:         cars,
+		LoggerHolder: utils.LoggerHolder{Logger: logger},
+	}
+	service.SetOptions(FuelLogOptions{RollingWindow: 3})
+	cars.OnDelete(service.deleteByCar)
+	return service
+}
+
+// SetOptions 设置油耗计算选项
+func (s *FuelService) SetOptions(options FuelLogOptions) {
+	s.options.Store(&options)
+}
+
+// Options 获取当前油耗计算选项
+func (s *FuelService) Options() FuelLogOptions {
+	return *s.options.Load()
+}
+
+// ListRecords 获取车辆的所有加油记录，按日期从新到旧排序
+func (s *FuelService) ListRecords(ctx context.Context, carID string) (_ []FuelRecord, err error) {
+	ctx, span := tracing.Start(ctx, "FuelService.ListRecords", attribute.String("car.id", carID))
+	defer tracing.End(span, &err)
+
+	s.LoggerFor(ctx).Info("获取车辆加油记录，车辆ID: %s", carID)
+	if _, err := s.Cars.GetCarByID(ctx, carID); err != nil {
+		return nil, err
+	}
+
+	records, err := s.Repo.FindByCar(ctx, carID)
+	if err != nil {
+		s.LoggerFor(ctx).Error("获取加油记录失败: %v", err)
+		return nil, err
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Date.After(records[j].Date)
+	})
+	return records, nil
+}
+
+// GetRecord 根据ID获取车辆的加油记录
+func (s *FuelService) GetRecord(ctx context.Context, carID, id string) (_ *FuelRecord, err error) {
+	ctx, span := tracing.Start(ctx, "FuelService.GetRecord", attribute.String("car.id", carID), attribute.String("fuel.id", id))
+	defer tracing.End(span, &err)
+
+	s.LoggerFor(ctx).Info("获取加油记录，车辆ID: %s，记录ID: %s", carID, id)
+	return s.Repo.FindByID(ctx, carID, id)
+}
+
+// CreateRecord 为车辆创建加油记录
+func (s *FuelService) CreateRecord(ctx context.Context, record *FuelRecord) (err error) {
+	ctx, span := tracing.Start(ctx, "FuelService.CreateRecord", attribute.String("car.id", record.CarID))
+	defer tracing.End(span, &err)
+
+	s.LoggerFor(ctx).Info("创建加油记录，车辆ID: %s，里程: %v", record.CarID, record.Odometer)
+	if err := record.Validate(); err != nil {
+		return err
+	}
+	if _, err := s.Cars.GetCarByID(ctx, record.CarID); err != nil {
+		return err
+	}
+	existing, err := s.Repo.FindByCar(ctx, record.CarID)
+	if err != nil {
+		return err
+	}
+	if err := checkOdometer(record, existing); err != nil {
+		return err
+	}
+
+	record.ID = GenerateID()
+	record.TotalCost = roundTo(record.Litres*record.PricePerLitre, 2)
+	record.CreatedAt = time.Now()
+	record.UpdatedAt = time.Time{}
+	if err := s.Repo.Create(ctx, record); err != nil {
+		s.LoggerFor(ctx).Error("创建加油记录失败: %v", err)
+		return err
+	}
+
+	s.syncCarConsumption(ctx, record.CarID)
+	return nil
+}
+
+// UpdateRecord 更新车辆的加油记录
+func (s *FuelService) UpdateRecord(ctx context.Context, record *FuelRecord) (err error) {
+	ctx, span := tracing.Start(ctx, "FuelService.UpdateRecord", attribute.String("car.id", record.CarID), attribute.String("fuel.id", record.ID))
+	defer tracing.End(span, &err)
+
+	s.LoggerFor(ctx).Info("更新加油记录，车辆ID: %s，记录ID: %s", record.CarID, record.ID)
+	if err := record.Validate(); err != nil {
+		return err
+	}
+
+	records, err := s.Repo.FindByCar(ctx, record.CarID)
+	if err != nil {
+		return err
+	}
+	var existing *FuelRecord
+	others := make([]FuelRecord, 0, len(records))
+	for i := range records {
+		if records[i].ID == record.ID {
+			existing = &records[i]
+			continue
+		}
+		others = append(others, records[i])
+	}
+	if existing == nil {
+		return fmt.Errorf("%w: %s", ErrFuelRecordNotFound, record.ID)
+	}
+	if err := checkOdometer(record, others); err != nil {
+		return err
+	}
+
+	record.TotalCost = roundTo(record.Litres*record.PricePerLitre, 2)
+	record.CreatedAt = existing.CreatedAt
+	record.UpdatedAt = time.Now()
+	if err := s.Repo.Update(ctx, record); err != nil {
+		s.LoggerFor(ctx).Error("更新加油记录失败: %v", err)
+		return err
+	}
+
+	s.syncCarConsumption(ctx, record.CarID)
+	return nil
+}
+
+// DeleteRecord 删除车辆的加油记录
+func (s *FuelService) DeleteRecord(ctx context.Context, carID, id string) (err error) {
+	ctx, span := tracing.Start(ctx, "FuelService.DeleteRecord", attribute.String("car.id", carID), attribute.String("fuel.id", id))
+	defer tracing.End(span, &err)
+
+	s.LoggerFor(ctx).Info("删除加油记录，车辆ID: %s，记录ID: %s", carID, id)
+	if err := s.Repo.Delete(ctx, carID, id); err != nil {
+		return err
+	}
+
+	s.syncCarConsumption(ctx, carID)
+	return nil
+}
+
+// Stats 计算车辆的油耗统计
+func (s *FuelService) Stats(ctx context.Context, carID string) (_ *FuelStats, err error) {
+	ctx, span := tracing.Start(ctx, "FuelService.Stats", attribute.String("car.id", carID))
+	defer tracing.End(span, &err)
+
+	s.LoggerFor(ctx).Info("计算车辆油耗，车辆ID: %s", carID)
+	if _, err := s.Cars.GetCarByID(ctx, carID); err != nil {
+		return nil, err
+	}
+	records, err := s.Repo.FindByCar(ctx, carID)
+	if err != nil {
+		s.LoggerFor(ctx).Error("获取加油记录失败: %v", err)
+		return nil, err
+	}
+	return ComputeFuelStats(carID, records, s.Options().RollingWindow), nil
+}
+
+// syncCarConsumption 按配置将平均油耗写回车辆信息，失败时只记录警告
+func (s *FuelService) syncCarConsumption(ctx context.Context, carID string) {
+	if !s.Options().UpdateCarConsumption {
+		return
+	}
+
+	records, err := s.Repo.FindByCar(ctx, carID)
+	if err != nil {
+		s.LoggerFor(ctx).Warning("写回平均油耗失败: %v", err)
+		return
+	}
+	stats := ComputeFuelStats(carID, records, s.Options().RollingWindow)
+	if stats.AverageConsumption == 0 {
+		return
+	}
+
+	car, err := s.Cars.GetCarByID(ctx, carID)
+	if err != nil {
+		s.LoggerFor(ctx).Warning("写回平均油耗失败: %v", err)
+		return
+	}
+	if car.FuelConsumption == stats.AverageConsumption {
+		return
+	}
+	if err := s.Cars.UpdateFuelConsumption(ctx, carID, stats.AverageConsumption); err != nil {
+		s.LoggerFor(ctx).Warning("写回平均油耗失败: %v", err)
+		return
+	}
+	s.LoggerFor(ctx).Info("已将车辆 %s 的油耗更新为 %v L/100km", carID, stats.AverageConsumption)
+}
+
+// deleteByCar 删除车辆的所有加油记录，在车辆删除后调用
+func (s *FuelService) deleteByCar(ctx context.Context, carID string) error {
+	deleted, err := s.Repo.DeleteByCar(ctx, carID)
+	if err != nil {
+		return fmt.Errorf("删除加油记录失败: %w", err)
+	}
+	if deleted > 0 {
+		s.LoggerFor(ctx).Info("已删除车辆 %s 的 %d 条加油记录", carID, deleted)
+	}
+	return nil
+}
+
+// checkOdometer 检查里程表读数与同一车辆的其他加油记录是否一致：日期更早的记录读数不能更大
+func checkOdometer(record *FuelRecord, others []FuelRecord) error {
+	for _, other := range others {
+		if (other.Date.Before(record.Date) && other.Odometer > record.Odometer) ||
+			(other.Date.After(record.Date) && other.Odometer < record.Odometer) {
+			return utils.ValidationError(fmt.Sprintf("里程表读数 %v 与 %s 的加油记录(%v)不一致",
+				record.Odometer, other.Date.Format("2006-01-02"), other.Odometer))
+		}
+	}
+	return nil
+}
+
+// ComputeFuelStats 按满箱法计算油耗：两次加满之间的加油量(不含起点那次)除以行驶里程
+// 第一次加满之前的加油记录无法确定对应的里程，不计入油耗
+func ComputeFuelStats(carID string, records []FuelRecord, rollingWindow int) *FuelStats {
+	sorted := make([]FuelRecord, len(records))
+	copy(sorted, records)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Odometer != sorted[j].Odometer {
+			return sorted[i].Odometer < sorted[j].Odometer
+		}
+		return sorted[i].Date.Before(sorted[j].Date)
+	})
+
+	stats := &FuelStats{CarID: carID, Records: len(sorted), Segments: []FuelSegment{}}
+	var (
+		lastFull      *FuelRecord
+		pendingLitres float64
+		pendingCost   float64
+		segmentLitres float64
+		segmentCost   float64
+	)
+	for i := range sorted {
+		record := &sorted[i]
+		stats.TotalLitres += record.Litres
+		stats.TotalCost += record.TotalCost
+		if lastFull == nil {
+			if record.FullTank {
+				lastFull = record
+			}
+			continue
+		}
+
+		pendingLitres += record.Litres
+		pendingCost += record.TotalCost
+		if !record.FullTank {
+			continue
+		}
+
+		distance := record.Odometer - lastFull.Odometer
+		if distance > 0 {
+			segment := FuelSegment{
+				From:        lastFull.Date,
+				To:          record.Date,
+				Distance:    roundTo(distance, 1),
+				Litres:      roundTo(pendingLitres, 2),
+				Cost:        roundTo(pendingCost, 2),
+				Consumption: roundTo(pendingLitres/distance*100, 2),
+			}
+			stats.Segments = append(stats.Segments, segment)
+			stats.Distance += distance
+			segmentLitres += pendingLitres
+			segmentCost += pendingCost
+		}
+		lastFull = record
+		pendingLitres, pendingCost = 0, 0
+	}
+
+	if rollingWindow <= 0 {
+		rollingWindow = 1
+	}
+	for i := range stats.Segments {
+		start := i + 1 - rollingWindow
+		if start < 0 {
+			start = 0
+		}
+		var distance, litres float64
+		for _, segment := range stats.Segments[start : i+1] {
+			distance += segment.Distance
+			litres += segment.Litres
+		}
+		stats.Segments[i].RollingAverage = roundTo(litres/distance*100, 2)
+	}
+
+	if stats.Distance > 0 {
+		stats.AverageConsumption = roundTo(segmentLitres/stats.Distance*100, 2)
+		stats.CostPerKm = roundTo(segmentCost/stats.Distance, 3)
+		stats.RollingAverage = stats.Segments[len(stats.Segments)-1].RollingAverage
+	}
+	stats.TotalLitres = roundTo(stats.TotalLitres, 2)
+	stats.TotalCost = roundTo(stats.TotalCost, 2)
+	stats.Distance = roundTo(stats.Distance, 1)
+	return stats
+}
+
+// roundTo 四舍五入到指定的小数位数
+func roundTo(value float64, digits int) float64 {
+	scale := math.Pow(10, float64(digits))
+	return math.Round(value*scale) / scale
+}
@@ -0,0 +1,162 @@
+package models
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jasonzheng/carrag/rag"
+)
+
+// memoryFuelRepository 保存在内存中的加油记录仓库
+type memoryFuelRepository struct {
+	records []FuelRecord
+}
+
+func (r *memoryFuelRepository) FindAll(ctx context.Context) ([]FuelRecord, error) {
+	return append([]FuelRecord(nil), r.records...), nil
+}
+
+func (r *memoryFuelRepository) FindByCar(ctx context.Context, carID string) ([]FuelRecord, error) {
+	var records []FuelRecord
+	for _, record := range r.records {
+		if record.CarID == carID {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+func (r *memoryFuelRepository) FindByID(ctx context.Context, carID, id string) (*FuelRecord, error) {
+	for _, record := range r.records {
+		if record.CarID == carID && record.ID == id {
+			return &record, nil
+		}
+	}
+	return nil, ErrFuelRecordNotFound
+}
+
+func (r *memoryFuelRepository) Create(ctx context.Context, record *FuelRecord) error {
+	r.records = append(r.records, *record)
+	return nil
+}
+
+func (r *memoryFuelRepository) Update(ctx context.Context, record *FuelRecord) error {
+	return nil
+}
+
+func (r *memoryFuelRepository) Delete(ctx context.Context, carID, id string) error {
+	return nil
+}
+
+func (r *memoryFuelRepository) DeleteByCar(ctx context.Context, carID string) (int, error) {
+	return 0, nil
+}
+
+func TestComputeFuelStats(t *testing.T) {
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fill := func(days int, odometer, litres float64, full bool) FuelRecord {
+		return FuelRecord{Date: day.AddDate(0, 0, days), Odometer: odometer, Litres: litres, TotalCost: litres * 8, FullTank: full}
+	}
+
+	tests := []struct {
+		name        string
+		records     []FuelRecord
+		window      int
+		wantAverage float64
+		wantRolling float64
+		wantSegs    []float64 // 各满箱区间的油耗
+	}{
+		{
+			name:     "只有一次加满时无法计算",
+			records:  []FuelRecord{fill(0, 1000, 40, true), fill(7, 1500, 30, false)},
+			wantSegs: []float64{},
+		},
+		{
+			name:        "两次加满之间的加油量计入后一个区间",
+			records:     []FuelRecord{fill(0, 1000, 40, true), fill(7, 1300, 20, false), fill(14, 1500, 20, true)},
+			wantAverage: 8,
+			wantRolling: 8,
+			wantSegs:    []float64{8},
+		},
+		{
+			name:        "第一次加满之前的加油量不计入油耗",
+			records:     []FuelRecord{fill(0, 500, 30, false), fill(7, 1000, 40, true), fill(14, 1500, 35, true)},
+			wantAverage: 7,
+			wantRolling: 7,
+			wantSegs:    []float64{7},
+		},
+		{
+			name:        "平均油耗按总里程加权",
+			records:     []FuelRecord{fill(0, 1000, 40, true), fill(7, 1100, 10, true), fill(14, 1400, 18, true)},
+			window:      1,
+			wantAverage: 7,
+			wantRolling: 6,
+			wantSegs:    []float64{10, 6},
+		},
+		{
+			name:        "按里程表读数而非录入顺序排序",
+			records:     []FuelRecord{fill(14, 1500, 20, true), fill(0, 1000, 40, true), fill(7, 1300, 20, false)},
+			wantAverage: 8,
+			wantRolling: 8,
+			wantSegs:    []float64{8},
+		},
+		{
+			name:     "里程没有增加的区间被跳过",
+			records:  []FuelRecord{fill(0, 1000, 40, true), fill(1, 1000, 5, true)},
+			wantSegs: []float64{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := ComputeFuelStats("1", tt.records, tt.window)
+			if stats.AverageConsumption != tt.wantAverage {
+				t.Errorf("AverageConsumption = %v，期望 %v", stats.AverageConsumption, tt.wantAverage)
+			}
+			if stats.RollingAverage != tt.wantRolling {
+				t.Errorf("RollingAverage = %v，期望 %v", stats.RollingAverage, tt.wantRolling)
+			}
+			if len(stats.Segments) != len(tt.wantSegs) {
+				t.Fatalf("返回 %d 个满箱区间，期望 %d 个", len(stats.Segments), len(tt.wantSegs))
+			}
+			for i, want := range tt.wantSegs {
+				if got := stats.Segments[i].Consumption; got != want {
+					t.Errorf("第%d个区间油耗 = %v，期望 %v", i+1, got, want)
+				}
+			}
+			if stats.Records != len(tt.records) {
+				t.Errorf("Records = %d，期望 %d", stats.Records, len(tt.records))
+			}
+		})
+	}
+}
+
+func TestFuelSyncUpdatesIndexes(t *testing.T) {
+	ctx := context.Background()
+	rags, cars, _ := newTestRAGService(t, rag.NewHashEmbedder(0), Car{ID: "1", Brand: "丰田", Model: "卡罗拉", FuelConsumption: 6})
+	service := NewFuelService(&memoryFuelRepository{}, cars, newTestLogger(t))
+	service.SetOptions(FuelLogOptions{RollingWindow: 3, UpdateCarConsumption: true})
+
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, record := range []FuelRecord{
+		{CarID: "1", Date: day, Odometer: 1000, Litres: 40, PricePerLitre: 8, FullTank: true},
+		{CarID: "1", Date: day.AddDate(0, 0, 7), Odometer: 1500, Litres: 40, PricePerLitre: 8, FullTank: true},
+	} {
+		record := record
+		if err := service.CreateRecord(ctx, &record); err != nil {
+			t.Fatalf("CreateRecord 失败: %v", err)
+		}
+	}
+
+	car, err := cars.GetCarByID(ctx, "1")
+	if err != nil {
+		t.Fatalf("GetCarByID 失败: %v", err)
+	}
+	if car.FuelConsumption != 8 {
+		t.Fatalf("车辆油耗为 %v，期望写回 8", car.FuelConsumption)
+	}
+	eventually(t, "语义检索索引更新油耗", func() bool {
+		return strings.Contains(indexedDocument(t, rags, "1"), "油耗：8 L/100km")
+	})
+}
@@ -0,0 +1,201 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/jasonzheng/carrag/tracing"
+	"github.com/jasonzheng/carrag/utils"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// 保养类型
+const (
+	MaintenanceOilChange  = "oil_change" // 更换机油和机油滤清器
+	MaintenanceInspection = "inspection" // 常规检查保养
+	MaintenanceTire       = "tire"       // 轮胎更换或换位
+	MaintenanceBrake      = "brake"      // 刹车片、刹车油
+	MaintenanceRepair     = "repair"     // 故障维修
+	MaintenanceOther      = "other"      // 其他
+)
+
+// maintenanceTypes 支持的保养类型
+var maintenanceTypes = []string{
+	MaintenanceOilChange,
+	MaintenanceInspection,
+	MaintenanceTire,
+	MaintenanceBrake,
+	MaintenanceRepair,
+	MaintenanceOther,
+}
+
+// MaintenanceRecord 车辆保养维修记录
+type MaintenanceRecord struct {
+	ID        string    `json:"id"`                  // 记录唯一标识符
+	CarID     string    `json:"carId"`               // 所属车辆ID
+	Date      time.Time `json:"date"`                // 保养日期
+	Mileage   float64   `json:"mileage"`             // 保养时的行驶里程(km)
+	Type      string    `json:"type"`                // 保养类型
+	Cost      float64   `json:"cost,omitempty"`      // 费用(元)
+	Shop      string    `json:"shop,omitempty"`      // 保养门店
+	Notes     string    `json:"notes,omitempty"`     // 备注
+	CreatedAt time.Time `json:"createdAt"`           // 创建时间
+	UpdatedAt time.Time `json:"updatedAt,omitempty"` // 更新时间
+}
+
+// IsMaintenanceType 判断是否为支持的保养类型
+func IsMaintenanceType(t string) bool {
+	return utils.ContainsString(maintenanceTypes, t)
+}
+
+// Validate 校验保养记录
+func (r *MaintenanceRecord) Validate() error {
+	switch {
+	case r.Date.IsZero():
+		return utils.ValidationError("保养日期不能为空")
+	case r.Mileage < 0:
+		return utils.ValidationError("保养里程不能为负数")
+	case r.Cost < 0:
+		return utils.ValidationError("保养费用不能为负数")
+	case !IsMaintenanceType(r.Type):
+		return utils.ValidationError(fmt.Sprintf("保养类型必须是 %v 之一: %q", maintenanceTypes, r.Type))
+	}
+	return nil
+}
+
+// MaintenanceRepository 保养记录仓库接口
+type MaintenanceRepository interface {
+	FindAll(ctx context.Context) ([]MaintenanceRecord, error)                   // 获取所有车辆的保养记录
+	FindByCar(ctx context.Context, carID string) ([]MaintenanceRecord, error)   // 获取车辆的所有保养记录
+	FindByID(ctx context.Context, carID, id string) (*MaintenanceRecord, error) // 根据ID获取车辆的保养记录
+	Create(ctx context.Context, record *MaintenanceRecord) error                // 创建保养记录
+	Update(ctx context.Context, record *MaintenanceRecord) error                // 更新保养记录
+	Delete(ctx context.Context, carID, id string) error                         // 删除保养记录
+	DeleteByCar(ctx context.Context, carID string) (int, error)                 // 删除车辆的所有保养记录，返回删除的条数
+}
+
+// ErrMaintenanceRecordNotFound 保养记录不存在，errors.Is(err, utils.ErrNotFound) 同样成立
+const ErrMaintenanceRecordNotFound = utils.NotFoundError("保养记录不存在")
+
+// MaintenanceService 保养记录服务
+type MaintenanceService struct {
+	Repo               MaintenanceRepository // 保养记录仓库
+	Cars               *CarService           // 车辆服务，用于确认车辆存在
+	utils.LoggerHolder                       // 日志记录器
+
+	schedule atomic.Pointer[MaintenanceSchedule] // 保养计划，支持运行时调整
+}
+
+// NewMaintenanceService 创建保养记录服务，车辆删除时一并删除其保养记录
+func NewMaintenanceService(repo MaintenanceRepository, cars *CarService, logger *utils.Logger) *MaintenanceService {
+	service := &MaintenanceService{
+		Repo:         repo,
+		Cars:         cars,
+		LoggerHolder: utils.LoggerHolder{Logger: logger},
+	}
+	cars.OnDelete(service.deleteByCar)
+	return service
+}
+
+// ListRecords 获取车辆的所有保养记录，按保养日期从新到旧排序
+func (s *MaintenanceService) ListRecords(ctx context.Context, carID string) (_ []MaintenanceRecord, err error) {
+	ctx, span := tracing.Start(ctx, "MaintenanceService.ListRecords", attribute.String("car.id", carID))
+	defer tracing.End(span, &err)
+
+	s.LoggerFor(ctx).Info("获取车辆保养记录，车辆ID: %s", carID)
+	if _, err := s.Cars.GetCarByID(ctx, carID); err != nil {
+		return nil, err
+	}
+
+	records, err := s.Repo.FindByCar(ctx, carID)
+	if err != nil {
+		s.LoggerFor(ctx).Error("获取保养记录失败: %v", err)
+		return nil, err
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Date.After(records[j].Date)
+	})
+	return records, nil
+}
+
+// GetRecord 根据ID获取车辆的保养记录
+func (s *MaintenanceService) GetRecord(ctx context.Context, carID, id string) (_ *MaintenanceRecord, err error) {
+	ctx, span := tracing.Start(ctx, "MaintenanceService.GetRecord", attribute.String("car.id", carID), attribute.String("maintenance.id", id))
+	defer tracing.End(span, &err)
+
+	s.LoggerFor(ctx).Info("获取保养记录，车辆ID: %s，记录ID: %s", carID, id)
+	return s.Repo.FindByID(ctx, carID, id)
+}
+
+// CreateRecord 为车辆创建保养记录
+func (s *MaintenanceService) CreateRecord(ctx context.Context, record *MaintenanceRecord) (err error) {
+	ctx, span := tracing.Start(ctx, "MaintenanceService.CreateRecord", attribute.String("car.id", record.CarID))
+	defer tracing.End(span, &err)
+
+	s.LoggerFor(ctx).Info("创建保养记录，车辆ID: %s，类型: %s", record.CarID, record.Type)
+	if err := record.Validate(); err != nil {
+		return err
+	}
+	if _, err := s.Cars.GetCarByID(ctx, record.CarID); err != nil {
+		return err
+	}
+
+	record.ID = GenerateID()
+	record.CreatedAt = time.Now()
+	record.UpdatedAt = time.Time{}
+	if err := s.Repo.Create(ctx, record); err != nil {
+		s.LoggerFor(ctx).Error("创建保养记录失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+// UpdateRecord 更新车辆的保养记录
+func (s *MaintenanceService) UpdateRecord(ctx context.Context, record *MaintenanceRecord) (err error) {
+	ctx, span := tracing.Start(ctx, "MaintenanceService.UpdateRecord", attribute.String("car.id", record.CarID), attribute.String("maintenance.id", record.ID))
+	defer tracing.End(span, &err)
+
+	s.LoggerFor(ctx).Info("更新保养记录，车辆ID: %s，记录ID: %s", record.CarID, record.ID)
+	if err := record.Validate(); err != nil {
+		return err
+	}
+
+	existing, err := s.Repo.FindByID(ctx, record.CarID, record.ID)
+	if err != nil {
+		return err
+	}
+	record.CreatedAt = existing.CreatedAt
+	record.UpdatedAt = time.Now()
+	if err := s.Repo.Update(ctx, record); err != nil {
+		s.LoggerFor(ctx).Error("更新保养记录失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+// DeleteRecord 删除车辆的保养记录
+func (s *MaintenanceService) DeleteRecord(ctx context.Context, carID, id string) (err error) {
+	ctx, span := tracing.Start(ctx, "MaintenanceService.DeleteRecord", attribute.String("car.id", carID), attribute.String("maintenance.id", id))
+	defer tracing.End(span, &err)
+
+	s.LoggerFor(ctx).Info("删除保养记录，车辆ID: %s，记录ID: %s", carID, id)
+	if err := s.Repo.Delete(ctx, carID, id); err != nil {
+		return err
+	}
+	return nil
+}
+
+// deleteByCar 删除车辆的所有保养记录，在车辆删除后调用
+func (s *MaintenanceService) deleteByCar(ctx context.Context, carID string) error {
+	deleted, err := s.Repo.DeleteByCar(ctx, carID)
+	if err != nil {
+		return fmt.Errorf("删除保养记录失败: %w", err)
+	}
+	if deleted > 0 {
+		s.LoggerFor(ctx).Info("已删除车辆 %s 的 %d 条保养记录", carID, deleted)
+	}
+	return nil
+}
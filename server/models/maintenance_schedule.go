@@ -0,0 +1,217 @@
+package models
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/jasonzheng/carrag/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ServiceInterval 某类保养的间隔，里程和时间先到者为准
+type ServiceInterval struct {
+	Type   string  `json:"type" yaml:"type"`     // 保养类型
+	Km     float64 `json:"km" yaml:"km"`         // 里程间隔(km)，0表示不按里程
+	Months int     `json:"months" yaml:"months"` // 时间间隔(月)，0表示不按时间
+}
+
+// MaintenanceSchedule 保养计划
+type MaintenanceSchedule struct {
+	Default   []ServiceInterval            // 默认保养间隔
+	Overrides map[string][]ServiceInterval // 按品牌或"品牌/车型"覆盖的保养间隔，只覆盖列出的保养类型
+}
+
+// IntervalsFor 返回车辆适用的保养间隔，车型配置优先于品牌配置，品牌配置优先于默认配置
+func (s *MaintenanceSchedule) IntervalsFor(car *Car) []ServiceInterval {
+	byType := make(map[string]ServiceInterval, len(s.Default))
+	order := make([]string, 0, len(s.Default))
+	apply := func(intervals []ServiceInterval) {
+		for _, interval := range intervals {
+			if _, ok := byType[interval.Type]; !ok {
+				order = append(order, interval.Type)
+			}
+			byType[interval.Type] = interval
+		}
+	}
+	apply(s.Default)
+	apply(s.Overrides[car.Brand])
+	apply(s.Overrides[car.Brand+"/"+car.Model])
+
+	intervals := make([]ServiceInterval, 0, len(order))
+	for _, t := range order {
+		if interval := byType[t]; interval.Km > 0 || interval.Months > 0 {
+			intervals = append(intervals, interval)
+		}
+	}
+	return intervals
+}
+
+// 到期原因
+const (
+	DueByMileage = "mileage" // 按里程到期
+	DueByTime    = "time"    // 按时间到期
+)
+
+// DueService 即将到期或已逾期的保养
+type DueService struct {
+	CarID              string     `json:"carId"`                        // 车辆ID
+	Brand              string     `json:"brand"`                        // 品牌
+	Model              string     `json:"model"`                        // 车型
+	Type               string     `json:"type"`                         // 保养类型
+	LastServiceDate    *time.Time `json:"lastServiceDate,omitempty"`    // 上次保养日期，没有保养记录时为空
+	LastServiceMileage float64    `json:"lastServiceMileage,omitempty"` // 上次保养时的里程(km)
+	EstimatedMileage   float64    `json:"estimatedMileage"`             // 按年均里程估算的当前里程(km)
+	DueMileage         float64    `json:"dueMileage,omitempty"`         // 到期里程(km)，不按里程时为空
+	DueDate            time.Time  `json:"dueDate"`                      // 预计到期日期，取里程和时间中较早者
+	DaysRemaining      int        `json:"daysRemaining"`                // 距到期的天数，负数表示已逾期
+	Overdue            bool       `json:"overdue"`                      // 是否已逾期
+	Reason             string     `json:"reason"`                       // 到期原因: mileage 或 time
+	NoRecord           bool       `json:"noRecord,omitempty"`           // 没有该类保养记录，按车辆信息中的里程和购车日期估算
+}
+
+// SetSchedule 设置保养计划，支持运行时调整
+func (s *MaintenanceService) SetSchedule(schedule MaintenanceSchedule) {
+	s.schedule.Store(&schedule)
+}
+
+// Schedule 获取当前保养计划
+func (s *MaintenanceService) Schedule() *MaintenanceSchedule {
+	if schedule := s.schedule.Load(); schedule != nil {
+		return schedule
+	}
+	return &MaintenanceSchedule{}
+}
+
+// DueServices 返回withinDays天内到期或已逾期的保养，按到期日期从早到晚排序
+func (s *MaintenanceService) DueServices(ctx context.Context, withinDays int) (_ []DueService, err error) {
+	ctx, span := tracing.Start(ctx, "MaintenanceService.DueServices", attribute.Int("maintenance.within_days", withinDays))
+	defer tracing.End(span, &err)
+
+	s.LoggerFor(ctx).Info("查询 %d 天内到期的保养", withinDays)
+	cars, err := s.Cars.GetAllCars(ctx)
+	if err != nil {
+		return nil, err
+	}
+	records, err := s.Repo.FindAll(ctx)
+	if err != nil {
+		s.LoggerFor(ctx).Error("获取保养记录失败: %v", err)
+		return nil, err
+	}
+
+	recordsByCar := make(map[string][]MaintenanceRecord)
+	for _, record := range records {
+		recordsByCar[record.CarID] = append(recordsByCar[record.CarID], record)
+	}
+
+	now := time.Now()
+	schedule := s.Schedule()
+	result := make([]DueService, 0)
+	for i := range cars {
+		for _, due := range dueServices(&cars[i], recordsByCar[cars[i].ID], schedule, now) {
+			if due.Overdue || due.DaysRemaining <= withinDays {
+				result = append(result, due)
+			}
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].DueDate.Before(result[j].DueDate)
+	})
+	return result, nil
+}
+
+// dueServices 计算车辆每类保养的下次到期情况
+func dueServices(car *Car, records []MaintenanceRecord, schedule *MaintenanceSchedule, now time.Time) []DueService {
+	intervals := schedule.IntervalsFor(car)
+	if len(intervals) == 0 {
+		return nil
+	}
+
+	mileage := estimateMileage(car, records, now)
+	result := make([]DueService, 0, len(intervals))
+	for _, interval := range intervals {
+		due := DueService{
+			CarID:            car.ID,
+			Brand:            car.Brand,
+			Model:            car.Model,
+			Type:             interval.Type,
+			EstimatedMileage: math.Round(mileage),
+		}
+
+		// 没有该类保养记录时，之前的保养情况未知，以车辆信息中的里程和购车日期为起点估算
+		baseDate, baseMileage := car.OwnedSince(), car.MileageKm()
+		if last := lastService(records, interval.Type); last != nil {
+			baseDate, baseMileage = last.Date, last.Mileage
+			due.LastServiceDate = &last.Date
+			due.LastServiceMileage = last.Mileage
+		} else {
+			due.NoRecord = true
+		}
+
+		if interval.Months > 0 {
+			due.DueDate = baseDate.AddDate(0, interval.Months, 0)
+			due.Reason = DueByTime
+		}
+		if interval.Km > 0 {
+			due.DueMileage = baseMileage + interval.Km
+			byMileage, ok := projectMileageDate(due.DueMileage, mileage, car.AnnualMileage, now)
+			if ok && (due.Reason == "" || byMileage.Before(due.DueDate)) {
+				due.DueDate = byMileage
+				due.Reason = DueByMileage
+			}
+		}
+		if due.Reason == "" {
+			// 只按里程保养且未填写年均里程，无法推算到期日期
+			continue
+		}
+
+		due.DaysRemaining = int(math.Floor(due.DueDate.Sub(now).Hours() / 24))
+		due.Overdue = !due.DueDate.After(now)
+		result = append(result, due)
+	}
+	return result
+}
+
+// lastService 返回最近一次指定类型的保养记录
+func lastService(records []MaintenanceRecord, serviceType string) *MaintenanceRecord {
+	var last *MaintenanceRecord
+	for i := range records {
+		if records[i].Type == serviceType && (last == nil || records[i].Date.After(last.Date)) {
+			last = &records[i]
+		}
+	}
+	return last
+}
+
+// estimateMileage 估算车辆当前里程(km)：以车辆信息最后更新时的里程为基础，按年均里程推算至今，
+// 不低于保养记录中的最大里程
+func estimateMileage(car *Car, records []MaintenanceRecord, now time.Time) float64 {
+	since := car.CreatedAt
+	if car.UpdatedAt.After(since) {
+		since = car.UpdatedAt
+	}
+	mileage := car.MileageKm()
+	if car.AnnualMileage > 0 && now.After(since) {
+		mileage += car.AnnualMileage * now.Sub(since).Hours() / (24 * 365)
+	}
+	for _, record := range records {
+		mileage = math.Max(mileage, record.Mileage)
+	}
+	return mileage
+}
+
+// projectMileageDate 按年均里程推算达到目标里程的日期，已达到时返回now；
+// 未填写年均里程时无法推算，返回false
+func projectMileageDate(target, current, annualMileage float64, now time.Time) (time.Time, bool) {
+	remaining := target - current
+	if remaining <= 0 {
+		return now, true
+	}
+	if annualMileage <= 0 {
+		return time.Time{}, false
+	}
+	days := remaining / annualMileage * 365
+	return now.Add(time.Duration(days * 24 * float64(time.Hour))), true
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDueServices(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	purchased := now.AddDate(-3, 0, 0)
+	schedule := &MaintenanceSchedule{
+		Default: []ServiceInterval{{Type: "机油", Km: 5000, Months: 12}},
+	}
+
+	tests := []struct {
+		name         string
+		car          Car
+		records      []MaintenanceRecord
+		wantDueKm    float64
+		wantReason   string
+		wantOverdue  bool
+		wantNoRecord bool
+	}{
+		{
+			// 里程5万公里的二手车没有保养记录，不应按0公里起算而逾期
+			name:         "没有记录时以当前里程为起点",
+			car:          Car{ID: "1", Mileage: 5, AnnualMileage: 10000, CreatedAt: now, UpdatedAt: now},
+			wantDueKm:    55000,
+			wantReason:   DueByMileage,
+			wantNoRecord: true,
+		},
+		{
+			name:         "没有记录时以购车日期为时间起点",
+			car:          Car{ID: "2", Mileage: 5, PurchaseDate: &purchased, CreatedAt: now, UpdatedAt: now},
+			wantDueKm:    55000,
+			wantReason:   DueByTime,
+			wantOverdue:  true,
+			wantNoRecord: true,
+		},
+		{
+			name:        "按上次保养里程计算",
+			car:         Car{ID: "3", Mileage: 5.2, AnnualMileage: 10000, CreatedAt: now, UpdatedAt: now},
+			records:     []MaintenanceRecord{{CarID: "3", Type: "机油", Date: now.AddDate(0, -1, 0), Mileage: 46000}},
+			wantDueKm:   51000,
+			wantReason:  DueByMileage,
+			wantOverdue: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			due := dueServices(&tt.car, tt.records, schedule, now)
+			if len(due) != 1 {
+				t.Fatalf("返回 %d 条到期保养，期望 1 条", len(due))
+			}
+			got := due[0]
+			if got.DueMileage != tt.wantDueKm {
+				t.Errorf("DueMileage = %v，期望 %v", got.DueMileage, tt.wantDueKm)
+			}
+			if got.Reason != tt.wantReason {
+				t.Errorf("Reason = %q，期望 %q", got.Reason, tt.wantReason)
+			}
+			if got.Overdue != tt.wantOverdue {
+				t.Errorf("Overdue = %v，期望 %v", got.Overdue, tt.wantOverdue)
+			}
+			if got.NoRecord != tt.wantNoRecord {
+				t.Errorf("NoRecord = %v，期望 %v", got.NoRecord, tt.wantNoRecord)
+			}
+		})
+	}
+}
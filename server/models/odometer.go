@@ -0,0 +1,356 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/jasonzheng/carrag/tracing"
+	"github.com/jasonzheng/carrag/utils"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// 里程表读数来源
+const (
+	OdometerSourceManual = "manual" // 手动录入
+	OdometerSourceCar    = "car"    // 创建或更新车辆信息时记录
+)
+
+// 读数低于之前读数(里程回退)时的处理方式
+const (
+	OdometerRollbackReject = "reject" // 拒绝保存
+	OdometerRollbackFlag   = "flag"   // 保存并标记为可疑
+)
+
+// OdometerReading 里程表读数
+type OdometerReading struct {
+	ID        string    `json:"id"`                // 读数唯一标识符
+	CarID     string    `json:"carId"`             // 所属车辆ID
+	Date      time.Time `json:"date"`              // 读数日期
+	Reading   float64   `json:"reading"`           // 里程表读数(km)
+	Source    string    `json:"source"`            // 来源: manual 或 car
+	Flagged   bool      `json:"flagged,omitempty"` // 读数与之前的读数矛盾，可能被回调
+	Notes     string    `json:"notes,omitempty"`   // 备注
+	CreatedAt time.Time `json:"createdAt"`         // 创建时间
+}
+
+// Validate 校验里程表读数
+func (r *OdometerReading) Validate() error {
+	switch {
+	case r.Date.IsZero():
+		return utils.ValidationError("读数日期不能为空")
+	case r.Date.After(time.Now()):
+		return utils.ValidationError("读数日期不能晚于当前时间")
+	case r.Reading < 0:
+		return utils.ValidationError("里程表读数不能为负数")
+	}
+	return nil
+}
+
+// OdometerRepository 里程表读数仓库接口
+type OdometerRepository interface {
+	FindByCar(ctx context.Context, carID string) ([]OdometerReading, error) // 获取车辆的所有里程表读数
+	Create(ctx context.Context, reading *OdometerReading) error             // 创建里程表读数
+	Delete(ctx context.Context, carID, id string) error                     // 删除里程表读数
+	DeleteByCar(ctx context.Context, carID string) (int, error)             // 删除车辆的所有里程表读数，返回删除的条数
+}
+
+// ErrOdometerReadingNotFound 里程表读数不存在，errors.Is(err, utils.ErrNotFound) 同样成立
+const ErrOdometerReadingNotFound = utils.NotFoundError("里程表读数不存在")
+
+// OdometerOptions 里程表读数选项
+type OdometerOptions struct {
+	RollbackPolicy      string        // 读数低于之前读数时的处理方式
+	DeriveAnnualMileage bool          // 是否根据读数推算车辆的年均行驶里程，开启后覆盖用户填写的值
+	MinSpan             time.Duration // 推算年均行驶里程所需的最短读数跨度
+}
+
+// OdometerService 里程表读数服务，同时跟踪车辆信息中行驶里程的变化
+type OdometerService struct {
+	Repo               OdometerRepository // 里程表读数仓库
+	Cars               *CarService        // 车辆服务，用于确认车辆存在和同步行驶里程
+	utils.LoggerHolder                    // 日志记录器
+
+	options atomic.Pointer[OdometerOptions] // 里程表读数选项，支持运行时调整
+}
+
+// NewOdometerService 创建里程表读数服务，车辆创建或更新时记录行驶里程，车辆删除时一并删除其读数
+func NewOdometerService(repo OdometerRepository, cars *CarService, logger *utils.Logger) *OdometerService {
+	service := &OdometerService{
+		Repo:         repo,
+		Cars:         cars,
+		LoggerHolder: utils.LoggerHolder{Logger: logger},
+	}
+	service.SetOptions(OdometerOptions{
+		RollbackPolicy:      OdometerRollbackReject,
+		DeriveAnnualMileage: false,
+		MinSpan:             30 * 24 * time.Hour,
+	})
+	cars.TrackMileage(service)
+	cars.OnDelete(service.deleteByCar)
+	return service
+}
+
+// SetOptions 设置里程表读数选项
+func (s *OdometerService) SetOptions(options OdometerOptions) {
+	s.options.Store(&options)
+}
+
+// Options 获取当前里程表读数选项
+func (s *OdometerService) Options() OdometerOptions {
+	return *s.options.Load()
+}
+
+// ListReadings 获取车辆的所有里程表读数，按日期从新到旧排序
+func (s *OdometerService) ListReadings(ctx context.Context, carID string) (_ []OdometerReading, err error) {
+	ctx, span := tracing.Start(ctx, "OdometerService.ListReadings", attribute.String("car.id", carID))
+	defer tracing.End(span, &err)
+
+	s.LoggerFor(ctx).Info("获取车辆里程表读数，车辆ID: %s", carID)
+	if _, err := s.Cars.GetCarByID(ctx, carID); err != nil {
+		return nil, err
+	}
+
+	readings, err := s.Repo.FindByCar(ctx, carID)
+	if err != nil {
+		s.LoggerFor(ctx).Error("获取里程表读数失败: %v", err)
+		return nil, err
+	}
+	sort.SliceStable(readings, func(i, j int) bool {
+		return readingBefore(&readings[j], &readings[i])
+	})
+	return readings, nil
+}
+
+// AddReading 手动录入里程表读数，读数是最新的一条时同步更新车辆的行驶里程
+func (s *OdometerService) AddReading(ctx context.Context, reading *OdometerReading) (err error) {
+	ctx, span := tracing.Start(ctx, "OdometerService.AddReading", attribute.String("car.id", reading.CarID))
+	defer tracing.End(span, &err)
+
+	s.LoggerFor(ctx).Info("录入里程表读数，车辆ID: %s，读数: %v", reading.CarID, reading.Reading)
+	if err := reading.Validate(); err != nil {
+		return err
+	}
+	car, err := s.Cars.GetCarByID(ctx, reading.CarID)
+	if err != nil {
+		return err
+	}
+	existing, err := s.Repo.FindByCar(ctx, reading.CarID)
+	if err != nil {
+		return err
+	}
+	flagged, err := s.checkRollback(ctx, reading.CarID, reading.Date, reading.Reading, existing)
+	if err != nil {
+		return err
+	}
+
+	reading.ID = GenerateID()
+	reading.Source = OdometerSourceManual
+	reading.Flagged = flagged
+	reading.CreatedAt = time.Now()
+	if err := s.Repo.Create(ctx, reading); err != nil {
+		s.LoggerFor(ctx).Error("录入里程表读数失败: %v", err)
+		return err
+	}
+
+	if !flagged && isLatestReading(reading, existing) && car.MileageKm() != reading.Reading {
+		car.SetMileageKm(reading.Reading)
+		if err := s.Cars.UpdateCar(ctx, car); err != nil {
+			s.LoggerFor(ctx).Warning("同步车辆 %s 的行驶里程失败: %v", car.ID, err)
+		}
+	}
+	return nil
+}
+
+// DeleteReading 删除车辆的里程表读数，用于更正录入错误
+func (s *OdometerService) DeleteReading(ctx context.Context, carID, id string) (err error) {
+	ctx, span := tracing.Start(ctx, "OdometerService.DeleteReading", attribute.String("car.id", carID), attribute.String("odometer.id", id))
+	defer tracing.End(span, &err)
+
+	s.LoggerFor(ctx).Info("删除里程表读数，车辆ID: %s，读数ID: %s", carID, id)
+	return s.Repo.Delete(ctx, carID, id)
+}
+
+// CheckMileage 保存车辆信息前检查行驶里程是否低于之前的读数，并按配置推算年均行驶里程
+// 车辆信息中的行驶里程以万公里填写，读数以公里记录，比较前先换算；未填写行驶里程时不检查
+func (s *OdometerService) CheckMileage(ctx context.Context, car *Car) error {
+	if car.Mileage <= 0 {
+		return nil
+	}
+	readings, err := s.Repo.FindByCar(ctx, car.ID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	mileage := car.MileageKm()
+	flagged, err := s.checkRollback(ctx, car.ID, now, mileage, readings)
+	if err != nil {
+		return err
+	}
+
+	options := s.Options()
+	if !options.DeriveAnnualMileage || flagged {
+		return nil
+	}
+	readings = append(readings, OdometerReading{Date: now, Reading: mileage})
+	if annual, ok := DeriveAnnualMileage(readings, options.MinSpan); ok {
+		car.AnnualMileage = annual
+	}
+	return nil
+}
+
+// RecordMileage 保存车辆信息后按公里记录行驶里程，行驶里程未变化时不记录
+func (s *OdometerService) RecordMileage(ctx context.Context, car *Car) error {
+	if car.Mileage <= 0 {
+		return nil
+	}
+	readings, err := s.Repo.FindByCar(ctx, car.ID)
+	if err != nil {
+		return err
+	}
+	mileage := car.MileageKm()
+	// 与最新的读数或最近录入的读数(可能是已标记的读数)相同时，视为未变化
+	if latest := latestReading(readings); latest != nil && latest.Reading == mileage {
+		return nil
+	}
+	if last := lastRecorded(readings); last != nil && last.Reading == mileage {
+		return nil
+	}
+
+	date := car.UpdatedAt
+	if date.IsZero() {
+		date = car.CreatedAt
+	}
+	reading := &OdometerReading{
+		ID:        GenerateID(),
+		CarID:     car.ID,
+		Date:      date,
+		Reading:   mileage,
+		Source:    OdometerSourceCar,
+		Flagged:   findRollback(date, mileage, readings) != nil,
+		CreatedAt: time.Now(),
+	}
+	return s.Repo.Create(ctx, reading)
+}
+
+// checkRollback 检查读数是否与之前的读数矛盾，按配置拒绝或返回需要标记
+func (s *OdometerService) checkRollback(ctx context.Context, carID string, date time.Time, value float64, readings []OdometerReading) (bool, error) {
+	conflict := findRollback(date, value, readings)
+	if conflict == nil {
+		return false, nil
+	}
+	if s.Options().RollbackPolicy == OdometerRollbackReject {
+		return false, utils.ValidationError(fmt.Sprintf("里程表读数 %v 与 %s 的读数(%v)不一致",
+			value, conflict.Date.Format("2006-01-02"), conflict.Reading))
+	}
+	s.LoggerFor(ctx).Warning("车辆 %s 的里程表读数 %v 与 %s 的读数(%v)不一致，已标记",
+		carID, value, conflict.Date.Format("2006-01-02"), conflict.Reading)
+	return true, nil
+}
+
+// deleteByCar 删除车辆的所有里程表读数，在车辆删除后调用
+func (s *OdometerService) deleteByCar(ctx context.Context, carID string) error {
+	deleted, err := s.Repo.DeleteByCar(ctx, carID)
+	if err != nil {
+		return fmt.Errorf("删除里程表读数失败: %w", err)
+	}
+	if deleted > 0 {
+		s.LoggerFor(ctx).Info("已删除车辆 %s 的 %d 条里程表读数", carID, deleted)
+	}
+	return nil
+}
+
+// findRollback 查找与读数矛盾的已有读数：当天及之前的读数更大，或之后的读数更小
+// 有多条时返回相差最大的一条，已标记的读数不参与比较
+func findRollback(date time.Time, value float64, readings []OdometerReading) *OdometerReading {
+	day := readingDay(date)
+	var conflict *OdometerReading
+	for i, other := range readings {
+		if other.Flagged {
+			continue
+		}
+		later := readingDay(other.Date).After(day)
+		if (!later && other.Reading > value) || (later && other.Reading < value) {
+			if conflict == nil || math.Abs(other.Reading-value) > math.Abs(conflict.Reading-value) {
+				conflict = &readings[i]
+			}
+		}
+	}
+	return conflict
+}
+
+// readingDay 返回读数所在的日期(UTC)，手动录入的读数通常只精确到天，因此按天比较先后
+func readingDay(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// readingBefore 判断读数a是否早于b：先按日期比较，同一天内读数较小的在前
+func readingBefore(a, b *OdometerReading) bool {
+	dayA, dayB := readingDay(a.Date), readingDay(b.Date)
+	if !dayA.Equal(dayB) {
+		return dayA.Before(dayB)
+	}
+	if a.Reading != b.Reading {
+		return a.Reading < b.Reading
+	}
+	return a.Date.Before(b.Date)
+}
+
+// latestReading 返回最新的读数，没有读数时返回nil
+func latestReading(readings []OdometerReading) *OdometerReading {
+	var latest *OdometerReading
+	for i := range readings {
+		if latest == nil || !readingBefore(&readings[i], latest) {
+			latest = &readings[i]
+		}
+	}
+	return latest
+}
+
+// lastRecorded 返回最近录入的读数，没有读数时返回nil
+func lastRecorded(readings []OdometerReading) *OdometerReading {
+	var last *OdometerReading
+	for i := range readings {
+		if last == nil || !readings[i].CreatedAt.Before(last.CreatedAt) {
+			last = &readings[i]
+		}
+	}
+	return last
+}
+
+// isLatestReading 判断读数是否不早于其他所有读数
+func isLatestReading(reading *OdometerReading, others []OdometerReading) bool {
+	latest := latestReading(others)
+	return latest == nil || !readingBefore(reading, latest)
+}
+
+// DeriveAnnualMileage 根据最早和最新的未标记读数推算年均行驶里程(km)
+// 读数跨度不足minSpan或里程没有增加时无法推算
+func DeriveAnnualMileage(readings []OdometerReading, minSpan time.Duration) (float64, bool) {
+	var first, last *OdometerReading
+	for i := range readings {
+		if readings[i].Flagged {
+			continue
+		}
+		if first == nil || readings[i].Date.Before(first.Date) {
+			first = &readings[i]
+		}
+		if last == nil || !readings[i].Date.Before(last.Date) {
+			last = &readings[i]
+		}
+	}
+	if first == nil {
+		return 0, false
+	}
+
+	span := last.Date.Sub(first.Date)
+	distance := last.Reading - first.Reading
+	if span <= 0 || span < minSpan || distance <= 0 {
+		return 0, false
+	}
+	return math.Round(distance / span.Hours() * 24 * 365), true
+}
@@ -0,0 +1,131 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jasonzheng/carrag/utils"
+)
+
+// memoryOdometerRepository 保存在内存中的里程表读数仓库
+type memoryOdometerRepository struct {
+	readings []OdometerReading
+}
+
+func (r *memoryOdometerRepository) FindByCar(ctx context.Context, carID string) ([]OdometerReading, error) {
+	return append([]OdometerReading(nil), r.readings...), nil
+}
+
+func (r *memoryOdometerRepository) Create(ctx context.Context, reading *OdometerReading) error {
+	r.readings = append(r.readings, *reading)
+	return nil
+}
+
+func (r *memoryOdometerRepository) Delete(ctx context.Context, carID, id string) error {
+	return nil
+}
+
+func (r *memoryOdometerRepository) DeleteByCar(ctx context.Context, carID string) (int, error) {
+	return 0, nil
+}
+
+func TestOdometerCheckMileage(t *testing.T) {
+	yearAgo := time.Now().AddDate(-1, 0, 0)
+
+	tests := []struct {
+		name       string
+		mileage    float64 // 车辆信息中的行驶里程(万km)
+		derive     bool
+		wantErr    bool
+		wantAnnual float64
+	}{
+		{"行驶里程按万公里与读数比较", 5, false, false, 8000},
+		{"低于之前的读数时拒绝", 3, false, true, 8000},
+		{"未开启时保留填写的年均里程", 6, false, false, 8000},
+		{"开启后按读数推算年均里程", 6, true, false, 20000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &memoryOdometerRepository{readings: []OdometerReading{{CarID: "1", Date: yearAgo, Reading: 40000}}}
+			service := &OdometerService{Repo: repo}
+			service.SetOptions(OdometerOptions{RollbackPolicy: OdometerRollbackReject, DeriveAnnualMileage: tt.derive, MinSpan: 24 * time.Hour})
+
+			car := &Car{ID: "1", Mileage: tt.mileage, AnnualMileage: 8000}
+			err := service.CheckMileage(context.Background(), car)
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("CheckMileage 错误为 %v，期望出错=%v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, utils.ErrInvalid) {
+				t.Errorf("错误为 %v，期望校验错误", err)
+			}
+			// 一年行驶2万公里，允许天数取整带来的误差
+			if diff := car.AnnualMileage - tt.wantAnnual; diff < -100 || diff > 100 {
+				t.Errorf("AnnualMileage = %v，期望约 %v", car.AnnualMileage, tt.wantAnnual)
+			}
+		})
+	}
+}
+
+func TestOdometerRecordMileage(t *testing.T) {
+	now := time.Now()
+	repo := &memoryOdometerRepository{readings: []OdometerReading{{CarID: "1", Date: now.AddDate(0, -1, 0), Reading: 52000}}}
+	service := &OdometerService{Repo: repo}
+	service.SetOptions(OdometerOptions{RollbackPolicy: OdometerRollbackReject})
+
+	tests := []struct {
+		name        string
+		mileage     float64
+		wantReading float64 // 新记录的读数，0表示不记录
+	}{
+		{"与最新读数相同时不记录", 5.2, 0},
+		{"按公里记录新的行驶里程", 5.5, 55000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := len(repo.readings)
+			car := &Car{ID: "1", Mileage: tt.mileage, UpdatedAt: now}
+			if err := service.RecordMileage(context.Background(), car); err != nil {
+				t.Fatalf("RecordMileage 失败: %v", err)
+			}
+			added := repo.readings[before:]
+			switch {
+			case tt.wantReading == 0 && len(added) != 0:
+				t.Errorf("记录了读数 %v，期望不记录", added[0].Reading)
+			case tt.wantReading != 0 && (len(added) != 1 || added[0].Reading != tt.wantReading):
+				t.Errorf("新增读数 %v，期望 %v", added, tt.wantReading)
+			}
+		})
+	}
+}
+
+func TestOdometerAddReadingSyncsMileage(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	for _, km := range []float64{30020, 12345.6, 99999, 150003} {
+		t.Run(fmt.Sprint(km), func(t *testing.T) {
+			repo := &memoryOdometerRepository{readings: []OdometerReading{{CarID: "1", Date: now.AddDate(0, -1, 0), Reading: km - 1000}}}
+			cars := NewCarService(newMemoryCarRepository(Car{ID: "1", Brand: "丰田", Model: "卡罗拉"}), newTestLogger(t), nil)
+			service := NewOdometerService(repo, cars, newTestLogger(t))
+
+			if err := service.AddReading(ctx, &OdometerReading{CarID: "1", Date: now, Reading: km}); err != nil {
+				t.Fatalf("AddReading 失败: %v", err)
+			}
+			car, err := cars.GetCarByID(ctx, "1")
+			if err != nil {
+				t.Fatalf("GetCarByID 失败: %v", err)
+			}
+			if car.MileageKm() != km {
+				t.Errorf("车辆行驶里程为 %v 公里，期望 %v", car.MileageKm(), km)
+			}
+			for _, reading := range repo.readings {
+				if reading.Flagged || reading.Reading != km && reading.Reading != km-1000 {
+					t.Errorf("记录了多余或被标记的读数: %+v", reading)
+				}
+			}
+		})
+	}
+}
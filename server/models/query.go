@@ -0,0 +1,211 @@
+package models
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jasonzheng/carrag/nlq"
+	"github.com/jasonzheng/carrag/tracing"
+	"github.com/jasonzheng/carrag/utils"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// fuelTypeSynonyms 燃油类型及其他叫法，前端表单中的 "92"、"95" 也视为汽油
+var fuelTypeSynonyms = map[string][]string{
+	"汽油":   {"汽油车", "燃油车", "油车"},
+	"92":   {"92号", "92#", "汽油车", "燃油车", "油车", "汽油"},
+	"95":   {"95号", "95#", "汽油车", "燃油车", "油车", "汽油"},
+	"柴油":   {"柴油车"},
+	"电动":   {"纯电", "纯电动", "电动车", "电车", "新能源"},
+	"混合动力": {"混动", "油电混动", "油电混合"},
+	"插电混动": {"插混", "插电式混动", "PHEV", "新能源"},
+}
+
+// usageScenarioSynonyms 使用场景及其他叫法，与前端表单的选项一致
+var usageScenarioSynonyms = map[string][]string{
+	"通勤":   {"上下班", "上班"},
+	"商务":   {"商务接待"},
+	"家用":   {"家庭", "接送孩子"},
+	"越野":   {"野外"},
+	"长途旅行": {"长途", "自驾游", "旅行", "出游"},
+	"市区代步": {"代步", "市区"},
+}
+
+// storageEnvironmentSynonyms 存放环境及其他叫法，与前端表单的选项一致
+var storageEnvironmentSynonyms = map[string][]string{
+	"地下停车场": {"地下车库", "地库", "地下"},
+	"露天停车场": {"露天"},
+	"路边停车":  {"路边"},
+}
+
+// QueryResult 自然语言查询的结果
+type QueryResult struct {
+	Query  string     `json:"query"`  // 查询内容
+	Filter nlq.Filter `json:"filter"` // 解析得到的筛选条件
+	Total  int        `json:"total"`  // 符合条件的车辆数
+	Cars   []Car      `json:"cars"`   // 符合条件的车辆
+}
+
+// QueryService 自然语言查询服务，将查询解析为筛选条件并返回符合条件的车辆
+// 识别车型目录和车队中已有的品牌、车型，以及燃油类型、使用场景、存放环境的已知取值
+type QueryService struct {
+	Cars               *CarService    // 车辆服务
+	Catalog            []CatalogBrand // 车型目录，用于识别品牌、车型和车身类型
+	utils.LoggerHolder                // 日志记录器
+}
+
+// NewQueryService 创建自然语言查询服务，使用内置的车型目录
+func NewQueryService(cars *CarService, logger *utils.Logger) *QueryService {
+	return &QueryService{
+		Cars:         cars,
+		Catalog:      DefaultCatalog,
+		LoggerHolder: utils.LoggerHolder{Logger: logger},
+	}
+}
+
+// Query 解析查询并返回符合条件的车辆，没有识别出任何条件时返回全部车辆
+func (s *QueryService) Query(ctx context.Context, query string) (_ *QueryResult, err error) {
+	ctx, span := tracing.Start(ctx, "QueryService.Query", attribute.String("query.text", query))
+	defer tracing.End(span, &err)
+
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, utils.ValidationError("查询内容不能为空")
+	}
+
+	cars, err := s.Cars.GetAllCars(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := nlq.Parse(query, s.vocabulary(cars))
+	s.LoggerFor(ctx).Info("自然语言查询: %q, 未识别的内容: %v", query, filter.Unparsed)
+
+	categories := s.categories()
+	matched := make([]Car, 0, len(cars))
+	for i := range cars {
+		if MatchFilter(&cars[i], &filter, categories) {
+			matched = append(matched, cars[i])
+		}
+	}
+	return &QueryResult{Query: query, Filter: filter, Total: len(matched), Cars: matched}, nil
+}
+
+// vocabulary 由车型目录、已知取值和车队中已有的取值构造词表
+func (s *QueryService) vocabulary(cars []Car) *nlq.Vocabulary {
+	vocab := nlq.NewVocabulary()
+	for _, brand := range s.Catalog {
+		vocab.Add(nlq.FieldBrand, brand.Brand, brand.Aliases...)
+		for _, model := range brand.Models {
+			// "CR-V" 也可以写作 "CRV"
+			vocab.Add(nlq.FieldModel, model.Name, strings.ReplaceAll(model.Name, "-", ""))
+		}
+	}
+	// 按键排序添加，使同一个词对应多个取值时返回的顺序固定
+	for _, category := range sortedKeys(categoryAliases) {
+		vocab.Add(nlq.FieldCategory, category, categoryAliases[category]...)
+	}
+	for _, value := range sortedKeys(fuelTypeSynonyms) {
+		vocab.Add(nlq.FieldFuelType, value, fuelTypeSynonyms[value]...)
+	}
+	for _, value := range sortedKeys(usageScenarioSynonyms) {
+		vocab.Add(nlq.FieldUsageScenario, value, usageScenarioSynonyms[value]...)
+	}
+	for _, value := range sortedKeys(storageEnvironmentSynonyms) {
+		vocab.Add(nlq.FieldStorageEnvironment, value, storageEnvironmentSynonyms[value]...)
+	}
+
+	for i := range cars {
+		vocab.Add(nlq.FieldBrand, cars[i].Brand)
+		vocab.Add(nlq.FieldModel, cars[i].Model)
+		vocab.Add(nlq.FieldFuelType, cars[i].FuelType)
+		vocab.Add(nlq.FieldStorageEnvironment, cars[i].StorageEnvironment)
+		for _, scenario := range cars[i].UsageScenario {
+			vocab.Add(nlq.FieldUsageScenario, scenario)
+		}
+	}
+	return vocab
+}
+
+// categories 返回车型目录中"品牌/车型"对应的车身类型
+func (s *QueryService) categories() map[string]string {
+	categories := make(map[string]string)
+	for _, brand := range s.Catalog {
+		for _, model := range brand.Models {
+			categories[catalogKey(brand.Brand, model.Name)] = model.Category
+		}
+	}
+	return categories
+}
+
+// MatchFilter 判断车辆是否满足筛选条件，categories为"品牌/车型"对应的车身类型
+// 数值字段未填写(为0)的车辆不满足该字段的范围条件；不在车型目录中的车辆不满足车身类型条件
+func MatchFilter(car *Car, filter *nlq.Filter, categories map[string]string) bool {
+	if len(filter.Brands) > 0 && !containsFold(filter.Brands, car.Brand) {
+		return false
+	}
+	if len(filter.Models) > 0 && !containsFold(filter.Models, car.Model) {
+		return false
+	}
+	if len(filter.Categories) > 0 && !containsFold(filter.Categories, categories[catalogKey(car.Brand, car.Model)]) {
+		return false
+	}
+	if len(filter.FuelTypes) > 0 && !containsFold(filter.FuelTypes, car.FuelType) {
+		return false
+	}
+	if len(filter.StorageEnvironments) > 0 && !containsFold(filter.StorageEnvironments, car.StorageEnvironment) {
+		return false
+	}
+	if len(filter.UsageScenarios) > 0 {
+		found := false
+		for _, scenario := range car.UsageScenario {
+			if containsFold(filter.UsageScenarios, scenario) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for _, r := range filter.Ranges {
+		value := rangeValue(car, r.Field)
+		if value <= 0 || !r.Contains(value) {
+			return false
+		}
+	}
+	return true
+}
+
+// rangeValue 返回车辆在数值字段上的取值
+func rangeValue(car *Car, field string) float64 {
+	switch field {
+	case nlq.RangeFuelConsumption:
+		return car.FuelConsumption
+	case nlq.RangeMileage:
+		return car.Mileage
+	case nlq.RangeAnnualMileage:
+		return car.AnnualMileage
+	case nlq.RangePurchasePrice:
+		return car.PurchasePrice
+	}
+	return 0
+}
+
+// catalogKey 返回车型目录中车型的键，忽略大小写
+func catalogKey(brand, model string) string {
+	return strings.ToLower(brand + "/" + model)
+}
+
+// containsFold 判断取值是否在列表中，忽略大小写，空值不在任何列表中
+func containsFold(values []string, value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
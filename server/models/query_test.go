@@ -0,0 +1,154 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/jasonzheng/carrag/nlq"
+)
+
+func TestQueryService(t *testing.T) {
+	service := NewQueryService(NewCarService(newMemoryCarRepository(
+		Car{ID: "1", Brand: "丰田", Model: "RAV4", FuelType: "汽油", FuelConsumption: 6.5, UsageScenario: []string{"通勤"}},
+		Car{ID: "2", Brand: "丰田", Model: "汉兰达", FuelType: "95", FuelConsumption: 8.5, UsageScenario: []string{"通勤"}},
+		Car{ID: "3", Brand: "丰田", Model: "卡罗拉", FuelType: "92", FuelConsumption: 5.5, UsageScenario: []string{"通勤"}},
+		Car{ID: "4", Brand: "本田", Model: "CR-V", FuelType: "汽油", FuelConsumption: 6, UsageScenario: []string{"通勤", "家用"}},
+		Car{ID: "5", Brand: "丰田", Model: "RAV4", FuelType: "汽油", FuelConsumption: 6.8, UsageScenario: []string{"长途旅行"}, StorageEnvironment: "地下停车场"},
+		Car{ID: "6", Brand: "比亚迪", Model: "汉", FuelType: "电动", FuelConsumption: 13},
+	), newTestLogger(t), nil), newTestLogger(t))
+
+	tests := []struct {
+		name       string
+		query      string
+		wantFilter nlq.Filter // 不比较Ranges和Unparsed
+		wantRanges int
+		wantIDs    []string
+	}{
+		{
+			name:       "品牌、车身类型、油耗和使用场景",
+			query:      "油耗低于7的丰田SUV，通勤用",
+			wantFilter: nlq.Filter{Brands: []string{"丰田"}, Categories: []string{"SUV"}, UsageScenarios: []string{"通勤"}},
+			wantRanges: 1,
+			wantIDs:    []string{"1"},
+		},
+		{
+			name:       "汽油同时对应汽油、92和95",
+			query:      "汽油",
+			wantFilter: nlq.Filter{FuelTypes: []string{"92", "95", "汽油"}},
+			wantIDs:    []string{"1", "2", "3", "4", "5"},
+		},
+		{
+			name:       "汽油车的叫法",
+			query:      "燃油车",
+			wantFilter: nlq.Filter{FuelTypes: []string{"92", "95", "汽油"}},
+			wantIDs:    []string{"1", "2", "3", "4", "5"},
+		},
+		{
+			name:       "标号只对应该标号",
+			query:      "加95号的车",
+			wantFilter: nlq.Filter{FuelTypes: []string{"95"}},
+			wantIDs:    []string{"2"},
+		},
+		{
+			name:       "92号",
+			query:      "92#",
+			wantFilter: nlq.Filter{FuelTypes: []string{"92"}},
+			wantIDs:    []string{"3"},
+		},
+		{
+			name:       "新能源对应电动和插电混动",
+			query:      "新能源",
+			wantFilter: nlq.Filter{FuelTypes: []string{"插电混动", "电动"}},
+			wantIDs:    []string{"6"},
+		},
+		{
+			name:       "品牌英文名和使用场景的叫法",
+			query:      "上下班用的Honda",
+			wantFilter: nlq.Filter{Brands: []string{"本田"}, UsageScenarios: []string{"通勤"}},
+			wantIDs:    []string{"4"},
+		},
+		{
+			name:       "车型中的连字符可以省略",
+			query:      "CRV",
+			wantFilter: nlq.Filter{Models: []string{"CR-V"}},
+			wantIDs:    []string{"4"},
+		},
+		{
+			name:       "存放环境的叫法",
+			query:      "停地库的车",
+			wantFilter: nlq.Filter{StorageEnvironments: []string{"地下停车场"}},
+			wantIDs:    []string{"5"},
+		},
+		{
+			name:       "车队中已有但不在车型目录中的品牌",
+			query:      "比亚迪",
+			wantFilter: nlq.Filter{Brands: []string{"比亚迪"}},
+			wantIDs:    []string{"6"},
+		},
+		{
+			name:    "没有识别出条件时返回全部车辆",
+			query:   "随便看看",
+			wantIDs: []string{"1", "2", "3", "4", "5", "6"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := service.Query(context.Background(), tt.query)
+			if err != nil {
+				t.Fatalf("Query 失败: %v", err)
+			}
+			filter := result.Filter
+			if len(filter.Ranges) != tt.wantRanges {
+				t.Errorf("数值范围 = %+v，期望 %d 个", filter.Ranges, tt.wantRanges)
+			}
+			filter.Ranges, filter.Unparsed = nil, nil
+			if !reflect.DeepEqual(filter, tt.wantFilter) {
+				t.Errorf("Query(%q) 的筛选条件 = %+v，期望 %+v", tt.query, filter, tt.wantFilter)
+			}
+
+			ids := make([]string, 0, len(result.Cars))
+			for _, car := range result.Cars {
+				ids = append(ids, car.ID)
+			}
+			sort.Strings(ids)
+			if fmt.Sprint(ids) != fmt.Sprint(tt.wantIDs) || result.Total != len(tt.wantIDs) {
+				t.Errorf("Query(%q) 返回 %v (total=%d)，期望 %v", tt.query, ids, result.Total, tt.wantIDs)
+			}
+		})
+	}
+}
+
+func TestMatchFilter(t *testing.T) {
+	seven := 7.0
+	categories := map[string]string{catalogKey("丰田", "RAV4"): CategorySUV}
+	car := Car{Brand: "丰田", Model: "RAV4", FuelType: "汽油", FuelConsumption: 6.5, UsageScenario: []string{"通勤", "家用"}}
+
+	tests := []struct {
+		name   string
+		car    Car
+		filter nlq.Filter
+		want   bool
+	}{
+		{"没有条件", car, nlq.Filter{}, true},
+		{"品牌忽略大小写", Car{Brand: "BMW"}, nlq.Filter{Brands: []string{"bmw"}}, true},
+		{"同一字段满足其一即可", car, nlq.Filter{Brands: []string{"本田", "丰田"}}, true},
+		{"不同字段需同时满足", car, nlq.Filter{Brands: []string{"丰田"}, FuelTypes: []string{"柴油"}}, false},
+		{"使用场景有一个相同即可", car, nlq.Filter{UsageScenarios: []string{"家用"}}, true},
+		{"车身类型", car, nlq.Filter{Categories: []string{CategorySUV}}, true},
+		{"不在车型目录中的车辆不满足车身类型条件", Car{Brand: "比亚迪", Model: "汉"}, nlq.Filter{Categories: []string{CategorySedan}}, false},
+		{"满足数值范围", car, nlq.Filter{Ranges: []nlq.Range{{Field: nlq.RangeFuelConsumption, Max: &seven}}}, true},
+		{"不满足数值范围", Car{FuelConsumption: 8}, nlq.Filter{Ranges: []nlq.Range{{Field: nlq.RangeFuelConsumption, Max: &seven}}}, false},
+		{"未填写的数值不满足范围条件", Car{}, nlq.Filter{Ranges: []nlq.Range{{Field: nlq.RangeFuelConsumption, Max: &seven}}}, false},
+		{"行驶里程按万公里比较", Car{Mileage: 5}, nlq.Filter{Ranges: []nlq.Range{{Field: nlq.RangeMileage, Max: &seven}}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MatchFilter(&tt.car, &tt.filter, categories); got != tt.want {
+				t.Errorf("MatchFilter = %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+}
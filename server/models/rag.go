@@ -0,0 +1,390 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jasonzheng/carrag/rag"
+	"github.com/jasonzheng/carrag/tracing"
+	"github.com/jasonzheng/carrag/utils"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// DefaultRAGTopK 语义检索默认返回的结果数
+const DefaultRAGTopK = 5
+
+// DefaultRAGBatchSize 重建索引时每次向量化的默认文档数
+const DefaultRAGBatchSize = 32
+
+// DefaultRAGQueueSize 等待向量化的车辆更新数上限，队列已满时的更新在下次重建索引时补上
+const DefaultRAGQueueSize = 256
+
+// ragRefreshTimeout 定期重建索引的超时时间，包含读取车辆信息和向量化
+const ragRefreshTimeout = 2 * time.Minute
+
+// ragEmbedTimeout 后台向量化一批车辆更新的超时时间
+const ragEmbedTimeout = 30 * time.Second
+
+// RAGResult 语义检索结果
+type RAGResult struct {
+	Car      Car     `json:"car"`      // 车辆信息
+	Score    float32 `json:"score"`    // 与查询的余弦相似度
+	Document string  `json:"document"` // 用于向量化的车辆文档，可直接作为大模型的上下文
+}
+
+// ragEntry 已索引车辆的信息、文档和向量
+type ragEntry struct {
+	car    Car
+	text   string
+	vector []float32
+}
+
+// ragUpdate 等待向量化的车辆更新，seq与该车辆最新的更新序号一致时才写入索引
+type ragUpdate struct {
+	car Car
+	seq uint64
+}
+
+// RAGService 车辆语义检索服务，将车辆信息转换为文档并向量化，按与查询的相似度返回车辆
+// 本实例写入的车辆信息在后台向量化后更新索引，其他实例写入的数据在定期重建索引后可被检索到
+type RAGService struct {
+	Cars               *CarService  // 车辆服务
+	Embedder           rag.Embedder // 向量化实现
+	utils.LoggerHolder              // 日志记录器
+	MaxTopK            int          // 单次检索返回的最大结果数，0表示不限制
+	BatchSize          int          // 重建索引时和后台每次向量化的文档数，0使用默认值
+
+	index   *rag.VectorIndex
+	mu      sync.RWMutex
+	entries map[string]ragEntry // 已索引的车辆
+	changes map[string]*Car     // 重建索引期间本实例写入的变更，nil表示车辆已删除，重建完成后重放
+	latest  map[string]uint64   // 等待向量化的车辆及其最新的更新序号
+	seq     uint64              // 更新序号
+
+	queue     chan ragUpdate // 等待向量化的车辆更新
+	stop      chan struct{}
+	stopOnce  sync.Once
+	done      chan struct{}
+	rebuildMu sync.Mutex // 同一时间只执行一次重建
+	refresher *refresher // 定期重建索引
+}
+
+// NewRAGService 创建车辆语义检索服务，车辆创建或更新时在后台向量化并更新索引，删除时立即从索引中删除
+// 需调用Rebuild建立初始索引，调用Close停止后台向量化
+func NewRAGService(cars *CarService, embedder rag.Embedder, logger *utils.Logger) *RAGService {
+	service := &RAGService{
+		Cars:         cars,
+		Embedder:     embedder,
+		LoggerHolder: utils.LoggerHolder{Logger: logger},
+		index:        rag.NewVectorIndex(),
+		entries:      make(map[string]ragEntry),
+		latest:       make(map[string]uint64),
+		queue:        make(chan ragUpdate, DefaultRAGQueueSize),
+		stop:         make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+	service.refresher = newRefresher("语义检索索引", ragRefreshTimeout, service.Rebuild)
+	cars.OnSave(service.indexCar)
+	cars.OnDelete(service.removeCar)
+	go service.embedLoop()
+	return service
+}
+
+// Len 返回已索引的车辆数
+func (s *RAGService) Len() int {
+	return s.index.Len()
+}
+
+// Rebuild 从车辆仓库读取所有车辆信息并重建索引，文档内容未变化的车辆沿用原有向量
+// 读取和向量化期间本实例写入的变更在替换索引前重放，不会被读取到的旧数据覆盖
+func (s *RAGService) Rebuild(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "RAGService.Rebuild")
+	defer tracing.End(span, &err)
+
+	s.rebuildMu.Lock()
+	defer s.rebuildMu.Unlock()
+	s.mu.Lock()
+	s.changes = make(map[string]*Car)
+	previous := s.entries
+	s.mu.Unlock()
+	defer func() {
+		if err != nil {
+			s.mu.Lock()
+			s.changes = nil
+			s.mu.Unlock()
+		}
+	}()
+
+	// 直接读取仓库，避免使用其他实例写入前的列表缓存
+	cars, err := s.Cars.Repo.FindAll(ctx)
+	if err != nil {
+		return fmt.Errorf("重建语义检索索引失败: %w", err)
+	}
+
+	entries := make(map[string]ragEntry, len(cars))
+	var pending []string
+	for i := range cars {
+		entry := ragEntry{car: cars[i], text: CarDocument(&cars[i])}
+		if old, ok := previous[entry.car.ID]; ok && old.text == entry.text {
+			entry.vector = old.vector
+		} else {
+			pending = append(pending, entry.car.ID)
+		}
+		entries[entry.car.ID] = entry
+	}
+
+	for start := 0; start < len(pending); start += s.batchSize() {
+		end := start + s.batchSize()
+		if end > len(pending) {
+			end = len(pending)
+		}
+		ids := pending[start:end]
+		texts := make([]string, len(ids))
+		for i, id := range ids {
+			texts[i] = entries[id].text
+		}
+		vectors, err := s.Embedder.Embed(ctx, texts)
+		if err != nil {
+			return fmt.Errorf("重建语义检索索引失败: %w", err)
+		}
+		for i, id := range ids {
+			entry := entries[id]
+			entry.vector = vectors[i]
+			entries[id] = entry
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, car := range s.changes {
+		if car == nil {
+			delete(entries, id)
+			continue
+		}
+		text := CarDocument(car)
+		if rebuilt, ok := entries[id]; ok && rebuilt.text == text {
+			// 读取到的已是变更后的数据
+			continue
+		}
+		if current, ok := s.entries[id]; ok && current.text == text {
+			// 后台已完成该车辆的向量化
+			entries[id] = current
+		} else if s.latest[id] == 0 {
+			// 后台向量化失败或队列已满，该车辆暂时无法被检索到，下次重建索引时重试
+			delete(entries, id)
+		}
+		// 其他情况后台尚未完成向量化，暂时保留读取到的数据，完成后更新
+	}
+	vectors := make(map[string][]float32, len(entries))
+	for id, entry := range entries {
+		vectors[id] = entry.vector
+	}
+	if err := s.index.Reset(vectors); err != nil {
+		return fmt.Errorf("重建语义检索索引失败: %w", err)
+	}
+	s.entries = entries
+	s.changes = nil
+
+	s.LoggerFor(ctx).Debug("语义检索索引已重建，共 %d 辆车，重新向量化 %d 辆", len(entries), len(pending))
+	return nil
+}
+
+// StartRefresh 每隔interval重建一次索引，使其他实例写入的数据可被检索到，interval不大于0时不重建
+func (s *RAGService) StartRefresh(interval time.Duration) {
+	s.refresher.start(interval, s.Logger)
+}
+
+// Close 停止定期重建索引和后台向量化，尚未向量化的更新在下次启动重建索引时补上
+func (s *RAGService) Close() {
+	s.refresher.close()
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+	<-s.done
+}
+
+// Query 返回与查询语义最相似的最多topK辆车，按相似度从高到低排序，相似度不高于minScore的车辆不返回
+func (s *RAGService) Query(ctx context.Context, query string, topK int, minScore float32) (_ []RAGResult, err error) {
+	ctx, span := tracing.Start(ctx, "RAGService.Query",
+		attribute.String("rag.query", query),
+		attribute.Int("rag.top_k", topK),
+	)
+	defer tracing.End(span, &err)
+
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, utils.ValidationError("查询内容不能为空")
+	}
+	if topK <= 0 {
+		return nil, utils.ValidationError(fmt.Sprintf("topK必须大于0: %d", topK))
+	}
+	if s.MaxTopK > 0 && topK > s.MaxTopK {
+		return nil, utils.ValidationError(fmt.Sprintf("topK不能超过 %d: %d", s.MaxTopK, topK))
+	}
+	if minScore < -1 || minScore > 1 {
+		return nil, utils.ValidationError(fmt.Sprintf("minScore必须在 -1 到 1 之间: %v", minScore))
+	}
+
+	s.LoggerFor(ctx).Info("语义检索车辆: %q, topK: %d", query, topK)
+	vectors, err := s.Embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("查询向量化失败: %w", err)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	matches, err := s.index.Search(vectors[0], topK, minScore)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]RAGResult, 0, len(matches))
+	for _, match := range matches {
+		entry, ok := s.entries[match.ID]
+		if !ok {
+			continue
+		}
+		results = append(results, RAGResult{Car: entry.car, Score: match.Score, Document: entry.text})
+	}
+	return results, nil
+}
+
+// indexCar 将车辆加入后台向量化队列，在车辆创建或更新后调用
+// 向量化完成前检索结果中仍是车辆原有的信息；队列已满时记录警告，下次重建索引时更新
+func (s *RAGService) indexCar(ctx context.Context, car *Car) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	if s.changes != nil {
+		changed := *car
+		s.changes[car.ID] = &changed
+	}
+
+	select {
+	case s.queue <- ragUpdate{car: *car, seq: s.seq}:
+		s.latest[car.ID] = s.seq
+		return nil
+	default:
+		delete(s.latest, car.ID)
+		return fmt.Errorf("向量化队列已满，车辆 %s 将在下次重建索引时更新", car.ID)
+	}
+}
+
+// removeCar 从索引中删除车辆，在车辆删除后调用，尚未完成的向量化结果不再写入索引
+func (s *RAGService) removeCar(ctx context.Context, carID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.index.Remove(carID)
+	delete(s.entries, carID)
+	delete(s.latest, carID)
+	if s.changes != nil {
+		s.changes[carID] = nil
+	}
+	return nil
+}
+
+// embedLoop 在后台批量向量化队列中的车辆更新并写入索引
+func (s *RAGService) embedLoop() {
+	defer close(s.done)
+
+	for {
+		var batch []ragUpdate
+		select {
+		case <-s.stop:
+			return
+		case update := <-s.queue:
+			batch = append(batch, update)
+		}
+		// 合并已在队列中的更新，减少调用模型的次数
+	drain:
+		for len(batch) < s.batchSize() {
+			select {
+			case update := <-s.queue:
+				batch = append(batch, update)
+			default:
+				break drain
+			}
+		}
+		s.embedBatch(batch)
+	}
+}
+
+// embedBatch 向量化一批车辆更新，只写入仍是该车辆最新更新的结果
+// 向量化失败时车辆暂时无法被检索到，下次重建索引时重试
+func (s *RAGService) embedBatch(batch []ragUpdate) {
+	ctx, cancel := context.WithTimeout(context.Background(), ragEmbedTimeout)
+	defer cancel()
+
+	texts := make([]string, len(batch))
+	for i := range batch {
+		texts[i] = CarDocument(&batch[i].car)
+	}
+	vectors, err := s.Embedder.Embed(ctx, texts)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, update := range batch {
+		id := update.car.ID
+		if s.latest[id] != update.seq {
+			continue
+		}
+		delete(s.latest, id)
+		upsertErr := err
+		if upsertErr == nil {
+			upsertErr = s.index.Upsert(id, vectors[i])
+		}
+		if upsertErr != nil {
+			s.Logger.Warning("向量化车辆 %s 的文档失败，下次重建索引时重试: %v", id, upsertErr)
+			s.index.Remove(id)
+			delete(s.entries, id)
+			continue
+		}
+		s.entries[id] = ragEntry{car: update.car, text: texts[i], vector: vectors[i]}
+	}
+}
+
+// batchSize 返回每次向量化的文档数
+func (s *RAGService) batchSize() int {
+	if s.BatchSize <= 0 {
+		return DefaultRAGBatchSize
+	}
+	return s.BatchSize
+}
+
+// CarDocument 将车辆信息转换为用于向量化的中文文档，未填写的字段不出现在文档中
+func CarDocument(car *Car) string {
+	var b strings.Builder
+	b.WriteString(strings.TrimSpace(car.Brand + " " + car.Model))
+	b.WriteString("。")
+
+	field := func(label, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "%s：%s。", label, value)
+		}
+	}
+	number := func(label string, value float64, unit string) {
+		if value > 0 {
+			field(label, strconv.FormatFloat(value, 'f', -1, 64)+unit)
+		}
+	}
+
+	field("燃油类型", car.FuelType)
+	unit := "L/100km"
+	if car.FuelType == "电动" {
+		unit = "kWh/100km"
+	}
+	number("油耗", car.FuelConsumption, " "+unit)
+	number("行驶里程", car.Mileage, " 万公里")
+	number("年均行驶里程", car.AnnualMileage, " 公里")
+	number("购车价格", car.PurchasePrice, " 元")
+	if car.PurchaseDate != nil {
+		field("购车日期", car.PurchaseDate.Format("2006-01-02"))
+	}
+	field("存放环境", car.StorageEnvironment)
+	field("使用场景", strings.Join(car.UsageScenario, "、"))
+	field("备注", strings.TrimSpace(car.Remarks))
+	return b.String()
+}
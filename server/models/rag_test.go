@@ -0,0 +1,174 @@
+package models
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jasonzheng/carrag/rag"
+)
+
+// gatedEmbedder 每次向量化前等待gate放行，用于模拟耗时的模型调用
+type gatedEmbedder struct {
+	*rag.HashEmbedder
+	gate chan struct{}
+}
+
+func (e *gatedEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	select {
+	case <-e.gate:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return e.HashEmbedder.Embed(ctx, texts)
+}
+
+// newTestRAGService 创建使用内存仓库的语义检索服务并建立初始索引
+func newTestRAGService(t *testing.T, embedder rag.Embedder, cars ...Car) (*RAGService, *CarService, *memoryCarRepository) {
+	t.Helper()
+	repo := newMemoryCarRepository(cars...)
+	carService := NewCarService(repo, newTestLogger(t), nil)
+	service := NewRAGService(carService, embedder, newTestLogger(t))
+	t.Cleanup(service.Close)
+	if err := service.Rebuild(context.Background()); err != nil {
+		t.Fatalf("Rebuild 失败: %v", err)
+	}
+	return service, carService, repo
+}
+
+// indexedDocument 返回车辆在语义检索索引中的文档，未索引时返回空字符串
+func indexedDocument(t *testing.T, service *RAGService, id string) string {
+	t.Helper()
+	results, err := service.Query(context.Background(), "车辆", 100, -1)
+	if err != nil {
+		t.Fatalf("Query 失败: %v", err)
+	}
+	for _, result := range results {
+		if result.Car.ID == id {
+			return result.Document
+		}
+	}
+	return ""
+}
+
+// eventually 等待条件成立，超时后测试失败
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("等待%s超时", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRAGIndexCarAsync(t *testing.T) {
+	ctx := context.Background()
+	embedder := &gatedEmbedder{HashEmbedder: rag.NewHashEmbedder(0), gate: make(chan struct{})}
+	close(embedder.gate)
+	service, cars, _ := newTestRAGService(t, embedder)
+	embedder.gate = make(chan struct{})
+
+	// 模型调用未完成时保存车辆不应阻塞
+	saved := make(chan error, 1)
+	go func() {
+		saved <- cars.CreateCar(ctx, &Car{Brand: "宝马", Model: "X5"})
+	}()
+	select {
+	case err := <-saved:
+		if err != nil {
+			t.Fatalf("CreateCar 失败: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("保存车辆时等待了向量化")
+	}
+	if service.Len() != 0 {
+		t.Errorf("向量化完成前已索引 %d 辆车", service.Len())
+	}
+
+	close(embedder.gate)
+	eventually(t, "后台向量化", func() bool { return service.Len() == 1 })
+}
+
+func TestRAGRemoveWhileEmbedding(t *testing.T) {
+	ctx := context.Background()
+	embedder := &gatedEmbedder{HashEmbedder: rag.NewHashEmbedder(0), gate: make(chan struct{})}
+	close(embedder.gate)
+	service, cars, _ := newTestRAGService(t, embedder, Car{ID: "1", Brand: "宝马", Model: "X5"})
+	embedder.gate = make(chan struct{})
+
+	if err := cars.UpdateCar(ctx, &Car{ID: "1", Brand: "宝马", Model: "X3"}); err != nil {
+		t.Fatalf("UpdateCar 失败: %v", err)
+	}
+	if err := cars.DeleteCar(ctx, "1"); err != nil {
+		t.Fatalf("DeleteCar 失败: %v", err)
+	}
+	close(embedder.gate)
+
+	// 等待队列处理完毕：随后保存的车辆被索引时，之前的更新一定已处理
+	if err := cars.CreateCar(ctx, &Car{Brand: "奔驰", Model: "C级"}); err != nil {
+		t.Fatalf("CreateCar 失败: %v", err)
+	}
+	eventually(t, "后台向量化", func() bool { return service.Len() > 0 })
+	if doc := indexedDocument(t, service, "1"); doc != "" {
+		t.Errorf("已删除的车辆仍在索引中: %s", doc)
+	}
+}
+
+func TestRAGRebuildKeepsConcurrentChanges(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		change  func(cars *CarService) error // 重建读取车辆信息后写入的变更
+		wantDoc string                       // 重建后车辆1的文档应包含的内容，空表示不在索引中
+	}{
+		{
+			name: "更新车辆",
+			change: func(cars *CarService) error {
+				return cars.UpdateCar(ctx, &Car{ID: "1", Brand: "奔驰", Model: "C级"})
+			},
+			wantDoc: "奔驰 C级",
+		},
+		{
+			name:   "删除车辆",
+			change: func(cars *CarService) error { return cars.DeleteCar(ctx, "1") },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, cars, repo := newTestRAGService(t, rag.NewHashEmbedder(0), Car{ID: "1", Brand: "宝马", Model: "X5"})
+
+			repo.beforeFindAll = func() {
+				repo.beforeFindAll = nil
+				if err := tt.change(cars); err != nil {
+					t.Errorf("写入变更失败: %v", err)
+				}
+			}
+			if err := service.Rebuild(ctx); err != nil {
+				t.Fatalf("Rebuild 失败: %v", err)
+			}
+
+			if tt.wantDoc == "" {
+				if doc := indexedDocument(t, service, "1"); doc != "" {
+					t.Errorf("已删除的车辆仍在索引中: %s", doc)
+				}
+				return
+			}
+			eventually(t, "索引更新", func() bool {
+				return strings.Contains(indexedDocument(t, service, "1"), tt.wantDoc)
+			})
+		})
+	}
+}
+
+func TestCarDocumentMileageUnit(t *testing.T) {
+	doc := CarDocument(&Car{Brand: "丰田", Model: "卡罗拉", Mileage: 5.2, AnnualMileage: 12000})
+	for _, want := range []string{"行驶里程：5.2 万公里", "年均行驶里程：12000 公里"} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("文档 %q 不包含 %q", doc, want)
+		}
+	}
+}
@@ -0,0 +1,393 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/jasonzheng/carrag/tracing"
+	"github.com/jasonzheng/carrag/utils"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// 推荐结果数
+const (
+	DefaultRecommendations = 5  // 默认返回的推荐车型数
+	MaxRecommendations     = 20 // 最多返回的推荐车型数
+)
+
+// 推荐因素
+const (
+	FactorFuelEconomy        = "fuelEconomy"        // 实际油耗在同类车型中的排名
+	FactorUsageScenario      = "usageScenario"      // 已有车辆的使用场景与用户的相似度
+	FactorAnnualMileage      = "annualMileage"      // 已有车辆的年均行驶里程与用户的接近程度
+	FactorStorageEnvironment = "storageEnvironment" // 已有车辆中存放环境与用户相同的比例
+)
+
+// neutralScore 缺少油耗数据或没有可比较的同类车型时油耗因素的得分，既不加分也不减分
+// 相似度因素缺少数据时得分为0，没有依据的车型不应排在与用户情况相符的车型之前
+const neutralScore = 0.5
+
+// RecommendationWeights 各因素的权重，用户未提供对应信息的因素不参与评分，其余权重按比例放大
+type RecommendationWeights struct {
+	FuelEconomy        float64 // 油耗
+	UsageScenario      float64 // 使用场景
+	AnnualMileage      float64 // 年均行驶里程
+	StorageEnvironment float64 // 存放环境
+}
+
+// RecommendationOptions 推荐选项
+type RecommendationOptions struct {
+	Weights RecommendationWeights // 各因素的权重
+}
+
+// RecommendationRequest 用户的用车情况
+type RecommendationRequest struct {
+	AnnualMileage      float64  `json:"annualMileage"`      // 年均行驶里程(km)
+	UsageScenarios     []string `json:"usageScenarios"`     // 使用场景
+	StorageEnvironment string   `json:"storageEnvironment"` // 存放环境
+	Limit              int      `json:"limit"`              // 返回的推荐车型数，0使用默认值
+}
+
+// RecommendationFactor 推荐因素的得分和说明
+type RecommendationFactor struct {
+	Name         string  `json:"name"`         // 因素
+	Score        float64 `json:"score"`        // 得分(0-1)
+	Weight       float64 `json:"weight"`       // 归一化后的权重
+	Contribution float64 `json:"contribution"` // 对总分的贡献，即得分 × 权重
+	Detail       string  `json:"detail"`       // 说明
+}
+
+// Recommendation 推荐的车型
+type Recommendation struct {
+	Brand                  string                 `json:"brand"`                            // 品牌
+	Model                  string                 `json:"model"`                            // 车型
+	Score                  float64                `json:"score"`                            // 总分(0-1)
+	CarCount               int                    `json:"carCount"`                         // 车队中该车型的车辆数
+	AverageFuelConsumption float64                `json:"averageFuelConsumption,omitempty"` // 平均油耗，电动车为kWh/100km
+	AverageAnnualMileage   float64                `json:"averageAnnualMileage,omitempty"`   // 平均年均行驶里程(km)
+	Factors                []RecommendationFactor `json:"factors"`                          // 各因素，按贡献从大到小排序
+}
+
+// RecommendationService 根据用户的用车情况，从车队已有的车辆中推荐品牌和车型
+type RecommendationService struct {
+	Cars               *CarService // 车辆服务
+	utils.LoggerHolder             // 日志记录器
+
+	options atomic.Pointer[RecommendationOptions] // 推荐选项，支持运行时调整
+}
+
+// NewRecommendationService 创建车型推荐服务
+func NewRecommendationService(cars *CarService, logger *utils.Logger) *RecommendationService {
+	service := &RecommendationService{
+		Cars:         cars,
+		LoggerHolder: utils.LoggerHolder{Logger: logger},
+	}
+	service.SetOptions(RecommendationOptions{})
+	return service
+}
+
+// SetOptions 设置推荐选项
+func (s *RecommendationService) SetOptions(options RecommendationOptions) {
+	s.options.Store(&options)
+}
+
+// Options 返回当前的推荐选项
+func (s *RecommendationService) Options() RecommendationOptions {
+	return *s.options.Load()
+}
+
+// Recommend 按用户的用车情况为车队中的品牌和车型评分，返回得分最高的车型
+func (s *RecommendationService) Recommend(ctx context.Context, req RecommendationRequest) (_ []Recommendation, err error) {
+	ctx, span := tracing.Start(ctx, "RecommendationService.Recommend",
+		attribute.Float64("recommendation.annual_mileage", req.AnnualMileage),
+		attribute.StringSlice("recommendation.usage_scenarios", req.UsageScenarios),
+	)
+	defer tracing.End(span, &err)
+
+	if req.AnnualMileage < 0 {
+		return nil, utils.ValidationError(fmt.Sprintf("年均行驶里程不能为负数: %v", req.AnnualMileage))
+	}
+	if req.AnnualMileage == 0 && len(req.UsageScenarios) == 0 && strings.TrimSpace(req.StorageEnvironment) == "" {
+		return nil, utils.ValidationError("请至少提供年均行驶里程、使用场景或存放环境之一")
+	}
+	if req.Limit == 0 {
+		req.Limit = DefaultRecommendations
+	}
+	if req.Limit < 1 || req.Limit > MaxRecommendations {
+		return nil, utils.ValidationError(fmt.Sprintf("推荐数必须在 1-%d 之间: %d", MaxRecommendations, req.Limit))
+	}
+
+	// 使用场景和存放环境接受常见叫法，如 "长途" 视为 "长途旅行"
+	scenarios := make([]string, 0, len(req.UsageScenarios))
+	for _, scenario := range req.UsageScenarios {
+		if scenario = canonicalValue(usageScenarioSynonyms, scenario); scenario != "" && !containsFold(scenarios, scenario) {
+			scenarios = append(scenarios, scenario)
+		}
+	}
+	req.UsageScenarios = scenarios
+	req.StorageEnvironment = canonicalValue(storageEnvironmentSynonyms, req.StorageEnvironment)
+
+	s.LoggerFor(ctx).Info("推荐车型，年均行驶里程: %v, 使用场景: %v, 存放环境: %q", req.AnnualMileage, req.UsageScenarios, req.StorageEnvironment)
+	cars, err := s.Cars.GetAllCars(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	recommendations := Recommend(cars, req, s.Options().Weights)
+	if len(recommendations) > req.Limit {
+		recommendations = recommendations[:req.Limit]
+	}
+	return recommendations, nil
+}
+
+// Recommend 按品牌和车型分组，计算每个车型各因素的得分和加权总分，按总分从高到低排序
+func Recommend(cars []Car, req RecommendationRequest, weights RecommendationWeights) []Recommendation {
+	groups := make(map[string][]*Car)
+	var keys []string
+	for i := range cars {
+		if cars[i].Brand == "" || cars[i].Model == "" {
+			continue
+		}
+		key := catalogKey(cars[i].Brand, cars[i].Model)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], &cars[i])
+	}
+
+	// 电动车与燃油车的油耗单位不同，分别排名
+	consumption := make(map[string]float64, len(groups))
+	for _, key := range keys {
+		consumption[key] = averageOf(groups[key], func(car *Car) float64 { return car.FuelConsumption })
+	}
+
+	type factor struct {
+		name   string
+		weight float64
+		score  func(key string, group []*Car) (float64, string)
+	}
+	factors := []factor{{FactorFuelEconomy, weights.FuelEconomy, func(key string, group []*Car) (float64, string) {
+		return fuelEconomyScore(key, group, consumption, groups)
+	}}}
+	if len(req.UsageScenarios) > 0 {
+		factors = append(factors, factor{FactorUsageScenario, weights.UsageScenario, func(_ string, group []*Car) (float64, string) {
+			return usageScenarioScore(group, req.UsageScenarios)
+		}})
+	}
+	if req.AnnualMileage > 0 {
+		factors = append(factors, factor{FactorAnnualMileage, weights.AnnualMileage, func(_ string, group []*Car) (float64, string) {
+			return annualMileageScore(group, req.AnnualMileage)
+		}})
+	}
+	if req.StorageEnvironment != "" {
+		factors = append(factors, factor{FactorStorageEnvironment, weights.StorageEnvironment, func(_ string, group []*Car) (float64, string) {
+			return storageEnvironmentScore(group, req.StorageEnvironment)
+		}})
+	}
+	total := 0.0
+	for _, f := range factors {
+		total += f.weight
+	}
+
+	recommendations := make([]Recommendation, 0, len(keys))
+	for _, key := range keys {
+		group := groups[key]
+		rec := Recommendation{
+			Brand:                  group[0].Brand,
+			Model:                  group[0].Model,
+			CarCount:               len(group),
+			AverageFuelConsumption: roundTo(consumption[key], 2),
+			AverageAnnualMileage:   roundTo(averageOf(group, func(car *Car) float64 { return car.AnnualMileage }), 0),
+			Factors:                make([]RecommendationFactor, 0, len(factors)),
+		}
+		for _, f := range factors {
+			if f.weight <= 0 || total <= 0 {
+				continue
+			}
+			score, detail := f.score(key, group)
+			weight := f.weight / total
+			rec.Factors = append(rec.Factors, RecommendationFactor{
+				Name:         f.name,
+				Score:        roundTo(score, 3),
+				Weight:       roundTo(weight, 3),
+				Contribution: roundTo(score*weight, 3),
+				Detail:       detail,
+			})
+			rec.Score += score * weight
+		}
+		rec.Score = roundTo(rec.Score, 3)
+		sort.SliceStable(rec.Factors, func(i, j int) bool {
+			return rec.Factors[i].Contribution > rec.Factors[j].Contribution
+		})
+		recommendations = append(recommendations, rec)
+	}
+
+	// 总分相同时车辆多的车型数据更可靠，排在前面
+	sort.Slice(recommendations, func(i, j int) bool {
+		a, b := recommendations[i], recommendations[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.CarCount != b.CarCount {
+			return a.CarCount > b.CarCount
+		}
+		return a.Brand+"/"+a.Model < b.Brand+"/"+b.Model
+	})
+	return recommendations
+}
+
+// fuelEconomyScore 按平均油耗在同类(电动或燃油)车型中的排名评分，油耗越低得分越高
+func fuelEconomyScore(key string, group []*Car, consumption map[string]float64, groups map[string][]*Car) (float64, string) {
+	value := consumption[key]
+	if value <= 0 {
+		return neutralScore, "车队中该车型没有油耗数据"
+	}
+	electric := isElectric(group)
+	unit := "L/100km"
+	if electric {
+		unit = "kWh/100km"
+	}
+
+	higher, peers := 0.0, 0
+	for other, c := range consumption {
+		if other == key || c <= 0 || isElectric(groups[other]) != electric {
+			continue
+		}
+		peers++
+		switch {
+		case c > value:
+			higher++
+		case c == value:
+			higher += 0.5
+		}
+	}
+	if peers == 0 {
+		return neutralScore, fmt.Sprintf("平均油耗 %.1f %s，没有可比较的同类车型", value, unit)
+	}
+	score := higher / float64(peers)
+	switch score {
+	case 1:
+		return score, fmt.Sprintf("平均油耗 %.1f %s，低于所有同类车型", value, unit)
+	case 0:
+		return score, fmt.Sprintf("平均油耗 %.1f %s，高于所有同类车型", value, unit)
+	}
+	return score, fmt.Sprintf("平均油耗 %.1f %s，低于 %.0f%% 的同类车型", value, unit, score*100)
+}
+
+// usageScenarioScore 计算已有车辆的使用场景与用户的平均Jaccard相似度，未填写使用场景的车辆不计入，全部未填写时为0
+func usageScenarioScore(group []*Car, scenarios []string) (float64, string) {
+	sum, counted, matched := 0.0, 0, 0
+	var common []string
+	for _, car := range group {
+		if len(car.UsageScenario) == 0 {
+			continue
+		}
+		counted++
+		intersection := 0
+		union := len(scenarios)
+		for _, scenario := range car.UsageScenario {
+			if containsFold(scenarios, scenario) {
+				intersection++
+				if !containsFold(common, scenario) {
+					common = append(common, scenario)
+				}
+			} else {
+				union++
+			}
+		}
+		if intersection > 0 {
+			matched++
+		}
+		sum += float64(intersection) / float64(union)
+	}
+	if counted == 0 {
+		return 0, "车队中该车型的车辆没有填写使用场景"
+	}
+	score := sum / float64(counted)
+	if matched == 0 {
+		return score, fmt.Sprintf("%d 辆车的使用场景与您的都不相同", counted)
+	}
+	return score, fmt.Sprintf("%d 辆车中有 %d 辆用于%s，使用场景相似度 %.0f%%", counted, matched, strings.Join(common, "、"), score*100)
+}
+
+// annualMileageScore 按已有车辆的年均行驶里程与用户的比值(较小值/较大值)的平均值评分，全部未填写时为0
+func annualMileageScore(group []*Car, annualMileage float64) (float64, string) {
+	sum, counted := 0.0, 0
+	for _, car := range group {
+		if car.AnnualMileage <= 0 {
+			continue
+		}
+		counted++
+		sum += math.Min(car.AnnualMileage, annualMileage) / math.Max(car.AnnualMileage, annualMileage)
+	}
+	if counted == 0 {
+		return 0, "车队中该车型的车辆没有填写年均行驶里程"
+	}
+	score := sum / float64(counted)
+	average := averageOf(group, func(car *Car) float64 { return car.AnnualMileage })
+	return score, fmt.Sprintf("已有车辆年均行驶 %.0f 公里，与您的 %.0f 公里接近程度 %.0f%%", average, annualMileage, score*100)
+}
+
+// storageEnvironmentScore 按已有车辆中存放环境与用户相同的比例评分，未填写存放环境的车辆不计入，全部未填写时为0
+func storageEnvironmentScore(group []*Car, environment string) (float64, string) {
+	counted, matched := 0, 0
+	for _, car := range group {
+		if car.StorageEnvironment == "" {
+			continue
+		}
+		counted++
+		if strings.EqualFold(car.StorageEnvironment, environment) {
+			matched++
+		}
+	}
+	if counted == 0 {
+		return 0, "车队中该车型的车辆没有填写存放环境"
+	}
+	return float64(matched) / float64(counted), fmt.Sprintf("%d 辆车中有 %d 辆存放在%s", counted, matched, environment)
+}
+
+// averageOf 计算车辆在数值字段上的平均值，值为0(未填写)的车辆不计入，全部未填写时返回0
+func averageOf(group []*Car, value func(car *Car) float64) float64 {
+	sum, counted := 0.0, 0
+	for _, car := range group {
+		if v := value(car); v > 0 {
+			sum += v
+			counted++
+		}
+	}
+	if counted == 0 {
+		return 0
+	}
+	return sum / float64(counted)
+}
+
+// isElectric 判断车型是否为电动车，以车辆中较多的燃油类型为准
+func isElectric(group []*Car) bool {
+	electric := 0
+	for _, car := range group {
+		if car.FuelType == "电动" {
+			electric++
+		}
+	}
+	return electric*2 > len(group)
+}
+
+// canonicalValue 将常见叫法转换为标准取值，未知的叫法原样返回
+func canonicalValue(synonyms map[string][]string, value string) string {
+	value = strings.TrimSpace(value)
+	for _, canonical := range sortedKeys(synonyms) {
+		if strings.EqualFold(canonical, value) {
+			return canonical
+		}
+		for _, synonym := range synonyms[canonical] {
+			if strings.EqualFold(synonym, value) {
+				return canonical
+			}
+		}
+	}
+	return value
+}
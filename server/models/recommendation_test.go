@@ -0,0 +1,270 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jasonzheng/carrag/utils"
+)
+
+// equalWeights 各因素权重相同，便于计算期望的总分
+var equalWeights = RecommendationWeights{FuelEconomy: 1, UsageScenario: 1, AnnualMileage: 1, StorageEnvironment: 1}
+
+// findRecommendation 返回指定车型的推荐结果，不存在时测试失败
+func findRecommendation(t *testing.T, recommendations []Recommendation, brand, model string) Recommendation {
+	t.Helper()
+	for _, rec := range recommendations {
+		if rec.Brand == brand && rec.Model == model {
+			return rec
+		}
+	}
+	t.Fatalf("推荐结果中没有 %s %s: %+v", brand, model, recommendations)
+	return Recommendation{}
+}
+
+// factorOf 返回推荐结果中指定因素的得分，不存在时返回false
+func factorOf(rec Recommendation, name string) (RecommendationFactor, bool) {
+	for _, f := range rec.Factors {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return RecommendationFactor{}, false
+}
+
+func TestRecommendFuelEconomy(t *testing.T) {
+	cars := []Car{
+		{Brand: "丰田", Model: "卡罗拉", FuelType: "汽油", FuelConsumption: 6},
+		{Brand: "本田", Model: "雅阁", FuelType: "汽油", FuelConsumption: 8},
+		{Brand: "本田", Model: "雅阁", FuelType: "汽油", FuelConsumption: 8},
+		{Brand: "大众", Model: "途观", FuelType: "汽油", FuelConsumption: 10},
+		// 电动车的电耗数值高于燃油车的油耗，但只与电动车比较
+		{Brand: "比亚迪", Model: "汉", FuelType: "电动", FuelConsumption: 13},
+		{Brand: "特斯拉", Model: "Model 3", FuelType: "电动", FuelConsumption: 15},
+		{Brand: "五菱", Model: "宏光", FuelType: "汽油"},
+	}
+	recommendations := Recommend(cars, RecommendationRequest{}, equalWeights)
+
+	tests := []struct {
+		brand, model string
+		want         float64
+	}{
+		{"丰田", "卡罗拉", 1},
+		{"本田", "雅阁", 0.5},
+		{"大众", "途观", 0},
+		{"比亚迪", "汉", 1},
+		{"特斯拉", "Model 3", 0},
+		{"五菱", "宏光", neutralScore}, // 没有油耗数据
+	}
+	for _, tt := range tests {
+		t.Run(tt.brand+tt.model, func(t *testing.T) {
+			rec := findRecommendation(t, recommendations, tt.brand, tt.model)
+			f, ok := factorOf(rec, FactorFuelEconomy)
+			if !ok {
+				t.Fatalf("缺少油耗因素: %+v", rec.Factors)
+			}
+			if f.Score != tt.want {
+				t.Errorf("油耗得分 = %v，期望 %v (%s)", f.Score, tt.want, f.Detail)
+			}
+		})
+	}
+
+	t.Run("没有同类车型时为中性得分", func(t *testing.T) {
+		recommendations := Recommend([]Car{
+			{Brand: "丰田", Model: "卡罗拉", FuelType: "汽油", FuelConsumption: 6},
+			{Brand: "比亚迪", Model: "汉", FuelType: "电动", FuelConsumption: 13},
+		}, RecommendationRequest{}, equalWeights)
+		for _, rec := range recommendations {
+			if f, _ := factorOf(rec, FactorFuelEconomy); f.Score != neutralScore {
+				t.Errorf("%s %s 油耗得分 = %v，期望 %v", rec.Brand, rec.Model, f.Score, neutralScore)
+			}
+		}
+	})
+}
+
+func TestRecommendUsageScenario(t *testing.T) {
+	tests := []struct {
+		name      string
+		scenarios [][]string // 同一车型各车辆的使用场景
+		want      float64
+	}{
+		{"完全相同", [][]string{{"通勤", "家用"}}, 1},
+		{"部分相同", [][]string{{"通勤"}}, 0.5},
+		{"车辆有额外的使用场景", [][]string{{"通勤", "长途旅行"}}, 0.333},
+		{"都不相同", [][]string{{"越野"}}, 0},
+		{"多辆车取平均", [][]string{{"通勤", "家用"}, {"越野"}}, 0.5},
+		{"未填写的车辆不计入", [][]string{{"通勤", "家用"}, nil}, 1},
+		{"全部未填写时为0", [][]string{nil}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cars []Car
+			for _, scenarios := range tt.scenarios {
+				cars = append(cars, Car{Brand: "丰田", Model: "卡罗拉", UsageScenario: scenarios})
+			}
+			recommendations := Recommend(cars, RecommendationRequest{UsageScenarios: []string{"通勤", "家用"}}, equalWeights)
+			f, ok := factorOf(findRecommendation(t, recommendations, "丰田", "卡罗拉"), FactorUsageScenario)
+			if !ok {
+				t.Fatal("缺少使用场景因素")
+			}
+			if f.Score != tt.want {
+				t.Errorf("使用场景得分 = %v，期望 %v (%s)", f.Score, tt.want, f.Detail)
+			}
+		})
+	}
+}
+
+func TestRecommendAnnualMileage(t *testing.T) {
+	tests := []struct {
+		name    string
+		mileage []float64 // 同一车型各车辆的年均行驶里程
+		want    float64
+	}{
+		{"相同", []float64{10000}, 1},
+		{"高于用户", []float64{20000}, 0.5},
+		{"低于用户", []float64{8000}, 0.8},
+		{"多辆车取比值的平均", []float64{20000, 8000}, 0.65},
+		{"未填写的车辆不计入", []float64{8000, 0}, 0.8},
+		{"全部未填写时为0", []float64{0}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cars []Car
+			for _, mileage := range tt.mileage {
+				cars = append(cars, Car{Brand: "丰田", Model: "卡罗拉", AnnualMileage: mileage})
+			}
+			recommendations := Recommend(cars, RecommendationRequest{AnnualMileage: 10000}, equalWeights)
+			f, ok := factorOf(findRecommendation(t, recommendations, "丰田", "卡罗拉"), FactorAnnualMileage)
+			if !ok {
+				t.Fatal("缺少年均行驶里程因素")
+			}
+			if f.Score != tt.want {
+				t.Errorf("年均行驶里程得分 = %v，期望 %v (%s)", f.Score, tt.want, f.Detail)
+			}
+		})
+	}
+}
+
+func TestRecommendWeights(t *testing.T) {
+	weights := RecommendationWeights{FuelEconomy: 0.3, UsageScenario: 0.35, AnnualMileage: 0.25, StorageEnvironment: 0.1}
+	cars := []Car{{Brand: "丰田", Model: "卡罗拉", UsageScenario: []string{"通勤"}, AnnualMileage: 10000, StorageEnvironment: "地下停车场"}}
+
+	tests := []struct {
+		name        string
+		req         RecommendationRequest
+		weights     RecommendationWeights
+		wantWeights map[string]float64 // 参与评分的因素及归一化后的权重
+		wantScore   float64
+	}{
+		{
+			name:        "未提供的因素不参与评分，其余权重按比例放大",
+			req:         RecommendationRequest{UsageScenarios: []string{"通勤"}},
+			weights:     weights,
+			wantWeights: map[string]float64{FactorFuelEconomy: 0.462, FactorUsageScenario: 0.538},
+			// 油耗为中性得分0.5，使用场景完全相同
+			wantScore: 0.769,
+		},
+		{
+			name:    "提供全部信息",
+			req:     RecommendationRequest{UsageScenarios: []string{"通勤"}, AnnualMileage: 10000, StorageEnvironment: "地下停车场"},
+			weights: weights,
+			wantWeights: map[string]float64{
+				FactorFuelEconomy: 0.3, FactorUsageScenario: 0.35, FactorAnnualMileage: 0.25, FactorStorageEnvironment: 0.1,
+			},
+			wantScore: 0.85,
+		},
+		{
+			name:        "权重为0的因素不参与评分",
+			req:         RecommendationRequest{UsageScenarios: []string{"通勤"}, StorageEnvironment: "地下停车场"},
+			weights:     RecommendationWeights{UsageScenario: 1, StorageEnvironment: 1},
+			wantWeights: map[string]float64{FactorUsageScenario: 0.5, FactorStorageEnvironment: 0.5},
+			wantScore:   1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := Recommend(cars, tt.req, tt.weights)[0]
+			if len(rec.Factors) != len(tt.wantWeights) {
+				t.Fatalf("参与评分的因素为 %+v，期望 %v", rec.Factors, tt.wantWeights)
+			}
+			for _, f := range rec.Factors {
+				if want, ok := tt.wantWeights[f.Name]; !ok || f.Weight != want {
+					t.Errorf("因素 %s 的权重 = %v，期望 %v", f.Name, f.Weight, tt.wantWeights[f.Name])
+				}
+			}
+			if rec.Score != tt.wantScore {
+				t.Errorf("总分 = %v，期望 %v", rec.Score, tt.wantScore)
+			}
+			for i := 1; i < len(rec.Factors); i++ {
+				if rec.Factors[i].Contribution > rec.Factors[i-1].Contribution {
+					t.Errorf("因素未按贡献从大到小排序: %+v", rec.Factors)
+				}
+			}
+		})
+	}
+}
+
+func TestRecommendOrder(t *testing.T) {
+	// 只按使用场景评分，丰田得分最高；其余车型得分相同，车辆多的排在前面，车辆数也相同时按品牌和车型排序
+	cars := []Car{
+		{Brand: "现代", Model: "伊兰特", UsageScenario: []string{"越野"}},
+		{Brand: "本田", Model: "思域", UsageScenario: []string{"越野"}},
+		{Brand: "大众", Model: "朗逸", UsageScenario: []string{"越野"}},
+		{Brand: "大众", Model: "朗逸", UsageScenario: []string{"越野"}},
+		{Brand: "丰田", Model: "卡罗拉", UsageScenario: []string{"通勤"}},
+		{Brand: "", Model: "未知", UsageScenario: []string{"通勤"}},
+	}
+	recommendations := Recommend(cars, RecommendationRequest{UsageScenarios: []string{"通勤"}}, RecommendationWeights{UsageScenario: 1})
+
+	want := []string{"丰田/卡罗拉", "大众/朗逸", "本田/思域", "现代/伊兰特"}
+	var got []string
+	for _, rec := range recommendations {
+		got = append(got, rec.Brand+"/"+rec.Model)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("推荐顺序为 %v，期望 %v", got, want)
+	}
+}
+
+func TestRecommendationServiceValidate(t *testing.T) {
+	var cars []Car
+	for i := 0; i < MaxRecommendations+5; i++ {
+		cars = append(cars, Car{ID: fmt.Sprint(i), Brand: "品牌", Model: fmt.Sprint("车型", i), AnnualMileage: 10000})
+	}
+	service := NewRecommendationService(NewCarService(newMemoryCarRepository(cars...), newTestLogger(t), nil), newTestLogger(t))
+	service.SetOptions(RecommendationOptions{Weights: equalWeights})
+
+	tests := []struct {
+		name    string
+		req     RecommendationRequest
+		wantErr bool
+		wantLen int
+	}{
+		{"未指定推荐数时使用默认值", RecommendationRequest{AnnualMileage: 10000}, false, DefaultRecommendations},
+		{"指定推荐数", RecommendationRequest{AnnualMileage: 10000, Limit: 3}, false, 3},
+		{"最多推荐数", RecommendationRequest{AnnualMileage: 10000, Limit: MaxRecommendations}, false, MaxRecommendations},
+		{"超过最多推荐数", RecommendationRequest{AnnualMileage: 10000, Limit: MaxRecommendations + 1}, true, 0},
+		{"推荐数为负数", RecommendationRequest{AnnualMileage: 10000, Limit: -1}, true, 0},
+		{"年均行驶里程为负数", RecommendationRequest{AnnualMileage: -1}, true, 0},
+		{"没有提供任何用车情况", RecommendationRequest{StorageEnvironment: " "}, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recommendations, err := service.Recommend(context.Background(), tt.req)
+			if tt.wantErr {
+				if !errors.Is(err, utils.ErrInvalid) {
+					t.Errorf("错误为 %v，期望校验错误", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Recommend 失败: %v", err)
+			}
+			if len(recommendations) != tt.wantLen {
+				t.Errorf("返回 %d 个推荐，期望 %d 个", len(recommendations), tt.wantLen)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jasonzheng/carrag/utils"
+)
+
+// refresher 定期执行刷新函数，用于定期重建内存索引，使其他实例写入的数据可被查询到
+type refresher struct {
+	name    string                          // 刷新的内容，用于日志
+	timeout time.Duration                   // 单次刷新的超时时间
+	refresh func(ctx context.Context) error // 刷新函数
+
+	startOnce sync.Once
+	stop      chan struct{}
+	stopOnce  sync.Once
+	done      chan struct{}
+}
+
+// newRefresher 创建定期刷新器，需调用start开始刷新
+func newRefresher(name string, timeout time.Duration, refresh func(ctx context.Context) error) *refresher {
+	return &refresher{
+		name:    name,
+		timeout: timeout,
+		refresh: refresh,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// start 每隔interval刷新一次，interval不大于0时不刷新，只有第一次调用生效
+func (r *refresher) start(interval time.Duration, logger *utils.Logger) {
+	r.startOnce.Do(func() {
+		if interval <= 0 {
+			close(r.done)
+			return
+		}
+		go r.loop(interval, logger)
+	})
+}
+
+// close 停止定期刷新，等待正在进行的刷新完成，未开始刷新时直接返回
+func (r *refresher) close() {
+	r.stopOnce.Do(func() {
+		close(r.stop)
+	})
+	r.startOnce.Do(func() {
+		close(r.done)
+	})
+	<-r.done
+}
+
+// loop 定期刷新，失败时记录警告并继续使用原有数据
+func (r *refresher) loop(interval time.Duration, logger *utils.Logger) {
+	defer close(r.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+			if err := r.refresh(ctx); err != nil {
+				logger.Warning("%v，继续使用原有%s", err, r.name)
+			}
+			cancel()
+		}
+	}
+}
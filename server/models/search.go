@@ -0,0 +1,184 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jasonzheng/carrag/search"
+	"github.com/jasonzheng/carrag/tracing"
+	"github.com/jasonzheng/carrag/utils"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// DefaultSearchLimit 搜索默认返回的结果数
+const DefaultSearchLimit = 20
+
+// searchRefreshTimeout 定期重建索引时读取车辆信息的超时时间
+const searchRefreshTimeout = 30 * time.Second
+
+// SearchResult 车辆搜索结果
+type SearchResult struct {
+	Car     Car      `json:"car"`     // 车辆信息
+	Score   float64  `json:"score"`   // 相关度得分
+	Matches []string `json:"matches"` // 匹配的字段
+}
+
+// SearchService 车辆全文搜索服务，在内存中维护品牌、车型、备注等字段的倒排索引
+// 本实例写入的车辆信息立即更新索引，其他实例写入的数据在定期重建索引后可被搜索到
+type SearchService struct {
+	Cars               *CarService // 车辆服务
+	utils.LoggerHolder             // 日志记录器
+	MaxResults         int         // 单次搜索返回的最大结果数，0表示不限制
+
+	index   *search.Index
+	mu      sync.RWMutex
+	cars    map[string]Car  // 已索引的车辆信息
+	changes map[string]*Car // 重建索引期间本实例写入的变更，nil表示车辆已删除，重建完成后重放
+
+	rebuildMu sync.Mutex // 同一时间只执行一次重建
+	refresher *refresher // 定期重建索引
+}
+
+// NewSearchService 创建车辆搜索服务，车辆创建、更新或删除时同步更新索引
+// 需调用Rebuild建立初始索引
+func NewSearchService(cars *CarService, logger *utils.Logger) *SearchService {
+	service := &SearchService{
+		Cars:         cars,
+		LoggerHolder: utils.LoggerHolder{Logger: logger},
+		index:        search.NewIndex(),
+		cars:         make(map[string]Car),
+	}
+	service.refresher = newRefresher("搜索索引", searchRefreshTimeout, service.Rebuild)
+	cars.OnSave(service.indexCar)
+	cars.OnDelete(service.removeCar)
+	return service
+}
+
+// Rebuild 从车辆仓库读取所有车辆信息并重建索引
+// 读取期间本实例写入的变更在替换索引前重放，不会被读取到的旧数据覆盖
+func (s *SearchService) Rebuild(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "SearchService.Rebuild")
+	defer tracing.End(span, &err)
+
+	s.rebuildMu.Lock()
+	defer s.rebuildMu.Unlock()
+	s.mu.Lock()
+	s.changes = make(map[string]*Car)
+	s.mu.Unlock()
+
+	// 直接读取仓库，避免使用其他实例写入前的列表缓存
+	cars, err := s.Cars.Repo.FindAll(ctx)
+	if err != nil {
+		s.mu.Lock()
+		s.changes = nil
+		s.mu.Unlock()
+		return fmt.Errorf("重建搜索索引失败: %w", err)
+	}
+
+	byID := make(map[string]Car, len(cars))
+	for i := range cars {
+		byID[cars[i].ID] = cars[i]
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, car := range s.changes {
+		if car == nil {
+			delete(byID, id)
+		} else {
+			byID[id] = *car
+		}
+	}
+	s.changes = nil
+	docs := make(map[string][]search.Field, len(byID))
+	for id, car := range byID {
+		docs[id] = searchFields(&car)
+	}
+	s.index.Reset(docs)
+	s.cars = byID
+
+	s.LoggerFor(ctx).Debug("搜索索引已重建，共 %d 辆车", len(byID))
+	return nil
+}
+
+// StartRefresh 每隔interval重建一次索引，使其他实例写入的数据可被搜索到，interval不大于0时不重建
+func (s *SearchService) StartRefresh(interval time.Duration) {
+	s.refresher.start(interval, s.Logger)
+}
+
+// Close 停止定期重建索引
+func (s *SearchService) Close() {
+	s.refresher.close()
+}
+
+// Search 搜索品牌、车型、备注、使用场景和存放环境，按相关度从高到低返回最多limit条结果，以及匹配的车辆总数
+// 品牌和车型支持全拼和拼音首字母，如 "bm" 匹配 "宝马"
+func (s *SearchService) Search(ctx context.Context, query string, limit int) (_ []SearchResult, total int, err error) {
+	ctx, span := tracing.Start(ctx, "SearchService.Search", attribute.String("search.query", query))
+	defer tracing.End(span, &err)
+
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, 0, utils.ValidationError("搜索内容不能为空")
+	}
+	if limit <= 0 {
+		return nil, 0, utils.ValidationError(fmt.Sprintf("结果数必须大于0: %d", limit))
+	}
+	if s.MaxResults > 0 && limit > s.MaxResults {
+		return nil, 0, utils.ValidationError(fmt.Sprintf("结果数不能超过 %d: %d", s.MaxResults, limit))
+	}
+
+	s.LoggerFor(ctx).Info("搜索车辆: %q", query)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	hits, total := s.index.Search(query, limit)
+	results := make([]SearchResult, 0, len(hits))
+	for _, hit := range hits {
+		car, ok := s.cars[hit.ID]
+		if !ok {
+			continue
+		}
+		results = append(results, SearchResult{Car: car, Score: hit.Score, Matches: hit.Fields})
+	}
+	return results, total, nil
+}
+
+// indexCar 更新车辆的索引，在车辆创建或更新后调用
+func (s *SearchService) indexCar(ctx context.Context, car *Car) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.index.Add(car.ID, searchFields(car)...)
+	s.cars[car.ID] = *car
+	if s.changes != nil {
+		changed := *car
+		s.changes[car.ID] = &changed
+	}
+	return nil
+}
+
+// removeCar 从索引中删除车辆，在车辆删除后调用
+func (s *SearchService) removeCar(ctx context.Context, carID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.index.Remove(carID)
+	delete(s.cars, carID)
+	if s.changes != nil {
+		s.changes[carID] = nil
+	}
+	return nil
+}
+
+// searchFields 返回车辆参与搜索的字段，品牌和车型权重较高并支持拼音匹配
+func searchFields(car *Car) []search.Field {
+	return []search.Field{
+		{Name: "brand", Text: car.Brand, Boost: 3, Pinyin: true},
+		{Name: "model", Text: car.Model, Boost: 3, Pinyin: true},
+		{Name: "remarks", Text: car.Remarks},
+		{Name: "usageScenario", Text: strings.Join(car.UsageScenario, " ")},
+		{Name: "storageEnvironment", Text: car.StorageEnvironment},
+	}
+}
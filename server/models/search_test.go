@@ -0,0 +1,164 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/jasonzheng/carrag/utils"
+)
+
+// memoryCarRepository 保存在内存中的车辆信息仓库，beforeFindAll在FindAll读取数据后、返回前调用
+type memoryCarRepository struct {
+	mu            sync.Mutex
+	cars          map[string]Car
+	beforeFindAll func()
+}
+
+func newMemoryCarRepository(cars ...Car) *memoryCarRepository {
+	repo := &memoryCarRepository{cars: make(map[string]Car)}
+	for _, car := range cars {
+		repo.cars[car.ID] = car
+	}
+	return repo
+}
+
+func (r *memoryCarRepository) FindAll(ctx context.Context) ([]Car, error) {
+	r.mu.Lock()
+	cars := make([]Car, 0, len(r.cars))
+	for _, car := range r.cars {
+		cars = append(cars, car)
+	}
+	r.mu.Unlock()
+	if r.beforeFindAll != nil {
+		r.beforeFindAll()
+	}
+	return cars, nil
+}
+
+func (r *memoryCarRepository) FindByID(ctx context.Context, id string) (*Car, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	car, ok := r.cars[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrCarNotFound, id)
+	}
+	return &car, nil
+}
+
+func (r *memoryCarRepository) Create(ctx context.Context, car *Car) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cars[car.ID] = *car
+	return nil
+}
+
+func (r *memoryCarRepository) Update(ctx context.Context, car *Car) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.cars[car.ID]; !ok {
+		return fmt.Errorf("%w: %s", ErrCarNotFound, car.ID)
+	}
+	r.cars[car.ID] = *car
+	return nil
+}
+
+func (r *memoryCarRepository) UpdateFuelConsumption(ctx context.Context, id string, consumption float64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	car, ok := r.cars[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrCarNotFound, id)
+	}
+	car.FuelConsumption = consumption
+	r.cars[id] = car
+	return nil
+}
+
+func (r *memoryCarRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.cars[id]; !ok {
+		return fmt.Errorf("%w: %s", ErrCarNotFound, id)
+	}
+	delete(r.cars, id)
+	return nil
+}
+
+func (r *memoryCarRepository) FindByBrand(ctx context.Context, brand string) ([]Car, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var cars []Car
+	for _, car := range r.cars {
+		if car.Brand == brand {
+			cars = append(cars, car)
+		}
+	}
+	return cars, nil
+}
+
+func TestSearchRebuildKeepsConcurrentChanges(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		change func(cars *CarService) error // 重建读取车辆信息后、替换索引前写入的变更
+		query  string
+		want   int
+	}{
+		{
+			name: "更新车辆",
+			change: func(cars *CarService) error {
+				return cars.UpdateCar(ctx, &Car{ID: "1", Brand: "奔驰", Model: "C级"})
+			},
+			query: "奔驰",
+			want:  1,
+		},
+		{
+			name:   "删除车辆",
+			change: func(cars *CarService) error { return cars.DeleteCar(ctx, "1") },
+			query:  "宝马",
+			want:   0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMemoryCarRepository(Car{ID: "1", Brand: "宝马", Model: "X5"})
+			cars := NewCarService(repo, newTestLogger(t), nil)
+			service := NewSearchService(cars, newTestLogger(t))
+			if err := service.Rebuild(ctx); err != nil {
+				t.Fatalf("Rebuild 失败: %v", err)
+			}
+
+			repo.beforeFindAll = func() {
+				repo.beforeFindAll = nil
+				if err := tt.change(cars); err != nil {
+					t.Errorf("写入变更失败: %v", err)
+				}
+			}
+			if err := service.Rebuild(ctx); err != nil {
+				t.Fatalf("Rebuild 失败: %v", err)
+			}
+
+			results, total, err := service.Search(ctx, tt.query, DefaultSearchLimit)
+			if err != nil {
+				t.Fatalf("Search 失败: %v", err)
+			}
+			if total != tt.want || len(results) != tt.want {
+				t.Errorf("Search(%q) 返回 %d 条 (total=%d)，期望 %d 条", tt.query, len(results), total, tt.want)
+			}
+		})
+	}
+}
+
+// newTestLogger 创建输出到临时目录的日志记录器，只输出致命错误
+func newTestLogger(t *testing.T) *utils.Logger {
+	t.Helper()
+	logger, err := utils.NewLogger(t.TempDir(), utils.FATAL, utils.RotateOptions{})
+	if err != nil {
+		t.Fatalf("创建日志记录器失败: %v", err)
+	}
+	t.Cleanup(func() { logger.Close() })
+	return logger
+}
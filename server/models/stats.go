@@ -0,0 +1,307 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/jasonzheng/carrag/tracing"
+	"github.com/jasonzheng/carrag/utils"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// 默认和最大统计范围
+const (
+	DefaultStatsBuckets = 10  // 分布直方图的默认区间数
+	MaxStatsBuckets     = 50  // 分布直方图的最大区间数
+	DefaultStatsMonths  = 12  // 按月统计新增车辆的默认月数
+	MaxStatsMonths      = 120 // 按月统计新增车辆的最大月数
+)
+
+// statsUnspecified 未填写的分类值
+const statsUnspecified = "未填写"
+
+// statsPercentiles 分布统计返回的百分位数
+var statsPercentiles = []int{25, 50, 75, 90, 95}
+
+// countFields 可按值计数的字段，返回车辆在该字段上的取值，可以有多个
+var countFields = map[string]func(car *Car) []string{
+	"brand": func(car *Car) []string { return []string{car.Brand} },
+	// 车型按"品牌/车型"计数，避免不同品牌的同名车型合并
+	"model":              func(car *Car) []string { return []string{car.Brand + "/" + car.Model} },
+	"fuelType":           func(car *Car) []string { return []string{car.FuelType} },
+	"storageEnvironment": func(car *Car) []string { return []string{car.StorageEnvironment} },
+	"usageScenario":      func(car *Car) []string { return car.UsageScenario },
+}
+
+// distributionFields 可统计分布的数值字段，0表示未填写
+var distributionFields = map[string]func(car *Car) float64{
+	"mileage":         func(car *Car) float64 { return car.Mileage },
+	"annualMileage":   func(car *Car) float64 { return car.AnnualMileage },
+	"fuelConsumption": func(car *Car) float64 { return car.FuelConsumption },
+}
+
+// CountItem 字段取值及对应的车辆数
+type CountItem struct {
+	Value string `json:"value"` // 字段取值，未填写时为"未填写"
+	Count int    `json:"count"` // 车辆数
+}
+
+// HistogramBucket 直方图区间[Min, Max)，最后一个区间包含Max
+type HistogramBucket struct {
+	Min   float64 `json:"min"`   // 区间下限
+	Max   float64 `json:"max"`   // 区间上限
+	Count int     `json:"count"` // 车辆数
+}
+
+// Distribution 数值字段的分布
+type Distribution struct {
+	Field       string             `json:"field"`       // 字段名
+	Count       int                `json:"count"`       // 填写了该字段的车辆数
+	Min         float64            `json:"min"`         // 最小值
+	Max         float64            `json:"max"`         // 最大值
+	Mean        float64            `json:"mean"`        // 平均值
+	Percentiles map[string]float64 `json:"percentiles"` // 百分位数，键为 p25、p50 等
+	Buckets     []HistogramBucket  `json:"buckets"`     // 等宽直方图
+}
+
+// MonthlyCount 某月新增的车辆数
+type MonthlyCount struct {
+	Month string `json:"month"` // 月份(YYYY-MM，UTC)
+	Count int    `json:"count"` // 新增车辆数
+}
+
+// FleetStats 车队统计概览
+type FleetStats struct {
+	Total         int                     `json:"total"`         // 车辆总数
+	Counts        map[string][]CountItem  `json:"counts"`        // 各分类字段的计数
+	Distributions map[string]Distribution `json:"distributions"` // 各数值字段的分布
+	Monthly       []MonthlyCount          `json:"monthly"`       // 按月新增的车辆数
+}
+
+// StatsService 车队统计服务，基于全部车辆信息计算
+type StatsService struct {
+	Cars               *CarService // 车辆服务
+	utils.LoggerHolder             // 日志记录器
+}
+
+// NewStatsService 创建车队统计服务
+func NewStatsService(cars *CarService, logger *utils.Logger) *StatsService {
+	return &StatsService{
+		Cars:         cars,
+		LoggerHolder: utils.LoggerHolder{Logger: logger},
+	}
+}
+
+// Overview 计算所有分类字段的计数、数值字段的分布和按月新增的车辆数，使用默认的区间数和月数
+func (s *StatsService) Overview(ctx context.Context) (_ *FleetStats, err error) {
+	ctx, span := tracing.Start(ctx, "StatsService.Overview")
+	defer tracing.End(span, &err)
+
+	s.LoggerFor(ctx).Info("计算车队统计概览")
+	cars, err := s.Cars.GetAllCars(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &FleetStats{
+		Total:         len(cars),
+		Counts:        make(map[string][]CountItem, len(countFields)),
+		Distributions: make(map[string]Distribution, len(distributionFields)),
+		Monthly:       CountByMonth(cars, DefaultStatsMonths, time.Now()),
+	}
+	for field, values := range countFields {
+		stats.Counts[field] = CountBy(cars, values)
+	}
+	for field, value := range distributionFields {
+		stats.Distributions[field] = Distribute(field, cars, value, DefaultStatsBuckets)
+	}
+	return stats, nil
+}
+
+// Counts 按字段取值计数，按车辆数从多到少排序
+func (s *StatsService) Counts(ctx context.Context, field string) (_ []CountItem, err error) {
+	ctx, span := tracing.Start(ctx, "StatsService.Counts", attribute.String("stats.field", field))
+	defer tracing.End(span, &err)
+
+	values, ok := countFields[field]
+	if !ok {
+		return nil, utils.ValidationError(fmt.Sprintf("不支持按 %q 计数，可选字段: %v", field, sortedKeys(countFields)))
+	}
+	s.LoggerFor(ctx).Info("按 %s 统计车辆数", field)
+	cars, err := s.Cars.GetAllCars(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return CountBy(cars, values), nil
+}
+
+// Distribution 计算数值字段的分布，直方图分为buckets个等宽区间
+func (s *StatsService) Distribution(ctx context.Context, field string, buckets int) (_ *Distribution, err error) {
+	ctx, span := tracing.Start(ctx, "StatsService.Distribution", attribute.String("stats.field", field))
+	defer tracing.End(span, &err)
+
+	value, ok := distributionFields[field]
+	if !ok {
+		return nil, utils.ValidationError(fmt.Sprintf("不支持统计 %q 的分布，可选字段: %v", field, sortedKeys(distributionFields)))
+	}
+	if buckets < 1 || buckets > MaxStatsBuckets {
+		return nil, utils.ValidationError(fmt.Sprintf("区间数必须在 1-%d 之间: %d", MaxStatsBuckets, buckets))
+	}
+	s.LoggerFor(ctx).Info("统计 %s 的分布，区间数: %d", field, buckets)
+	cars, err := s.Cars.GetAllCars(ctx)
+	if err != nil {
+		return nil, err
+	}
+	distribution := Distribute(field, cars, value, buckets)
+	return &distribution, nil
+}
+
+// Monthly 统计最近months个月(含当月)每月新增的车辆数
+func (s *StatsService) Monthly(ctx context.Context, months int) (_ []MonthlyCount, err error) {
+	ctx, span := tracing.Start(ctx, "StatsService.Monthly", attribute.Int("stats.months", months))
+	defer tracing.End(span, &err)
+
+	if months < 1 || months > MaxStatsMonths {
+		return nil, utils.ValidationError(fmt.Sprintf("月数必须在 1-%d 之间: %d", MaxStatsMonths, months))
+	}
+	s.LoggerFor(ctx).Info("统计最近 %d 个月新增的车辆数", months)
+	cars, err := s.Cars.GetAllCars(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return CountByMonth(cars, months, time.Now()), nil
+}
+
+// CountBy 按values返回的取值计数，按车辆数从多到少、取值从小到大排序
+func CountBy(cars []Car, values func(car *Car) []string) []CountItem {
+	counts := make(map[string]int)
+	for i := range cars {
+		carValues := values(&cars[i])
+		if len(carValues) == 0 {
+			counts[statsUnspecified]++
+			continue
+		}
+		for _, value := range carValues {
+			if value == "" || value == "/" {
+				value = statsUnspecified
+			}
+			counts[value]++
+		}
+	}
+
+	items := make([]CountItem, 0, len(counts))
+	for value, count := range counts {
+		items = append(items, CountItem{Value: value, Count: count})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Count != items[j].Count {
+			return items[i].Count > items[j].Count
+		}
+		return items[i].Value < items[j].Value
+	})
+	return items
+}
+
+// Distribute 计算数值字段的分布，值为0(未填写)的车辆不计入
+func Distribute(field string, cars []Car, value func(car *Car) float64, buckets int) Distribution {
+	values := make([]float64, 0, len(cars))
+	for i := range cars {
+		if v := value(&cars[i]); v > 0 {
+			values = append(values, v)
+		}
+	}
+	sort.Float64s(values)
+
+	distribution := Distribution{
+		Field:       field,
+		Count:       len(values),
+		Percentiles: make(map[string]float64, len(statsPercentiles)),
+		Buckets:     make([]HistogramBucket, 0, buckets),
+	}
+	if len(values) == 0 {
+		return distribution
+	}
+
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	distribution.Min = values[0]
+	distribution.Max = values[len(values)-1]
+	distribution.Mean = roundTo(sum/float64(len(values)), 2)
+	for _, p := range statsPercentiles {
+		distribution.Percentiles[fmt.Sprintf("p%d", p)] = roundTo(percentile(values, float64(p)), 2)
+	}
+
+	// 所有值相同时只有一个区间
+	if distribution.Min == distribution.Max {
+		buckets = 1
+	}
+	width := (distribution.Max - distribution.Min) / float64(buckets)
+	for i := 0; i < buckets; i++ {
+		distribution.Buckets = append(distribution.Buckets, HistogramBucket{
+			Min: roundTo(distribution.Min+width*float64(i), 2),
+			Max: roundTo(distribution.Min+width*float64(i+1), 2),
+		})
+	}
+	distribution.Buckets[buckets-1].Max = distribution.Max
+	for _, v := range values {
+		index := buckets - 1
+		if width > 0 {
+			index = int(math.Min(float64(buckets-1), math.Floor((v-distribution.Min)/width)))
+		}
+		// 浮点误差可能使区间下限上的值落入前一个区间，按返回的区间边界校正
+		for index+1 < buckets && v >= distribution.Buckets[index+1].Min {
+			index++
+		}
+		for index > 0 && v < distribution.Buckets[index].Min {
+			index--
+		}
+		distribution.Buckets[index].Count++
+	}
+	return distribution
+}
+
+// CountByMonth 统计截至now的最近months个月(含当月)每月新增的车辆数，按月份从早到晚排序，没有新增的月份计为0
+func CountByMonth(cars []Car, months int, now time.Time) []MonthlyCount {
+	now = now.UTC()
+	current := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	result := make([]MonthlyCount, months)
+	index := make(map[string]int, months)
+	for i := range result {
+		month := current.AddDate(0, i-months+1, 0).Format("2006-01")
+		result[i].Month = month
+		index[month] = i
+	}
+
+	for i := range cars {
+		if slot, ok := index[cars[i].CreatedAt.UTC().Format("2006-01")]; ok {
+			result[slot].Count++
+		}
+	}
+	return result
+}
+
+// percentile 计算已排序数据的第p百分位数，在相邻两个值之间线性插值
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
+
+// sortedKeys 返回按字典序排序的键
+func sortedKeys[V any](fields map[string]V) []string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
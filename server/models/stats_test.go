@@ -0,0 +1,73 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestPercentile(t *testing.T) {
+	tests := []struct {
+		name   string
+		sorted []float64
+		p      float64
+		want   float64
+	}{
+		{"只有一个值", []float64{7}, 90, 7},
+		{"p0为最小值", []float64{1, 2, 3, 4}, 0, 1},
+		{"p100为最大值", []float64{1, 2, 3, 4}, 100, 4},
+		{"奇数个值的中位数", []float64{1, 2, 3}, 50, 2},
+		{"偶数个值的中位数取中间两个的平均", []float64{1, 2, 3, 4}, 50, 2.5},
+		{"两个值之间线性插值", []float64{10, 20}, 25, 12.5},
+		{"相同的值", []float64{5, 5, 5}, 75, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := percentile(tt.sorted, tt.p); got != tt.want {
+				t.Errorf("percentile(%v, %v) = %v，期望 %v", tt.sorted, tt.p, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDistributeBuckets(t *testing.T) {
+	tests := []struct {
+		name       string
+		values     []float64
+		buckets    int
+		wantCounts []int
+		wantMax    float64 // 最后一个区间的上限
+	}{
+		{"没有值时没有区间", nil, 5, []int{}, 0},
+		{"未填写(0)的值不参与统计", []float64{0, 0, 3}, 3, []int{1}, 3},
+		{"所有值相同时只有一个区间", []float64{2, 2, 2}, 4, []int{3}, 2},
+		{"最大值计入最后一个区间", []float64{0, 1, 1.5, 2}, 2, []int{1, 2}, 2},
+		{"区间下限的值计入该区间", []float64{1, 2, 3, 4, 5}, 4, []int{1, 1, 1, 2}, 5},
+		{"小数边界不受浮点误差影响", []float64{0.1, 0.2, 0.3, 0.4, 0.5}, 4, []int{1, 1, 1, 2}, 0.5},
+		{"一个区间包含所有值", []float64{1, 5, 9}, 1, []int{3}, 9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cars := make([]Car, len(tt.values))
+			for i, v := range tt.values {
+				cars[i].Mileage = v
+			}
+			distribution := Distribute("mileage", cars, distributionFields["mileage"], tt.buckets)
+
+			if len(distribution.Buckets) != len(tt.wantCounts) {
+				t.Fatalf("返回 %d 个区间，期望 %d 个: %+v", len(distribution.Buckets), len(tt.wantCounts), distribution.Buckets)
+			}
+			total := 0
+			for i, want := range tt.wantCounts {
+				if got := distribution.Buckets[i].Count; got != want {
+					t.Errorf("区间 %+v 的车辆数 = %d，期望 %d", distribution.Buckets[i], got, want)
+				}
+				total += distribution.Buckets[i].Count
+			}
+			if total != distribution.Count {
+				t.Errorf("各区间车辆数之和 = %d，期望等于 Count %d", total, distribution.Count)
+			}
+			if n := len(distribution.Buckets); n > 0 && distribution.Buckets[n-1].Max != tt.wantMax {
+				t.Errorf("最后一个区间上限 = %v，期望 %v", distribution.Buckets[n-1].Max, tt.wantMax)
+			}
+		})
+	}
+}
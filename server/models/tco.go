@@ -0,0 +1,290 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/jasonzheng/carrag/tracing"
+	"github.com/jasonzheng/carrag/utils"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// 能源种类，用于查找单价
+const (
+	Energy92          = "92"          // 92号汽油(元/L)
+	Energy95          = "95"          // 95号汽油(元/L)
+	EnergyDiesel      = "diesel"      // 柴油(元/L)
+	EnergyElectricity = "electricity" // 电(元/kWh)
+)
+
+// 车队成本排名的排序字段
+const (
+	TCOSortTotalCost = "totalCost" // 按总成本
+	TCOSortCostPerKm = "costPerKm" // 按每公里成本
+)
+
+// TCOOptions 总拥有成本计算选项
+type TCOOptions struct {
+	FuelPrices       map[string]float64 // 能源单价，键为能源种类
+	FuelTypes        map[string]string  // 车辆燃油类型对应的能源种类，燃油类型本身是能源种类时无需配置
+	InsurancePerYear float64            // 车辆未填写保险费用时使用的每年保险费用(元)
+	DepreciationRate float64            // 每年折旧率，按余额递减法计算
+}
+
+// TCOReport 车辆在统计期间的总拥有成本
+type TCOReport struct {
+	Rank             int       `json:"rank,omitempty"`      // 在车队成本排名中的名次
+	CarID            string    `json:"carId"`               // 车辆ID
+	Brand            string    `json:"brand"`               // 品牌
+	Model            string    `json:"model"`               // 车型
+	From             time.Time `json:"from"`                // 统计开始时间
+	To               time.Time `json:"to"`                  // 统计结束时间
+	Distance         float64   `json:"distance"`            // 按年均行驶里程估算的期间行驶里程(km)
+	FuelCost         float64   `json:"fuelCost"`            // 燃油费用(元)，有加油记录的一段为加油费用合计，其余时间按油耗、行驶里程和能源单价估算
+	FuelLogged       bool      `json:"fuelLogged"`          // 燃油费用是否包含加油记录
+	FuelCoverage     float64   `json:"fuelCoverage"`        // 有加油记录的一段占统计期间的比例(0~1)，其余时间的燃油费用为估算
+	MaintenanceCost  float64   `json:"maintenanceCost"`     // 期间保养记录的费用合计(元)
+	InsuranceCost    float64   `json:"insuranceCost"`       // 按天折算的保险费用(元)
+	DepreciationCost float64   `json:"depreciationCost"`    // 期间的折旧(元)
+	TotalCost        float64   `json:"totalCost"`           // 总成本(元)
+	CostPerKm        float64   `json:"costPerKm,omitempty"` // 每公里成本(元/km)，无法估算行驶里程时为空
+	Notes            []string  `json:"notes,omitempty"`     // 缺少数据而未计入的费用说明
+}
+
+// TCOService 车辆总拥有成本服务
+type TCOService struct {
+	Cars               *CarService           // 车辆服务
+	Maintenance        MaintenanceRepository // 保养记录仓库，用于汇总保养费用
+	Fuel               FuelRepository        // 加油记录仓库，用于汇总燃油费用
+	utils.LoggerHolder                       // 日志记录器
+
+	options atomic.Pointer[TCOOptions] // 成本计算选项，支持运行时调整
+}
+
+// NewTCOService 创建总拥有成本服务
+func NewTCOService(cars *CarService, maintenance MaintenanceRepository, fuel FuelRepository, logger *utils.Logger) *TCOService {
+	service := &TCOService{
+		Cars:         cars,
+		Maintenance:  maintenance,
+		Fuel:         fuel,
+		LoggerHolder: utils.LoggerHolder{Logger: logger},
+	}
+	service.SetOptions(TCOOptions{})
+	return service
+}
+
+// SetOptions 设置成本计算选项
+func (s *TCOService) SetOptions(options TCOOptions) {
+	s.options.Store(&options)
+}
+
+// Options 获取当前成本计算选项
+func (s *TCOService) Options() TCOOptions {
+	return *s.options.Load()
+}
+
+// Report 计算车辆在[from, to)期间的总拥有成本
+func (s *TCOService) Report(ctx context.Context, carID string, from, to time.Time) (_ *TCOReport, err error) {
+	ctx, span := tracing.Start(ctx, "TCOService.Report", attribute.String("car.id", carID))
+	defer tracing.End(span, &err)
+
+	s.LoggerFor(ctx).Info("计算车辆总拥有成本，车辆ID: %s，期间: %s 至 %s", carID, from.Format(time.RFC3339), to.Format(time.RFC3339))
+	if !to.After(from) {
+		return nil, utils.ValidationError("统计结束时间必须晚于开始时间")
+	}
+	car, err := s.Cars.GetCarByID(ctx, carID)
+	if err != nil {
+		return nil, err
+	}
+	records, err := s.Maintenance.FindByCar(ctx, carID)
+	if err != nil {
+		s.LoggerFor(ctx).Error("获取保养记录失败: %v", err)
+		return nil, err
+	}
+	fuelRecords, err := s.Fuel.FindByCar(ctx, carID)
+	if err != nil {
+		s.LoggerFor(ctx).Error("获取加油记录失败: %v", err)
+		return nil, err
+	}
+
+	report := ComputeTCO(car, records, fuelRecords, from, to, s.Options())
+	return &report, nil
+}
+
+// Ranking 计算所有车辆在[from, to)期间的总拥有成本，按sortBy从高到低排名
+func (s *TCOService) Ranking(ctx context.Context, from, to time.Time, sortBy string) (_ []TCOReport, err error) {
+	ctx, span := tracing.Start(ctx, "TCOService.Ranking", attribute.String("tco.sort_by", sortBy))
+	defer tracing.End(span, &err)
+
+	s.LoggerFor(ctx).Info("计算车队总拥有成本排名，期间: %s 至 %s，排序: %s", from.Format(time.RFC3339), to.Format(time.RFC3339), sortBy)
+	if !to.After(from) {
+		return nil, utils.ValidationError("统计结束时间必须晚于开始时间")
+	}
+	if sortBy != TCOSortTotalCost && sortBy != TCOSortCostPerKm {
+		return nil, utils.ValidationError(fmt.Sprintf("排序字段必须是 %s 或 %s: %q", TCOSortTotalCost, TCOSortCostPerKm, sortBy))
+	}
+
+	cars, err := s.Cars.GetAllCars(ctx)
+	if err != nil {
+		return nil, err
+	}
+	records, err := s.Maintenance.FindAll(ctx)
+	if err != nil {
+		s.LoggerFor(ctx).Error("获取保养记录失败: %v", err)
+		return nil, err
+	}
+	recordsByCar := make(map[string][]MaintenanceRecord)
+	for _, record := range records {
+		recordsByCar[record.CarID] = append(recordsByCar[record.CarID], record)
+	}
+	fuelRecords, err := s.Fuel.FindAll(ctx)
+	if err != nil {
+		s.LoggerFor(ctx).Error("获取加油记录失败: %v", err)
+		return nil, err
+	}
+	fuelByCar := make(map[string][]FuelRecord)
+	for _, record := range fuelRecords {
+		fuelByCar[record.CarID] = append(fuelByCar[record.CarID], record)
+	}
+
+	options := s.Options()
+	reports := make([]TCOReport, 0, len(cars))
+	for i := range cars {
+		reports = append(reports, ComputeTCO(&cars[i], recordsByCar[cars[i].ID], fuelByCar[cars[i].ID], from, to, options))
+	}
+
+	sort.SliceStable(reports, func(i, j int) bool {
+		if sortBy == TCOSortCostPerKm {
+			// 无法估算行驶里程的车辆排在最后
+			if (reports[i].CostPerKm == 0) != (reports[j].CostPerKm == 0) {
+				return reports[j].CostPerKm == 0
+			}
+			return reports[i].CostPerKm > reports[j].CostPerKm
+		}
+		return reports[i].TotalCost > reports[j].TotalCost
+	})
+	for i := range reports {
+		reports[i].Rank = i + 1
+	}
+	return reports, nil
+}
+
+// ComputeTCO 计算车辆在[from, to)期间的总拥有成本，购车之前的时间不计入
+func ComputeTCO(car *Car, records []MaintenanceRecord, fuelRecords []FuelRecord, from, to time.Time, options TCOOptions) TCOReport {
+	report := TCOReport{
+		CarID: car.ID,
+		Brand: car.Brand,
+		Model: car.Model,
+		From:  from,
+		To:    to,
+	}
+
+	for _, record := range records {
+		if !record.Date.Before(from) && record.Date.Before(to) {
+			report.MaintenanceCost += record.Cost
+		}
+	}
+
+	owned := car.OwnedSince()
+	start := from
+	if owned.After(start) {
+		start = owned
+	}
+	if !to.After(start) {
+		report.Notes = append(report.Notes, "统计期间车辆尚未购入")
+		report.MaintenanceCost = roundTo(report.MaintenanceCost, 2)
+		report.TotalCost = report.MaintenanceCost
+		return report
+	}
+	years := yearsBetween(start, to)
+
+	// 期间内第一条到最后一条加油记录之间使用实际的加油费用，
+	// 其余时间按 行驶里程 × 百公里油耗 / 100 × 能源单价 估算，避免只记录了少数几次加油时低估燃油费用
+	report.Distance = car.AnnualMileage * years
+	var first, last time.Time
+	for _, record := range fuelRecords {
+		if record.Date.Before(start) || !record.Date.Before(to) {
+			continue
+		}
+		report.FuelCost += record.TotalCost
+		if !report.FuelLogged || record.Date.Before(first) {
+			first = record.Date
+		}
+		if !report.FuelLogged || record.Date.After(last) {
+			last = record.Date
+		}
+		report.FuelLogged = true
+	}
+	unlogged, estimated := years, "燃油费用"
+	if report.FuelLogged {
+		logged := yearsBetween(first, last)
+		report.FuelCoverage = roundTo(logged/years, 2)
+		unlogged, estimated = years-logged, "没有加油记录期间的燃油费用"
+	}
+	switch {
+	case unlogged <= 0:
+	case car.AnnualMileage <= 0:
+		report.Notes = append(report.Notes, "未填写年均行驶里程，无法估算"+estimated)
+	case car.FuelConsumption <= 0:
+		report.Notes = append(report.Notes, "未填写油耗，无法估算"+estimated)
+	default:
+		if price, ok := fuelPrice(car.FuelType, options); ok {
+			report.FuelCost += car.AnnualMileage * unlogged * car.FuelConsumption / 100 * price
+		} else {
+			report.Notes = append(report.Notes, fmt.Sprintf("未配置燃油类型 %q 的单价，无法估算%s", car.FuelType, estimated))
+		}
+	}
+	if report.FuelLogged && car.AnnualMileage <= 0 {
+		report.Notes = append(report.Notes, "未填写年均行驶里程，无法计算每公里成本")
+	}
+
+	insurance := car.AnnualInsurance
+	if insurance <= 0 {
+		insurance = options.InsurancePerYear
+	}
+	report.InsuranceCost = insurance * years
+
+	// 余额递减法：购车t年后的残值为 购车价格 × (1 - 折旧率)^t
+	if car.PurchasePrice <= 0 {
+		report.Notes = append(report.Notes, "未填写购车价格，无法计算折旧")
+	} else {
+		residual := func(at time.Time) float64 {
+			return car.PurchasePrice * math.Pow(1-options.DepreciationRate, yearsBetween(owned, at))
+		}
+		report.DepreciationCost = residual(start) - residual(to)
+	}
+
+	report.Distance = math.Round(report.Distance)
+	report.FuelCost = roundTo(report.FuelCost, 2)
+	report.MaintenanceCost = roundTo(report.MaintenanceCost, 2)
+	report.InsuranceCost = roundTo(report.InsuranceCost, 2)
+	report.DepreciationCost = roundTo(report.DepreciationCost, 2)
+	report.TotalCost = roundTo(report.FuelCost+report.MaintenanceCost+report.InsuranceCost+report.DepreciationCost, 2)
+	if report.Distance > 0 {
+		report.CostPerKm = roundTo(report.TotalCost/report.Distance, 3)
+	}
+	return report
+}
+
+// fuelPrice 查找燃油类型对应的能源单价
+func fuelPrice(fuelType string, options TCOOptions) (float64, bool) {
+	energy := fuelType
+	if mapped, ok := options.FuelTypes[fuelType]; ok {
+		energy = mapped
+	}
+	price, ok := options.FuelPrices[energy]
+	return price, ok
+}
+
+// yearsBetween 返回两个时间之间的年数，按365天计
+func yearsBetween(from, to time.Time) float64 {
+	years := to.Sub(from).Hours() / 24 / 365
+	if years < 0 {
+		return 0
+	}
+	return years
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestComputeTCOFuelCost(t *testing.T) {
+	from := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 365)
+	options := TCOOptions{FuelPrices: map[string]float64{Energy92: 8}}
+	car := Car{ID: "1", FuelType: Energy92, FuelConsumption: 8, AnnualMileage: 10000, CreatedAt: from.AddDate(-1, 0, 0)}
+
+	tests := []struct {
+		name         string
+		car          Car
+		to           time.Time // 为空时统计一年
+		fuel         []FuelRecord
+		wantCost     float64
+		wantLogged   bool
+		wantCoverage float64
+	}{
+		{name: "没有加油记录时按油耗估算", car: car, wantCost: 6400},
+		{
+			name: "有加油记录的一段使用实际费用，其余时间估算",
+			car:  car,
+			fuel: []FuelRecord{
+				{CarID: "1", Date: from.AddDate(0, 0, 73), TotalCost: 300},
+				{CarID: "1", Date: from.AddDate(0, 0, 292), TotalCost: 320.5},
+			},
+			// 加油记录覆盖0.6年，其余0.4年估算 6400 × 0.4 = 2560
+			wantCost:     3180.5,
+			wantLogged:   true,
+			wantCoverage: 0.6,
+		},
+		{
+			name:       "三年中只有一条加油记录时其余时间仍按油耗估算",
+			car:        car,
+			to:         from.AddDate(0, 0, 3*365),
+			fuel:       []FuelRecord{{CarID: "1", Date: from.AddDate(0, 1, 0), TotalCost: 300}},
+			wantCost:   300 + 3*6400,
+			wantLogged: true,
+		},
+		{
+			name:     "期间之外的加油记录不计入",
+			car:      car,
+			fuel:     []FuelRecord{{CarID: "1", Date: from.AddDate(0, -1, 0), TotalCost: 300}, {CarID: "1", Date: to, TotalCost: 300}},
+			wantCost: 6400,
+		},
+		{
+			name:       "未填写年均里程时仍可使用加油记录",
+			car:        Car{ID: "1", CreatedAt: from},
+			fuel:       []FuelRecord{{CarID: "1", Date: from.AddDate(0, 1, 0), TotalCost: 300}},
+			wantCost:   300,
+			wantLogged: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			end := to
+			if !tt.to.IsZero() {
+				end = tt.to
+			}
+			report := ComputeTCO(&tt.car, nil, tt.fuel, from, end, options)
+			if report.FuelCost != tt.wantCost {
+				t.Errorf("FuelCost = %v，期望 %v", report.FuelCost, tt.wantCost)
+			}
+			if report.FuelLogged != tt.wantLogged {
+				t.Errorf("FuelLogged = %v，期望 %v", report.FuelLogged, tt.wantLogged)
+			}
+			if report.FuelCoverage != tt.wantCoverage {
+				t.Errorf("FuelCoverage = %v，期望 %v", report.FuelCoverage, tt.wantCoverage)
+			}
+		})
+	}
+}
@@ -0,0 +1,338 @@
+package nlq
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// 可按范围筛选的数值字段
+const (
+	RangeFuelConsumption = "fuelConsumption" // 油耗(L/100km，电动车为kWh/100km)
+	RangeMileage         = "mileage"         // 行驶里程(万公里)
+	RangeAnnualMileage   = "annualMileage"   // 年均行驶里程(km)
+	RangePurchasePrice   = "purchasePrice"   // 购车价格(元)
+)
+
+// approxTolerance "左右" 表示的范围，取值上下浮动的比例
+const approxTolerance = 0.1
+
+// Range 数值字段的范围，Min和Max为空表示不限
+type Range struct {
+	Field        string   `json:"field"`                  // 数值字段
+	Min          *float64 `json:"min,omitempty"`          // 下限
+	Max          *float64 `json:"max,omitempty"`          // 上限
+	ExclusiveMin bool     `json:"exclusiveMin,omitempty"` // 是否不包含下限
+	ExclusiveMax bool     `json:"exclusiveMax,omitempty"` // 是否不包含上限
+	Text         string   `json:"text"`                   // 查询中对应的原文
+}
+
+// Contains 判断取值是否在范围内
+func (r Range) Contains(value float64) bool {
+	if r.Min != nil && (value < *r.Min || r.ExclusiveMin && value == *r.Min) {
+		return false
+	}
+	if r.Max != nil && (value > *r.Max || r.ExclusiveMax && value == *r.Max) {
+		return false
+	}
+	return true
+}
+
+// Filter 解析得到的筛选条件，同一字段的多个取值满足其一即可，不同字段需同时满足
+type Filter struct {
+	Brands              []string `json:"brands,omitempty"`              // 品牌
+	Models              []string `json:"models,omitempty"`              // 车型
+	Categories          []string `json:"categories,omitempty"`          // 车身类型
+	FuelTypes           []string `json:"fuelTypes,omitempty"`           // 燃油类型
+	UsageScenarios      []string `json:"usageScenarios,omitempty"`      // 使用场景
+	StorageEnvironments []string `json:"storageEnvironments,omitempty"` // 存放环境
+	Ranges              []Range  `json:"ranges,omitempty"`              // 数值范围，需全部满足
+	Unparsed            []string `json:"unparsed,omitempty"`            // 未能识别的内容，不参与筛选
+}
+
+// Empty 判断是否没有任何筛选条件
+func (f *Filter) Empty() bool {
+	return len(f.Brands) == 0 && len(f.Models) == 0 && len(f.Categories) == 0 && len(f.FuelTypes) == 0 &&
+		len(f.UsageScenarios) == 0 && len(f.StorageEnvironments) == 0 && len(f.Ranges) == 0
+}
+
+// add 添加字段取值，忽略重复的取值
+func (f *Filter) add(t term) {
+	var values *[]string
+	switch t.field {
+	case FieldBrand:
+		values = &f.Brands
+	case FieldModel:
+		values = &f.Models
+	case FieldCategory:
+		values = &f.Categories
+	case FieldFuelType:
+		values = &f.FuelTypes
+	case FieldUsageScenario:
+		values = &f.UsageScenarios
+	case FieldStorageEnvironment:
+		values = &f.StorageEnvironments
+	default:
+		return
+	}
+	for _, value := range *values {
+		if value == t.value {
+			return
+		}
+	}
+	*values = append(*values, t.value)
+}
+
+// 数值条件的组成部分
+const (
+	numberPattern = `(\d+(?:\.\d+)?|[零一二两三四五六七八九十百千]+)`
+	unitPattern   = `(万公里|万km|公里|千米|km|万元|万|千|元|块|升|l)?`
+	fieldPattern  = `(百公里油耗|油耗|能耗|电耗|年均行驶里程|年均里程|年里程|每年|行驶里程|里程数|里程|公里数|购车价格|购车价|价格|价钱|售价|预算)?`
+	prePattern    = `(不超过|不高于|不大于|低于|小于|少于|不到|不足|最多|至多|<=|≤|<|不低于|不少于|不小于|高于|大于|多于|超过|至少|最少|>=|≥|>)?`
+	postPattern   = `(以下|以内|之内|以上|之上|左右|上下)?`
+)
+
+// rangePattern 数值条件，如 "油耗低于7"、"里程5万公里以内"、"价格20到30万之间"
+var rangePattern = regexp.MustCompile(fieldPattern + `\s*(?:在|为|是|要|跑了|开了|跑|开)?\s*` + prePattern + `\s*` +
+	numberPattern + `\s*` + unitPattern + `\s*(?:(?:到|至|-|~|—)\s*` + numberPattern + `\s*` + unitPattern + `)?\s*` +
+	postPattern + `(?:之间)?`)
+
+// fieldKeywords 字段关键词对应的数值字段
+var fieldKeywords = map[string]string{
+	"百公里油耗": RangeFuelConsumption, "油耗": RangeFuelConsumption, "能耗": RangeFuelConsumption, "电耗": RangeFuelConsumption,
+	"年均行驶里程": RangeAnnualMileage, "年均里程": RangeAnnualMileage, "年里程": RangeAnnualMileage, "每年": RangeAnnualMileage,
+	"行驶里程": RangeMileage, "里程数": RangeMileage, "里程": RangeMileage, "公里数": RangeMileage,
+	"购车价格": RangePurchasePrice, "购车价": RangePurchasePrice, "价格": RangePurchasePrice, "价钱": RangePurchasePrice,
+	"售价": RangePurchasePrice, "预算": RangePurchasePrice,
+}
+
+// unitFields 未写字段关键词时，由单位推断的数值字段
+var unitFields = map[string]string{
+	"万公里": RangeMileage, "万km": RangeMileage, "公里": RangeMileage, "千米": RangeMileage, "km": RangeMileage,
+	"万元": RangePurchasePrice, "元": RangePurchasePrice, "块": RangePurchasePrice,
+	"升": RangeFuelConsumption, "l": RangeFuelConsumption,
+}
+
+// unitMultipliers 单位对应的倍数
+var unitMultipliers = map[string]float64{"万公里": 1e4, "万km": 1e4, "万元": 1e4, "万": 1e4, "千": 1e3}
+
+// fieldScales 数值字段的存储单位对应的倍数，未列出的字段为1；行驶里程以万公里存储
+var fieldScales = map[string]float64{RangeMileage: 1e4}
+
+// stopRunes 只由这些字组成的未识别内容视为语气和连接词，不报告为未识别
+var stopRunes = makeRuneSet("的了和与及或且在是要找想买一辆台个款些用车吧呢啊呀我请帮推荐看有能可以适合需要加油放停")
+
+// Parse 将查询解析为筛选条件
+// 先按词表识别品牌、车型等取值(优先匹配较长的词)，再从剩余内容中识别数值条件
+func Parse(query string, vocab *Vocabulary) Filter {
+	original := []rune(query)
+	text := []rune(lower(normalizeWidth(query)))
+	used := make([]bool, len(text))
+	var filter Filter
+
+	for pos := 0; pos < len(text); {
+		terms, n := vocab.match(text, pos)
+		if n == 0 {
+			pos++
+			continue
+		}
+		for _, t := range terms {
+			filter.add(t)
+		}
+		markUsed(used, pos, pos+n)
+		pos += n
+	}
+
+	// 已识别的词替换为空格，避免 "X5"、"3系" 中的数字被识别为数值条件
+	masked := make([]rune, len(text))
+	for i, r := range text {
+		if used[i] {
+			r = ' '
+		}
+		masked[i] = r
+	}
+	maskedText := string(masked)
+	for _, loc := range rangePattern.FindAllStringSubmatchIndex(maskedText, -1) {
+		r, ok := parseRange(maskedText, loc)
+		if !ok {
+			continue
+		}
+		start := len([]rune(maskedText[:loc[0]]))
+		end := start + len([]rune(maskedText[loc[0]:loc[1]]))
+		r.Text = strings.TrimSpace(string(original[start:end]))
+		filter.Ranges = append(filter.Ranges, r)
+		markUsed(used, start, end)
+	}
+
+	filter.Unparsed = unparsed(original, used)
+	return filter
+}
+
+// parseRange 根据正则匹配的分组构造数值范围，无法确定字段或比较方式时返回false
+func parseRange(text string, loc []int) (Range, bool) {
+	group := func(i int) string {
+		if loc[2*i] < 0 {
+			return ""
+		}
+		return text[loc[2*i]:loc[2*i+1]]
+	}
+	keyword, pre, post := group(1), group(2), group(7)
+	unit1, unit2 := group(4), group(6)
+
+	field := fieldKeywords[keyword]
+	if field == "" {
+		field = unitFields[unit1]
+	}
+	if field == "" {
+		field = unitFields[unit2]
+	}
+	if field == "" {
+		return Range{}, false
+	}
+
+	first, ok := parseNumber(group(3))
+	if !ok {
+		return Range{}, false
+	}
+	r := Range{Field: field}
+
+	// 区间：后一个数的单位同时作用于前一个数，如 "20到30万"
+	if group(5) != "" {
+		second, ok := parseNumber(group(5))
+		if !ok {
+			return Range{}, false
+		}
+		if unit1 == "" {
+			unit1 = unit2
+		}
+		low, high := convert(first, field, unit1), convert(second, field, unit2)
+		if low > high {
+			low, high = high, low
+		}
+		r.Min, r.Max = &low, &high
+		return r, true
+	}
+
+	value := convert(first, field, unit1)
+	switch {
+	case pre != "":
+		switch pre {
+		case "不超过", "不高于", "不大于", "最多", "至多", "<=", "≤":
+			r.Max = &value
+		case "低于", "小于", "少于", "不到", "不足", "<":
+			r.Max, r.ExclusiveMax = &value, true
+		case "不低于", "不少于", "不小于", "至少", "最少", ">=", "≥":
+			r.Min = &value
+		default:
+			r.Min, r.ExclusiveMin = &value, true
+		}
+	case post == "以下" || post == "以内" || post == "之内":
+		r.Max = &value
+	case post == "以上" || post == "之上":
+		r.Min = &value
+	case post == "左右" || post == "上下":
+		r.Min, r.Max = approx(value)
+	case keyword == "预算":
+		// "预算20万" 表示不超过20万
+		r.Max = &value
+	case keyword != "":
+		// 写了字段名但没有比较方式时按 "左右" 处理，如 "每年1万公里"
+		r.Min, r.Max = approx(value)
+	default:
+		return Range{}, false
+	}
+	return r, true
+}
+
+// approx 返回取值上下浮动approxTolerance的范围
+func approx(value float64) (*float64, *float64) {
+	low, high := round(value*(1-approxTolerance)), round(value*(1+approxTolerance))
+	return &low, &high
+}
+
+// round 保留两位小数，避免浮点误差出现在返回的范围中
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// convert 将带单位的取值换算为字段的存储单位，未写单位时视为已是存储单位
+// 如行驶里程 "5万公里" 为5，"8000公里" 为0.8
+func convert(value float64, field, unit string) float64 {
+	if unit == "" {
+		return value
+	}
+	if m, ok := unitMultipliers[unit]; ok {
+		value *= m
+	}
+	if scale, ok := fieldScales[field]; ok {
+		value /= scale
+	}
+	return value
+}
+
+// parseNumber 解析阿拉伯数字或中文数字，如 "7.5"、"十五"、"两"
+func parseNumber(s string) (float64, bool) {
+	if v, err := strconv.ParseFloat(s, 64); err == nil {
+		return v, true
+	}
+	digits := map[rune]float64{'零': 0, '一': 1, '二': 2, '两': 2, '三': 3, '四': 4, '五': 5, '六': 6, '七': 7, '八': 8, '九': 9}
+	units := map[rune]float64{'十': 10, '百': 100, '千': 1000}
+	total, current := 0.0, 0.0
+	for _, r := range s {
+		if d, ok := digits[r]; ok {
+			current = d
+			continue
+		}
+		u, ok := units[r]
+		if !ok {
+			return 0, false
+		}
+		if current == 0 {
+			current = 1
+		}
+		total += current * u
+		current = 0
+	}
+	total += current
+	return total, s != ""
+}
+
+// unparsed 返回未被识别的连续内容，忽略标点和只由语气、连接词组成的内容
+func unparsed(text []rune, used []bool) []string {
+	var result []string
+	var current []rune
+	flush := func() {
+		segment := string(current)
+		current = nil
+		if strings.IndexFunc(segment, func(r rune) bool { return !stopRunes[r] }) >= 0 {
+			result = append(result, segment)
+		}
+	}
+	for i, r := range text {
+		if used[i] || !(unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			flush()
+			continue
+		}
+		current = append(current, r)
+	}
+	flush()
+	return result
+}
+
+// markUsed 标记[start, end)范围内的字符已被识别
+func markUsed(used []bool, start, end int) {
+	for i := start; i < end; i++ {
+		used[i] = true
+	}
+}
+
+// makeRuneSet 将字符串中的字符转换为集合
+func makeRuneSet(s string) map[rune]bool {
+	set := make(map[rune]bool)
+	for _, r := range s {
+		set[r] = true
+	}
+	return set
+}
@@ -0,0 +1,107 @@
+package nlq
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// bound 返回指向取值的指针，用于构造期望的范围
+func bound(v float64) *float64 {
+	return &v
+}
+
+func TestParseRanges(t *testing.T) {
+	vocab := NewVocabulary()
+	vocab.Add(FieldBrand, "宝马")
+	vocab.Add(FieldModel, "X5")
+
+	tests := []struct {
+		name  string
+		query string
+		want  []Range // 不比较Text
+	}{
+		{"万公里按存储单位", "里程5万公里以内", []Range{{Field: RangeMileage, Max: bound(5)}}},
+		{"未写字段名时按单位推断", "5万公里以内", []Range{{Field: RangeMileage, Max: bound(5)}}},
+		{"公里换算为万公里", "里程不超过8000公里", []Range{{Field: RangeMileage, Max: bound(0.8)}}},
+		{"km换算为万公里", "里程低于20000km", []Range{{Field: RangeMileage, Max: bound(2), ExclusiveMax: true}}},
+		{"未写单位视为存储单位", "里程3以上", []Range{{Field: RangeMileage, Min: bound(3)}}},
+		{"年均里程以公里存储", "年均里程1万公里以下", []Range{{Field: RangeAnnualMileage, Max: bound(10000)}}},
+		{"没有比较方式时按左右处理", "每年1万公里", []Range{{Field: RangeAnnualMileage, Min: bound(9000), Max: bound(11000)}}},
+		{"左右", "油耗7左右", []Range{{Field: RangeFuelConsumption, Min: bound(6.3), Max: bound(7.7)}}},
+		{"不包含边界", "油耗低于7", []Range{{Field: RangeFuelConsumption, Max: bound(7), ExclusiveMax: true}}},
+		{"包含边界", "油耗不超过7", []Range{{Field: RangeFuelConsumption, Max: bound(7)}}},
+		{"大于不包含边界", "价格超过20万", []Range{{Field: RangePurchasePrice, Min: bound(200000), ExclusiveMin: true}}},
+		{"至少包含边界", "价格至少20万", []Range{{Field: RangePurchasePrice, Min: bound(200000)}}},
+		{"区间的单位作用于两个数", "价格20到30万之间", []Range{{Field: RangePurchasePrice, Min: bound(200000), Max: bound(300000)}}},
+		{"区间上下限颠倒", "里程5-3万公里", []Range{{Field: RangeMileage, Min: bound(3), Max: bound(5)}}},
+		{"预算表示上限", "预算二十万", []Range{{Field: RangePurchasePrice, Max: bound(200000)}}},
+		{"车型中的数字不是数值条件", "宝马X5", nil},
+		{"没有字段名和单位", "7以下", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := Parse(tt.query, vocab)
+			var got []Range
+			for _, r := range filter.Ranges {
+				r.Text = ""
+				got = append(got, r)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) 的数值范围 = %s，期望 %s", tt.query, formatRanges(got), formatRanges(tt.want))
+			}
+		})
+	}
+}
+
+func TestRangeContains(t *testing.T) {
+	tests := []struct {
+		name  string
+		r     Range
+		value float64
+		want  bool
+	}{
+		{"在范围内", Range{Min: bound(1), Max: bound(3)}, 2, true},
+		{"包含下限", Range{Min: bound(1)}, 1, true},
+		{"不包含下限", Range{Min: bound(1), ExclusiveMin: true}, 1, false},
+		{"包含上限", Range{Max: bound(3)}, 3, true},
+		{"不包含上限", Range{Max: bound(3), ExclusiveMax: true}, 3, false},
+		{"超出上限", Range{Max: bound(3)}, 4, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.r.Contains(tt.value); got != tt.want {
+				t.Errorf("Contains(%v) = %v，期望 %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+// formatRanges 将范围格式化为便于阅读的字符串
+func formatRanges(ranges []Range) string {
+	s := "["
+	for i, r := range ranges {
+		if i > 0 {
+			s += " "
+		}
+		s += r.Field + ":"
+		if r.Min != nil {
+			s += fmtFloat(*r.Min)
+			if r.ExclusiveMin {
+				s += "(不含)"
+			}
+		}
+		s += "~"
+		if r.Max != nil {
+			s += fmtFloat(*r.Max)
+			if r.ExclusiveMax {
+				s += "(不含)"
+			}
+		}
+	}
+	return s + "]"
+}
+
+func fmtFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
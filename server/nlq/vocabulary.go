@@ -0,0 +1,135 @@
+// Package nlq 将中文自然语言查询解析为结构化的车辆筛选条件，基于规则，不依赖模型
+package nlq
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 词表中的字段
+const (
+	FieldBrand              = "brand"              // 品牌
+	FieldModel              = "model"              // 车型
+	FieldCategory           = "category"           // 车身类型，如 SUV、轿车
+	FieldFuelType           = "fuelType"           // 燃油类型
+	FieldUsageScenario      = "usageScenario"      // 使用场景
+	FieldStorageEnvironment = "storageEnvironment" // 存放环境
+)
+
+// term 词表中的一个词在某个字段上对应的取值
+type term struct {
+	field string
+	value string
+}
+
+// Vocabulary 解析时识别的词表，一个词可以对应多个字段取值，如 "新能源" 对应电动和插电混动
+// 不可并发使用
+type Vocabulary struct {
+	terms   map[string][]term // 小写的词 -> 对应的取值
+	ordered []string          // 按长度从长到短排序的词，优先匹配较长的词
+}
+
+// NewVocabulary 创建空词表
+func NewVocabulary() *Vocabulary {
+	return &Vocabulary{terms: make(map[string][]term)}
+}
+
+// Add 添加字段取值及其同义词，取值本身也作为一个词
+// 单个字符和纯数字的词容易误匹配，不会被添加
+func (v *Vocabulary) Add(field, value string, synonyms ...string) {
+	if value == "" {
+		return
+	}
+	for _, surface := range append([]string{value}, synonyms...) {
+		surface = lower(normalizeWidth(strings.TrimSpace(surface)))
+		if utf8.RuneCountInString(surface) < 2 || isDigits(surface) {
+			continue
+		}
+		entry := term{field: field, value: value}
+		if containsTerm(v.terms[surface], entry) {
+			continue
+		}
+		if _, ok := v.terms[surface]; !ok {
+			v.ordered = nil
+		}
+		v.terms[surface] = append(v.terms[surface], entry)
+	}
+}
+
+// surfaces 返回按长度从长到短排序的词
+func (v *Vocabulary) surfaces() []string {
+	if v.ordered == nil {
+		v.ordered = make([]string, 0, len(v.terms))
+		for surface := range v.terms {
+			v.ordered = append(v.ordered, surface)
+		}
+		sort.Slice(v.ordered, func(i, j int) bool {
+			li, lj := utf8.RuneCountInString(v.ordered[i]), utf8.RuneCountInString(v.ordered[j])
+			if li != lj {
+				return li > lj
+			}
+			return v.ordered[i] < v.ordered[j]
+		})
+	}
+	return v.ordered
+}
+
+// match 返回从text[pos]开始能匹配的最长的词及其长度(字符数)，没有匹配时长度为0
+// 字母数字开头或结尾的词要求边界不是字母数字，避免 "X5" 匹配 "X50"
+func (v *Vocabulary) match(text []rune, pos int) ([]term, int) {
+	for _, surface := range v.surfaces() {
+		runes := []rune(surface)
+		end := pos + len(runes)
+		if end > len(text) || string(text[pos:end]) != surface {
+			continue
+		}
+		if isASCIIAlnum(runes[0]) && pos > 0 && isASCIIAlnum(text[pos-1]) {
+			continue
+		}
+		if isASCIIAlnum(runes[len(runes)-1]) && end < len(text) && isASCIIAlnum(text[end]) {
+			continue
+		}
+		return v.terms[surface], len(runes)
+	}
+	return nil, 0
+}
+
+// containsTerm 判断取值是否已存在
+func containsTerm(terms []term, t term) bool {
+	for _, existing := range terms {
+		if existing == t {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeWidth 将全角字母、数字和符号转换为半角，字符数不变
+func normalizeWidth(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '　':
+			return ' '
+		case r >= '！' && r <= '～':
+			return r - 0xfee0
+		}
+		return r
+	}, s)
+}
+
+// lower 逐字符转换为小写，字符数不变，保证与原文的位置一一对应
+func lower(s string) string {
+	return strings.Map(unicode.ToLower, s)
+}
+
+// isASCIIAlnum 判断是否为ASCII字母或数字
+func isASCIIAlnum(r rune) bool {
+	return r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// isDigits 判断是否全部由数字组成
+func isDigits(s string) bool {
+	return strings.IndexFunc(s, func(r rune) bool { return !unicode.IsDigit(r) }) < 0
+}
@@ -0,0 +1,120 @@
+// Package rag 提供文本向量化和向量检索，用于对车辆信息进行语义检索
+package rag
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"time"
+
+	"github.com/jasonzheng/carrag/search"
+)
+
+// 向量化方式
+const (
+	EmbedderHash = "hash" // 本地特征哈希，无需模型和网络，结果确定，适合离线运行和测试
+	EmbedderHTTP = "http" // 调用兼容OpenAI embeddings接口的模型服务，如本地部署的Ollama
+)
+
+// Embedder 将文本转换为向量
+type Embedder interface {
+	// Embed 按顺序返回每段文本的向量，向量维度相同
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+	// Name 返回向量化方式和模型名称，用于日志
+	Name() string
+}
+
+// Options 向量化配置
+type Options struct {
+	Embedder   string        // 向量化方式
+	Dimensions int           // 特征哈希的向量维度
+	Endpoint   string        // 模型服务的embeddings接口地址
+	Model      string        // 模型名称
+	APIKey     string        // 模型服务的API Key，为空时不发送
+	Timeout    time.Duration // 单次调用模型服务的超时时间
+}
+
+// NewEmbedder 按配置创建向量化实现
+func NewEmbedder(opts Options) (Embedder, error) {
+	switch opts.Embedder {
+	case "", EmbedderHash:
+		return NewHashEmbedder(opts.Dimensions), nil
+	case EmbedderHTTP:
+		if opts.Endpoint == "" {
+			return nil, fmt.Errorf("未配置模型服务地址")
+		}
+		return NewHTTPEmbedder(opts.Endpoint, opts.Model, opts.APIKey, opts.Timeout), nil
+	default:
+		return nil, fmt.Errorf("未知的向量化方式: %s", opts.Embedder)
+	}
+}
+
+// DefaultHashDimensions 特征哈希的默认向量维度
+const DefaultHashDimensions = 512
+
+// HashEmbedder 特征哈希向量化：按全文搜索的分词规则切分文本，每个词哈希到一个维度并随机取正负号，
+// 权重为 1 + ln(词频)，最后归一化。只能匹配字面上相同的词，不理解同义词
+type HashEmbedder struct {
+	dimensions int
+}
+
+// NewHashEmbedder 创建特征哈希向量化，dimensions不大于0时使用默认维度
+func NewHashEmbedder(dimensions int) *HashEmbedder {
+	if dimensions <= 0 {
+		dimensions = DefaultHashDimensions
+	}
+	return &HashEmbedder{dimensions: dimensions}
+}
+
+// Name 返回向量化方式
+func (e *HashEmbedder) Name() string {
+	return fmt.Sprintf("%s(%d)", EmbedderHash, e.dimensions)
+}
+
+// Embed 计算每段文本的特征哈希向量
+func (e *HashEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = e.embed(text)
+	}
+	return vectors, nil
+}
+
+// embed 计算单段文本的向量，不含任何词时返回零向量
+func (e *HashEmbedder) embed(text string) []float32 {
+	counts := make(map[string]int)
+	for _, token := range search.Tokenize(text) {
+		counts[token]++
+	}
+
+	vector := make([]float32, e.dimensions)
+	for token, count := range counts {
+		h := fnv.New64a()
+		h.Write([]byte(token))
+		sum := h.Sum64()
+		weight := float32(1 + math.Log(float64(count)))
+		// 低位决定维度，最高位决定正负号，减少哈希冲突带来的偏差
+		if sum>>63 == 1 {
+			weight = -weight
+		}
+		vector[sum%uint64(e.dimensions)] += weight
+	}
+	Normalize(vector)
+	return vector
+}
+
+// Normalize 将向量归一化为单位长度，零向量保持不变
+func Normalize(vector []float32) {
+	var sum float64
+	for _, v := range vector {
+		sum += float64(v) * float64(v)
+	}
+	if sum == 0 {
+		return
+	}
+	norm := float32(math.Sqrt(sum))
+	for i := range vector {
+		vector[i] /= norm
+	}
+}
@@ -0,0 +1,110 @@
+package rag
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/jasonzheng/carrag/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// maxErrorBody 模型服务返回错误时读取的响应体长度上限
+const maxErrorBody = 512
+
+// HTTPEmbedder 调用兼容OpenAI embeddings接口(POST {"model", "input": [...]})的模型服务
+type HTTPEmbedder struct {
+	endpoint string
+	model    string
+	apiKey   string
+	client   *http.Client
+}
+
+// NewHTTPEmbedder 创建调用模型服务的向量化实现，timeout不大于0时不设置超时
+func NewHTTPEmbedder(endpoint, model, apiKey string, timeout time.Duration) *HTTPEmbedder {
+	return &HTTPEmbedder{
+		endpoint: endpoint,
+		model:    model,
+		apiKey:   apiKey,
+		client:   &http.Client{Timeout: timeout},
+	}
+}
+
+// Name 返回向量化方式和模型名称
+func (e *HTTPEmbedder) Name() string {
+	return fmt.Sprintf("%s(%s)", EmbedderHTTP, e.model)
+}
+
+// embeddingRequest embeddings接口的请求体
+type embeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// embeddingResponse embeddings接口的响应体
+type embeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+// Embed 调用模型服务计算每段文本的向量，并归一化为单位长度
+func (e *HTTPEmbedder) Embed(ctx context.Context, texts []string) (_ [][]float32, err error) {
+	ctx, span := tracing.Start(ctx, "HTTPEmbedder.Embed",
+		attribute.String("rag.model", e.model),
+		attribute.Int("rag.texts", len(texts)),
+	)
+	defer tracing.End(span, &err)
+
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	body, err := json.Marshal(embeddingRequest{Model: e.model, Input: texts})
+	if err != nil {
+		return nil, fmt.Errorf("序列化向量化请求失败: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("创建向量化请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.apiKey)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("调用向量化模型服务失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return nil, fmt.Errorf("向量化模型服务返回 %d: %s", resp.StatusCode, bytes.TrimSpace(message))
+	}
+
+	var result embeddingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("解析向量化结果失败: %w", err)
+	}
+	if len(result.Data) != len(texts) {
+		return nil, fmt.Errorf("向量化结果数量不符: 请求 %d 段文本，返回 %d 个向量", len(texts), len(result.Data))
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, item := range result.Data {
+		if item.Index < 0 || item.Index >= len(texts) || vectors[item.Index] != nil {
+			return nil, fmt.Errorf("向量化结果的序号无效: %d", item.Index)
+		}
+		if len(item.Embedding) == 0 {
+			return nil, fmt.Errorf("向量化结果为空: 第 %d 段文本", item.Index)
+		}
+		Normalize(item.Embedding)
+		vectors[item.Index] = item.Embedding
+	}
+	return vectors, nil
+}
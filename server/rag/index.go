@@ -0,0 +1,114 @@
+package rag
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Match 向量检索命中的文档
+type Match struct {
+	ID    string  // 文档ID
+	Score float32 // 与查询向量的余弦相似度(-1到1)
+}
+
+// VectorIndex 内存向量索引，按余弦相似度暴力检索，可并发使用
+// 向量应已归一化，此时点积即为余弦相似度；所有向量的维度必须相同
+type VectorIndex struct {
+	mu      sync.RWMutex
+	vectors map[string][]float32
+	dim     int // 向量维度，索引为空时为0
+}
+
+// NewVectorIndex 创建空的向量索引
+func NewVectorIndex() *VectorIndex {
+	return &VectorIndex{vectors: make(map[string][]float32)}
+}
+
+// Len 返回索引中的文档数
+func (x *VectorIndex) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.vectors)
+}
+
+// Upsert 添加或替换文档的向量
+func (x *VectorIndex) Upsert(id string, vector []float32) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.dim != 0 && len(vector) != x.dim {
+		// 只有一个文档且被替换时允许改变维度
+		if _, ok := x.vectors[id]; !ok || len(x.vectors) > 1 {
+			return fmt.Errorf("向量维度不符: 索引为 %d，文档 %s 为 %d", x.dim, id, len(vector))
+		}
+	}
+	x.vectors[id] = vector
+	x.dim = len(vector)
+	return nil
+}
+
+// Remove 删除文档的向量
+func (x *VectorIndex) Remove(id string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	delete(x.vectors, id)
+	if len(x.vectors) == 0 {
+		x.dim = 0
+	}
+}
+
+// Reset 用给定的向量替换索引的全部内容，维度不一致时返回错误且不修改索引
+func (x *VectorIndex) Reset(vectors map[string][]float32) error {
+	dim := 0
+	for id, vector := range vectors {
+		if dim == 0 {
+			dim = len(vector)
+		} else if len(vector) != dim {
+			return fmt.Errorf("向量维度不符: 索引为 %d，文档 %s 为 %d", dim, id, len(vector))
+		}
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.vectors = vectors
+	x.dim = dim
+	return nil
+}
+
+// Search 返回与查询向量最相似的最多k个文档，按相似度从高到低排序，相似度不高于minScore的文档不返回
+func (x *VectorIndex) Search(query []float32, k int, minScore float32) ([]Match, error) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	if len(x.vectors) == 0 || k <= 0 {
+		return nil, nil
+	}
+	if len(query) != x.dim {
+		return nil, fmt.Errorf("查询向量维度不符: 索引为 %d，查询为 %d", x.dim, len(query))
+	}
+
+	matches := make([]Match, 0, len(x.vectors))
+	for id, vector := range x.vectors {
+		if score := dot(query, vector); score > minScore {
+			matches = append(matches, Match{ID: id, Score: score})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].ID < matches[j].ID
+	})
+	if len(matches) > k {
+		matches = matches[:k]
+	}
+	return matches, nil
+}
+
+// dot 计算两个等长向量的点积
+func dot(a, b []float32) float32 {
+	var sum float32
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/jasonzheng/carrag/models"
+	"github.com/jasonzheng/carrag/tracing"
+	"github.com/jasonzheng/carrag/utils"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// FileFuelRepository 基于文件的加油记录仓库实现，所有车辆的加油记录保存在同一个文件中
+type FileFuelRepository struct {
+	recordStore[models.FuelRecord]
+}
+
+// NewFileFuelRepository 创建新的文件加油记录仓库
+func NewFileFuelRepository(storage *utils.Storage, logger *utils.Logger, fileName string) *FileFuelRepository {
+	return &FileFuelRepository{
+		recordStore: newRecordStore(storage, logger, fileName, "加油记录", func(record *models.FuelRecord) (string, string) {
+			return record.CarID, record.ID
+		}, models.ErrFuelRecordNotFound),
+	}
+}
+
+// FindAll 获取所有车辆的加油记录
+func (r *FileFuelRepository) FindAll(ctx context.Context) (_ []models.FuelRecord, err error) {
+	ctx, span := tracing.Start(ctx, "FileFuelRepository.FindAll")
+	defer tracing.End(span, &err)
+
+	return r.findAll(ctx)
+}
+
+// FindByCar 获取车辆的所有加油记录
+func (r *FileFuelRepository) FindByCar(ctx context.Context, carID string) (_ []models.FuelRecord, err error) {
+	ctx, span := tracing.Start(ctx, "FileFuelRepository.FindByCar", attribute.String("car.id", carID))
+	defer tracing.End(span, &err)
+
+	return r.findByCar(ctx, carID)
+}
+
+// FindByID 根据ID获取车辆的加油记录
+func (r *FileFuelRepository) FindByID(ctx context.Context, carID, id string) (_ *models.FuelRecord, err error) {
+	ctx, span := tracing.Start(ctx, "FileFuelRepository.FindByID", attribute.String("fuel.id", id))
+	defer tracing.End(span, &err)
+
+	return r.findByID(ctx, carID, id)
+}
+
+// Create 创建加油记录
+func (r *FileFuelRepository) Create(ctx context.Context, record *models.FuelRecord) (err error) {
+	ctx, span := tracing.Start(ctx, "FileFuelRepository.Create", attribute.String("car.id", record.CarID))
+	defer tracing.End(span, &err)
+
+	return r.create(ctx, record)
+}
+
+// Update 更新加油记录
+func (r *FileFuelRepository) Update(ctx context.Context, record *models.FuelRecord) (err error) {
+	ctx, span := tracing.Start(ctx, "FileFuelRepository.Update", attribute.String("fuel.id", record.ID))
+	defer tracing.End(span, &err)
+
+	return r.update(ctx, record)
+}
+
+// Delete 删除加油记录
+func (r *FileFuelRepository) Delete(ctx context.Context, carID, id string) (err error) {
+	ctx, span := tracing.Start(ctx, "FileFuelRepository.Delete", attribute.String("fuel.id", id))
+	defer tracing.End(span, &err)
+
+	return r.delete(ctx, carID, id)
+}
+
+// DeleteByCar 删除车辆的所有加油记录
+func (r *FileFuelRepository) DeleteByCar(ctx context.Context, carID string) (_ int, err error) {
+	ctx, span := tracing.Start(ctx, "FileFuelRepository.DeleteByCar", attribute.String("car.id", carID))
+	defer tracing.End(span, &err)
+
+	return r.deleteByCar(ctx, carID)
+}
@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/jasonzheng/carrag/models"
+	"github.com/jasonzheng/carrag/tracing"
+	"github.com/jasonzheng/carrag/utils"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// FileMaintenanceRepository 基于文件的保养记录仓库实现，所有车辆的保养记录保存在同一个文件中
+type FileMaintenanceRepository struct {
+	recordStore[models.MaintenanceRecord]
+}
+
+// NewFileMaintenanceRepository 创建新的文件保养记录仓库
+func NewFileMaintenanceRepository(storage *utils.Storage, logger *utils.Logger, fileName string) *FileMaintenanceRepository {
+	return &FileMaintenanceRepository{
+		recordStore: newRecordStore(storage, logger, fileName, "保养记录", func(record *models.MaintenanceRecord) (string, string) {
+			return record.CarID, record.ID
+		}, models.ErrMaintenanceRecordNotFound),
+	}
+}
+
+// FindAll 获取所有车辆的保养记录
+func (r *FileMaintenanceRepository) FindAll(ctx context.Context) (_ []models.MaintenanceRecord, err error) {
+	ctx, span := tracing.Start(ctx, "FileMaintenanceRepository.FindAll")
+	defer tracing.End(span, &err)
+
+	return r.findAll(ctx)
+}
+
+// FindByCar 获取车辆的所有保养记录
+func (r *FileMaintenanceRepository) FindByCar(ctx context.Context, carID string) (_ []models.MaintenanceRecord, err error) {
+	ctx, span := tracing.Start(ctx, "FileMaintenanceRepository.FindByCar", attribute.String("car.id", carID))
+	defer tracing.End(span, &err)
+
+	return r.findByCar(ctx, carID)
+}
+
+// FindByID 根据ID获取车辆的保养记录
+func (r *FileMaintenanceRepository) FindByID(ctx context.Context, carID, id string) (_ *models.MaintenanceRecord, err error) {
+	ctx, span := tracing.Start(ctx, "FileMaintenanceRepository.FindByID", attribute.String("maintenance.id", id))
+	defer tracing.End(span, &err)
+
+	return r.findByID(ctx, carID, id)
+}
+
+// Create 创建保养记录
+func (r *FileMaintenanceRepository) Create(ctx context.Context, record *models.MaintenanceRecord) (err error) {
+	ctx, span := tracing.Start(ctx, "FileMaintenanceRepository.Create", attribute.String("car.id", record.CarID))
+	defer tracing.End(span, &err)
+
+	return r.create(ctx, record)
+}
+
+// Update 更新保养记录
+func (r *FileMaintenanceRepository) Update(ctx context.Context, record *models.MaintenanceRecord) (err error) {
+	ctx, span := tracing.Start(ctx, "FileMaintenanceRepository.Update", attribute.String("maintenance.id", record.ID))
+	defer tracing.End(span, &err)
+
+	return r.update(ctx, record)
+}
+
+// Delete 删除保养记录
+func (r *FileMaintenanceRepository) Delete(ctx context.Context, carID, id string) (err error) {
+	ctx, span := tracing.Start(ctx, "FileMaintenanceRepository.Delete", attribute.String("maintenance.id", id))
+	defer tracing.End(span, &err)
+
+	return r.delete(ctx, carID, id)
+}
+
+// DeleteByCar 删除车辆的所有保养记录
+func (r *FileMaintenanceRepository) DeleteByCar(ctx context.Context, carID string) (_ int, err error) {
+	ctx, span := tracing.Start(ctx, "FileMaintenanceRepository.DeleteByCar", attribute.String("car.id", carID))
+	defer tracing.End(span, &err)
+
+	return r.deleteByCar(ctx, carID)
+}
@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/jasonzheng/carrag/models"
+	"github.com/jasonzheng/carrag/tracing"
+	"github.com/jasonzheng/carrag/utils"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// FileOdometerRepository 基于文件的里程表读数仓库实现，所有车辆的里程表读数保存在同一个文件中
+type FileOdometerRepository struct {
+	recordStore[models.OdometerReading]
+}
+
+// NewFileOdometerRepository 创建新的文件里程表读数仓库
+func NewFileOdometerRepository(storage *utils.Storage, logger *utils.Logger, fileName string) *FileOdometerRepository {
+	return &FileOdometerRepository{
+		recordStore: newRecordStore(storage, logger, fileName, "里程表读数", func(reading *models.OdometerReading) (string, string) {
+			return reading.CarID, reading.ID
+		}, models.ErrOdometerReadingNotFound),
+	}
+}
+
+// FindByCar 获取车辆的所有里程表读数
+func (r *FileOdometerRepository) FindByCar(ctx context.Context, carID string) (_ []models.OdometerReading, err error) {
+	ctx, span := tracing.Start(ctx, "FileOdometerRepository.FindByCar", attribute.String("car.id", carID))
+	defer tracing.End(span, &err)
+
+	return r.findByCar(ctx, carID)
+}
+
+// Create 创建里程表读数
+func (r *FileOdometerRepository) Create(ctx context.Context, reading *models.OdometerReading) (err error) {
+	ctx, span := tracing.Start(ctx, "FileOdometerRepository.Create", attribute.String("car.id", reading.CarID))
+	defer tracing.End(span, &err)
+
+	return r.create(ctx, reading)
+}
+
+// Delete 删除里程表读数
+func (r *FileOdometerRepository) Delete(ctx context.Context, carID, id string) (err error) {
+	ctx, span := tracing.Start(ctx, "FileOdometerRepository.Delete", attribute.String("odometer.id", id))
+	defer tracing.End(span, &err)
+
+	return r.delete(ctx, carID, id)
+}
+
+// DeleteByCar 删除车辆的所有里程表读数
+func (r *FileOdometerRepository) DeleteByCar(ctx context.Context, carID string) (_ int, err error) {
+	ctx, span := tracing.Start(ctx, "FileOdometerRepository.DeleteByCar", attribute.String("car.id", carID))
+	defer tracing.End(span, &err)
+
+	return r.deleteByCar(ctx, carID)
+}
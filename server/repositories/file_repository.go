@@ -1,35 +1,47 @@
 package repositories
 
 import (
+	"context"
 	"fmt"
+	"sync"
 
+	"github.com/jasonzheng/carrag/metrics"
 	"github.com/jasonzheng/carrag/models"
+	"github.com/jasonzheng/carrag/tracing"
 	"github.com/jasonzheng/carrag/utils"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// scanCheckInterval 遍历车辆列表时检查请求是否已取消的间隔条数
+const scanCheckInterval = 1024
+
 // FileCarRepository 基于文件的车辆信息仓库实现
 type FileCarRepository struct {
-	Storage  *utils.Storage // 文件存储管理器
-	Logger   *utils.Logger  // 日志记录器
-	FileName string         // 数据文件名
+	Storage            *utils.Storage // 文件存储管理器
+	utils.LoggerHolder                // 日志记录器
+	FileName           string         // 数据文件名
+
+	mu sync.Mutex // 保护读取-修改-写入过程，避免并发修改相互覆盖
 }
 
 // NewFileCarRepository 创建新的文件车辆信息仓库
 func NewFileCarRepository(storage *utils.Storage, logger *utils.Logger, fileName string) *FileCarRepository {
 	return &FileCarRepository{
-		Storage:  storage,
-		Logger:   logger,
-		FileName: fileName,
+		Storage:      storage,
+		LoggerHolder: utils.LoggerHolder{Logger: logger},
+		FileName:     fileName,
 	}
 }
 
 // FindAll 获取所有车辆信息
-func (r *FileCarRepository) FindAll() ([]models.Car, error) {
-	r.Logger.Debug("从文件加载所有车辆信息: %s", r.FileName)
-	var cars []models.Car
-	err := r.Storage.LoadJSON(r.FileName, &cars)
+func (r *FileCarRepository) FindAll(ctx context.Context) (cars []models.Car, err error) {
+	ctx, span := tracing.Start(ctx, "FileCarRepository.FindAll")
+	defer tracing.End(span, &err)
+
+	r.LoggerFor(ctx).Debug("从文件加载所有车辆信息: %s", r.FileName)
+	err = r.Storage.LoadJSON(ctx, r.FileName, &cars)
 	if err != nil {
-		r.Logger.Error("加载车辆信息失败: %v", err)
+		r.LoggerFor(ctx).Error("加载车辆信息失败: %v", err)
 		return nil, fmt.Errorf("加载车辆信息失败: %w", err)
 	}
 
@@ -38,33 +50,45 @@ func (r *FileCarRepository) FindAll() ([]models.Car, error) {
 		cars = []models.Car{}
 	}
 
-	r.Logger.Debug("成功加载 %d 条车辆信息", len(cars))
+	r.LoggerFor(ctx).Debug("成功加载 %d 条车辆信息", len(cars))
 	return cars, nil
 }
 
 // FindByID 根据ID获取车辆信息
-func (r *FileCarRepository) FindByID(id string) (*models.Car, error) {
-	r.Logger.Debug("根据ID查找车辆信息: %s", id)
-	cars, err := r.FindAll()
+func (r *FileCarRepository) FindByID(ctx context.Context, id string) (_ *models.Car, err error) {
+	ctx, span := tracing.Start(ctx, "FileCarRepository.FindByID", attribute.String("car.id", id))
+	defer tracing.End(span, &err)
+
+	r.LoggerFor(ctx).Debug("根据ID查找车辆信息: %s", id)
+	cars, err := r.FindAll(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, car := range cars {
+	for i, car := range cars {
+		if err := checkCanceled(ctx, i); err != nil {
+			return nil, err
+		}
 		if car.ID == id {
-			r.Logger.Debug("找到车辆信息: %s", id)
+			r.LoggerFor(ctx).Debug("找到车辆信息: %s", id)
 			return &car, nil
 		}
 	}
 
-	r.Logger.Warning("未找到车辆信息: %s", id)
-	return nil, fmt.Errorf("车辆信息不存在: %s", id)
+	r.LoggerFor(ctx).Warning("未找到车辆信息: %s", id)
+	return nil, fmt.Errorf("%w: %s", models.ErrCarNotFound, id)
 }
 
 // Create 创建车辆信息
-func (r *FileCarRepository) Create(car *models.Car) error {
-	r.Logger.Debug("创建车辆信息: %s %s", car.Brand, car.Model)
-	cars, err := r.FindAll()
+func (r *FileCarRepository) Create(ctx context.Context, car *models.Car) (err error) {
+	ctx, span := tracing.Start(ctx, "FileCarRepository.Create")
+	defer tracing.End(span, &err)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.LoggerFor(ctx).Debug("创建车辆信息: %s %s", car.Brand, car.Model)
+	cars, err := r.FindAll(ctx)
 	if err != nil {
 		return err
 	}
@@ -73,19 +97,26 @@ func (r *FileCarRepository) Create(car *models.Car) error {
 	cars = append(cars, *car)
 
 	// 保存到文件
-	if err := r.Storage.SaveJSON(r.FileName, cars); err != nil {
-		r.Logger.Error("保存车辆信息失败: %v", err)
+	if err := r.Storage.SaveJSON(ctx, r.FileName, cars); err != nil {
+		r.LoggerFor(ctx).Error("保存车辆信息失败: %v", err)
 		return fmt.Errorf("保存车辆信息失败: %w", err)
 	}
 
-	r.Logger.Debug("成功创建车辆信息: %s", car.ID)
+	metrics.CarsTotal.Set(float64(len(cars)))
+	r.LoggerFor(ctx).Debug("成功创建车辆信息: %s", car.ID)
 	return nil
 }
 
 // Update 更新车辆信息
-func (r *FileCarRepository) Update(car *models.Car) error {
-	r.Logger.Debug("更新车辆信息: %s", car.ID)
-	cars, err := r.FindAll()
+func (r *FileCarRepository) Update(ctx context.Context, car *models.Car) (err error) {
+	ctx, span := tracing.Start(ctx, "FileCarRepository.Update", attribute.String("car.id", car.ID))
+	defer tracing.End(span, &err)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.LoggerFor(ctx).Debug("更新车辆信息: %s", car.ID)
+	cars, err := r.FindAll(ctx)
 	if err != nil {
 		return err
 	}
@@ -101,24 +132,67 @@ func (r *FileCarRepository) Update(car *models.Car) error {
 	}
 
 	if !found {
-		r.Logger.Warning("未找到要更新的车辆信息: %s", car.ID)
-		return fmt.Errorf("车辆信息不存在: %s", car.ID)
+		r.LoggerFor(ctx).Warning("未找到要更新的车辆信息: %s", car.ID)
+		return fmt.Errorf("%w: %s", models.ErrCarNotFound, car.ID)
 	}
 
 	// 保存到文件
-	if err := r.Storage.SaveJSON(r.FileName, cars); err != nil {
-		r.Logger.Error("保存车辆信息失败: %v", err)
+	if err := r.Storage.SaveJSON(ctx, r.FileName, cars); err != nil {
+		r.LoggerFor(ctx).Error("保存车辆信息失败: %v", err)
 		return fmt.Errorf("保存车辆信息失败: %w", err)
 	}
 
-	r.Logger.Debug("成功更新车辆信息: %s", car.ID)
+	r.LoggerFor(ctx).Debug("成功更新车辆信息: %s", car.ID)
+	return nil
+}
+
+// UpdateFuelConsumption 只更新车辆的油耗
+func (r *FileCarRepository) UpdateFuelConsumption(ctx context.Context, id string, consumption float64) (err error) {
+	ctx, span := tracing.Start(ctx, "FileCarRepository.UpdateFuelConsumption", attribute.String("car.id", id))
+	defer tracing.End(span, &err)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.LoggerFor(ctx).Debug("更新车辆油耗: %s", id)
+	cars, err := r.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for i := range cars {
+		if cars[i].ID == id {
+			cars[i].FuelConsumption = consumption
+			found = true
+			break
+		}
+	}
+	if !found {
+		r.LoggerFor(ctx).Warning("未找到要更新的车辆信息: %s", id)
+		return fmt.Errorf("%w: %s", models.ErrCarNotFound, id)
+	}
+
+	// 保存到文件
+	if err := r.Storage.SaveJSON(ctx, r.FileName, cars); err != nil {
+		r.LoggerFor(ctx).Error("保存车辆信息失败: %v", err)
+		return fmt.Errorf("保存车辆信息失败: %w", err)
+	}
+
+	r.LoggerFor(ctx).Debug("成功更新车辆油耗: %s", id)
 	return nil
 }
 
 // Delete 删除车辆信息
-func (r *FileCarRepository) Delete(id string) error {
-	r.Logger.Debug("删除车辆信息: %s", id)
-	cars, err := r.FindAll()
+func (r *FileCarRepository) Delete(ctx context.Context, id string) (err error) {
+	ctx, span := tracing.Start(ctx, "FileCarRepository.Delete", attribute.String("car.id", id))
+	defer tracing.End(span, &err)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.LoggerFor(ctx).Debug("删除车辆信息: %s", id)
+	cars, err := r.FindAll(ctx)
 	if err != nil {
 		return err
 	}
@@ -135,36 +209,51 @@ func (r *FileCarRepository) Delete(id string) error {
 	}
 
 	if !found {
-		r.Logger.Warning("未找到要删除的车辆信息: %s", id)
-		return fmt.Errorf("车辆信息不存在: %s", id)
+		r.LoggerFor(ctx).Warning("未找到要删除的车辆信息: %s", id)
+		return fmt.Errorf("%w: %s", models.ErrCarNotFound, id)
 	}
 
 	// 保存到文件
-	if err := r.Storage.SaveJSON(r.FileName, newCars); err != nil {
-		r.Logger.Error("保存车辆信息失败: %v", err)
+	if err := r.Storage.SaveJSON(ctx, r.FileName, newCars); err != nil {
+		r.LoggerFor(ctx).Error("保存车辆信息失败: %v", err)
 		return fmt.Errorf("保存车辆信息失败: %w", err)
 	}
 
-	r.Logger.Debug("成功删除车辆信息: %s", id)
+	metrics.CarsTotal.Set(float64(len(newCars)))
+	r.LoggerFor(ctx).Debug("成功删除车辆信息: %s", id)
 	return nil
 }
 
 // FindByBrand 根据品牌查找车辆信息
-func (r *FileCarRepository) FindByBrand(brand string) ([]models.Car, error) {
-	r.Logger.Debug("根据品牌查找车辆信息: %s", brand)
-	cars, err := r.FindAll()
+func (r *FileCarRepository) FindByBrand(ctx context.Context, brand string) (_ []models.Car, err error) {
+	ctx, span := tracing.Start(ctx, "FileCarRepository.FindByBrand", attribute.String("car.brand", brand))
+	defer tracing.End(span, &err)
+
+	r.LoggerFor(ctx).Debug("根据品牌查找车辆信息: %s", brand)
+	cars, err := r.FindAll(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	// 筛选符合条件的车辆
 	result := make([]models.Car, 0)
-	for _, car := range cars {
+	for i, car := range cars {
+		if err := checkCanceled(ctx, i); err != nil {
+			return nil, err
+		}
 		if car.Brand == brand {
 			result = append(result, car)
 		}
 	}
 
-	r.Logger.Debug("找到 %d 条符合品牌 %s 的车辆信息", len(result), brand)
+	r.LoggerFor(ctx).Debug("找到 %d 条符合品牌 %s 的车辆信息", len(result), brand)
 	return result, nil
 }
+
+// checkCanceled 每遍历scanCheckInterval条记录检查一次请求是否已取消或超时
+func checkCanceled(ctx context.Context, i int) error {
+	if i%scanCheckInterval != 0 {
+		return nil
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,212 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/jasonzheng/carrag/utils"
+)
+
+// recordStore 按车辆归属的记录的文件存储，所有车辆的记录保存在同一个JSON文件中
+// 修改记录时在互斥锁内完成读取、修改和写入，避免并发请求相互覆盖
+type recordStore[T any] struct {
+	Storage            *utils.Storage // 文件存储管理器
+	utils.LoggerHolder                // 日志记录器
+	FileName           string         // 数据文件名
+
+	kind     string                      // 记录名称，用于日志和错误信息，如"加油记录"
+	keyOf    func(*T) (carID, id string) // 返回记录所属的车辆ID和记录ID
+	notFound error                       // 记录不存在时返回的错误
+	mu       sync.Mutex                  // 保护读取-修改-写入过程
+}
+
+// newRecordStore 创建按车辆归属的记录存储
+func newRecordStore[T any](storage *utils.Storage, logger *utils.Logger, fileName, kind string, keyOf func(*T) (string, string), notFound error) recordStore[T] {
+	return recordStore[T]{
+		Storage:      storage,
+		LoggerHolder: utils.LoggerHolder{Logger: logger},
+		FileName:     fileName,
+		kind:         kind,
+		keyOf:        keyOf,
+		notFound:     notFound,
+	}
+}
+
+// loadAll 从文件加载所有记录
+func (s *recordStore[T]) loadAll(ctx context.Context) ([]T, error) {
+	var records []T
+	if err := s.Storage.LoadJSON(ctx, s.FileName, &records); err != nil {
+		s.LoggerFor(ctx).Error("加载%s失败: %v", s.kind, err)
+		return nil, fmt.Errorf("加载%s失败: %w", s.kind, err)
+	}
+	return records, nil
+}
+
+// saveAll 保存所有记录到文件
+func (s *recordStore[T]) saveAll(ctx context.Context, records []T) error {
+	if err := s.Storage.SaveJSON(ctx, s.FileName, records); err != nil {
+		s.LoggerFor(ctx).Error("保存%s失败: %v", s.kind, err)
+		return fmt.Errorf("保存%s失败: %w", s.kind, err)
+	}
+	return nil
+}
+
+// findAll 获取所有车辆的记录，没有记录时返回空切片
+func (s *recordStore[T]) findAll(ctx context.Context) ([]T, error) {
+	records, err := s.loadAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if records == nil {
+		records = []T{}
+	}
+
+	s.LoggerFor(ctx).Debug("成功加载 %d 条%s", len(records), s.kind)
+	return records, nil
+}
+
+// findByCar 获取车辆的所有记录
+func (s *recordStore[T]) findByCar(ctx context.Context, carID string) ([]T, error) {
+	records, err := s.loadAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]T, 0)
+	for i := range records {
+		if err := checkCanceled(ctx, i); err != nil {
+			return nil, err
+		}
+		if owner, _ := s.keyOf(&records[i]); owner == carID {
+			result = append(result, records[i])
+		}
+	}
+
+	s.LoggerFor(ctx).Debug("找到车辆 %s 的 %d 条%s", carID, len(result), s.kind)
+	return result, nil
+}
+
+// findByID 根据ID获取车辆的记录
+func (s *recordStore[T]) findByID(ctx context.Context, carID, id string) (*T, error) {
+	records, err := s.loadAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range records {
+		if err := checkCanceled(ctx, i); err != nil {
+			return nil, err
+		}
+		if owner, recordID := s.keyOf(&records[i]); owner == carID && recordID == id {
+			return &records[i], nil
+		}
+	}
+
+	s.LoggerFor(ctx).Warning("未找到%s: %s/%s", s.kind, carID, id)
+	return nil, fmt.Errorf("%w: %s", s.notFound, id)
+}
+
+// create 创建记录
+func (s *recordStore[T]) create(ctx context.Context, record *T) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records, err := s.loadAll(ctx)
+	if err != nil {
+		return err
+	}
+	records = append(records, *record)
+	if err := s.saveAll(ctx, records); err != nil {
+		return err
+	}
+
+	_, id := s.keyOf(record)
+	s.LoggerFor(ctx).Debug("成功创建%s: %s", s.kind, id)
+	return nil
+}
+
+// update 更新记录，记录按所属车辆ID和记录ID匹配
+func (s *recordStore[T]) update(ctx context.Context, record *T) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records, err := s.loadAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	carID, id := s.keyOf(record)
+	found := false
+	for i := range records {
+		if owner, recordID := s.keyOf(&records[i]); owner == carID && recordID == id {
+			records[i] = *record
+			found = true
+			break
+		}
+	}
+	if !found {
+		s.LoggerFor(ctx).Warning("未找到要更新的%s: %s/%s", s.kind, carID, id)
+		return fmt.Errorf("%w: %s", s.notFound, id)
+	}
+
+	if err := s.saveAll(ctx, records); err != nil {
+		return err
+	}
+
+	s.LoggerFor(ctx).Debug("成功更新%s: %s", s.kind, id)
+	return nil
+}
+
+// delete 删除车辆的记录
+func (s *recordStore[T]) delete(ctx context.Context, carID, id string) error {
+	deleted, err := s.deleteWhere(ctx, func(record *T) bool {
+		owner, recordID := s.keyOf(record)
+		return owner == carID && recordID == id
+	})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		s.LoggerFor(ctx).Warning("未找到要删除的%s: %s/%s", s.kind, carID, id)
+		return fmt.Errorf("%w: %s", s.notFound, id)
+	}
+
+	s.LoggerFor(ctx).Debug("成功删除%s: %s", s.kind, id)
+	return nil
+}
+
+// deleteByCar 删除车辆的所有记录，返回删除的条数
+func (s *recordStore[T]) deleteByCar(ctx context.Context, carID string) (int, error) {
+	return s.deleteWhere(ctx, func(record *T) bool {
+		owner, _ := s.keyOf(record)
+		return owner == carID
+	})
+}
+
+// deleteWhere 删除满足条件的记录，返回删除的条数，没有记录被删除时不写入文件
+func (s *recordStore[T]) deleteWhere(ctx context.Context, match func(*T) bool) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records, err := s.loadAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	kept := make([]T, 0, len(records))
+	for i := range records {
+		if !match(&records[i]) {
+			kept = append(kept, records[i])
+		}
+	}
+
+	deleted := len(records) - len(kept)
+	if deleted == 0 {
+		return 0, nil
+	}
+	if err := s.saveAll(ctx, kept); err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
@@ -0,0 +1,48 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/jasonzheng/carrag/models"
+	"github.com/jasonzheng/carrag/utils"
+)
+
+func TestRecordStoreConcurrentCreate(t *testing.T) {
+	ctx := context.Background()
+	logger, err := utils.NewLogger(t.TempDir(), utils.FATAL, utils.RotateOptions{})
+	if err != nil {
+		t.Fatalf("创建日志记录器失败: %v", err)
+	}
+	t.Cleanup(func() { logger.Close() })
+	storage, err := utils.NewStorage(t.TempDir(), logger)
+	if err != nil {
+		t.Fatalf("创建存储失败: %v", err)
+	}
+	repo := NewFileFuelRepository(storage, logger, "fuel.json")
+
+	// 并发写入时每条记录都应保存，不能被其他请求的读取-修改-写入覆盖
+	const writers = 20
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			record := &models.FuelRecord{ID: fmt.Sprint(i), CarID: "1", Litres: 40}
+			if err := repo.Create(ctx, record); err != nil {
+				t.Errorf("Create 失败: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	records, err := repo.FindByCar(ctx, "1")
+	if err != nil {
+		t.Fatalf("FindByCar 失败: %v", err)
+	}
+	if len(records) != writers {
+		t.Errorf("保存了 %d 条记录，期望 %d 条", len(records), writers)
+	}
+}
@@ -0,0 +1,240 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// prefixMatchFactor 前缀匹配的得分系数，低于完整匹配
+const prefixMatchFactor = 0.6
+
+// Field 待索引的字段
+type Field struct {
+	Name   string  // 字段名，搜索结果中标明匹配的字段
+	Text   string  // 字段内容
+	Boost  float64 // 字段权重，0按1计算
+	Pinyin bool    // 是否同时索引全拼和拼音首字母，适用于品牌、车型等短文本
+}
+
+// Hit 搜索命中的文档
+type Hit struct {
+	ID     string   // 文档ID
+	Score  float64  // 相关度得分
+	Fields []string // 匹配的字段，按字段名排序
+}
+
+// Index 内存倒排索引，可并发使用
+type Index struct {
+	mu       sync.RWMutex
+	postings map[string]map[string]map[string]float64 // 索引词 -> 文档ID -> 字段名 -> 权重
+	docs     map[string][]string                      // 文档ID -> 索引词，用于更新和删除文档
+	words    []string                                 // 排序后的字母数字索引词，用于前缀匹配
+}
+
+// NewIndex 创建空索引
+func NewIndex() *Index {
+	return &Index{
+		postings: make(map[string]map[string]map[string]float64),
+		docs:     make(map[string][]string),
+	}
+}
+
+// Len 返回索引中的文档数
+func (x *Index) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.docs)
+}
+
+// Add 索引文档，文档已存在时替换原有内容
+func (x *Index) Add(id string, fields ...Field) {
+	weights := analyze(fields)
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.remove(id)
+	x.add(id, weights)
+}
+
+// Remove 从索引中删除文档
+func (x *Index) Remove(id string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.remove(id)
+}
+
+// Reset 用给定的文档替换索引的全部内容
+func (x *Index) Reset(docs map[string][]Field) {
+	analyzed := make(map[string]map[string]map[string]float64, len(docs))
+	for id, fields := range docs {
+		analyzed[id] = analyze(fields)
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.postings = make(map[string]map[string]map[string]float64)
+	x.docs = make(map[string][]string, len(docs))
+	x.words = nil
+	for id, weights := range analyzed {
+		x.add(id, weights)
+	}
+}
+
+// Search 按相关度从高到低返回最多limit个匹配的文档，以及匹配的文档总数
+// 得分为各检索词的逆文档频率与字段权重之积的和，再乘以匹配的检索词占比；字母数字检索词同时前缀匹配单词和拼音
+func (x *Index) Search(query string, limit int) ([]Hit, int) {
+	terms := queryTerms(query)
+	if len(terms) == 0 || limit <= 0 {
+		return nil, 0
+	}
+
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	type match struct {
+		score  float64
+		terms  int
+		fields map[string]bool
+	}
+	matches := make(map[string]*match)
+	total := float64(len(x.docs))
+	for _, term := range terms {
+		matched := make(map[string]bool)
+		for _, candidate := range x.candidates(term) {
+			docs := x.postings[candidate]
+			idf := math.Log(1 + total/float64(len(docs)))
+			factor := 1.0
+			if candidate != term {
+				factor = prefixMatchFactor
+			}
+			for id, fields := range docs {
+				m := matches[id]
+				if m == nil {
+					m = &match{fields: make(map[string]bool)}
+					matches[id] = m
+				}
+				for field, weight := range fields {
+					m.score += idf * weight * factor
+					m.fields[field] = true
+				}
+				if !matched[id] {
+					matched[id] = true
+					m.terms++
+				}
+			}
+		}
+	}
+
+	hits := make([]Hit, 0, len(matches))
+	for id, m := range matches {
+		fields := make([]string, 0, len(m.fields))
+		for field := range m.fields {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		coverage := float64(m.terms) / float64(len(terms))
+		hits = append(hits, Hit{ID: id, Score: math.Round(m.score*coverage*1000) / 1000, Fields: fields})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID < hits[j].ID
+	})
+	matched := len(hits)
+	if matched > limit {
+		hits = hits[:limit]
+	}
+	return hits, matched
+}
+
+// candidates 返回与检索词匹配的索引词：完整匹配，字母数字检索词还包括以其为前缀的索引词
+// 调用者需持有读锁
+func (x *Index) candidates(term string) []string {
+	var result []string
+	if _, ok := x.postings[term]; ok {
+		result = append(result, term)
+	}
+	if !isASCIIWord(term) || len(term) < 2 {
+		return result
+	}
+	for i := sort.SearchStrings(x.words, term); i < len(x.words) && strings.HasPrefix(x.words[i], term); i++ {
+		if x.words[i] != term {
+			result = append(result, x.words[i])
+		}
+	}
+	return result
+}
+
+// add 将文档的索引词写入倒排表，调用者需持有写锁
+func (x *Index) add(id string, weights map[string]map[string]float64) {
+	terms := make([]string, 0, len(weights))
+	for term, fields := range weights {
+		docs := x.postings[term]
+		if docs == nil {
+			docs = make(map[string]map[string]float64)
+			x.postings[term] = docs
+			if isASCIIWord(term) {
+				i := sort.SearchStrings(x.words, term)
+				x.words = append(x.words, "")
+				copy(x.words[i+1:], x.words[i:])
+				x.words[i] = term
+			}
+		}
+		docs[id] = fields
+		terms = append(terms, term)
+	}
+	x.docs[id] = terms
+}
+
+// remove 从倒排表中删除文档，调用者需持有写锁
+func (x *Index) remove(id string) {
+	for _, term := range x.docs[id] {
+		docs := x.postings[term]
+		delete(docs, id)
+		if len(docs) > 0 {
+			continue
+		}
+		delete(x.postings, term)
+		if isASCIIWord(term) {
+			if i := sort.SearchStrings(x.words, term); i < len(x.words) && x.words[i] == term {
+				x.words = append(x.words[:i], x.words[i+1:]...)
+			}
+		}
+	}
+	delete(x.docs, id)
+}
+
+// analyze 计算文档各字段的索引词及权重：字段权重 × (1 + ln(词频))
+func analyze(fields []Field) map[string]map[string]float64 {
+	weights := make(map[string]map[string]float64)
+	for _, field := range fields {
+		if field.Text == "" {
+			continue
+		}
+		boost := field.Boost
+		if boost == 0 {
+			boost = 1
+		}
+
+		counts := make(map[string]int)
+		for _, token := range Tokenize(field.Text) {
+			counts[token]++
+		}
+		if field.Pinyin {
+			for _, term := range PinyinTerms(field.Text) {
+				counts[term]++
+			}
+		}
+
+		for term, count := range counts {
+			if weights[term] == nil {
+				weights[term] = make(map[string]float64)
+			}
+			weights[term][field.Name] += boost * (1 + math.Log(float64(count)))
+		}
+	}
+	return weights
+}
@@ -0,0 +1,71 @@
+package search
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestIndexSearchPinyin(t *testing.T) {
+	index := NewIndex()
+	for id, text := range map[string]string{
+		"1": "宝马",
+		"2": "奔驰",
+		"3": "比亚迪",
+		"4": "宝骏",
+		"5": "卡罗拉",
+	} {
+		index.Add(id, Field{Name: "brand", Text: text, Pinyin: true})
+	}
+
+	tests := []struct {
+		name   string
+		query  string
+		wantID []string // 按相关度排序的前几个结果
+	}{
+		{"拼音首字母", "bm", []string{"1"}},
+		{"全拼", "baoma", []string{"1"}},
+		{"大写拼音首字母", "BYD", []string{"3"}},
+		{"三个字的首字母", "kll", []string{"5"}},
+		{"汉字", "宝马", []string{"1"}},
+		{"拼音前缀匹配多个品牌", "bao", []string{"1", "4"}},
+		{"不匹配", "xyz", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits, total := index.Search(tt.query, 10)
+			if total != len(tt.wantID) || len(hits) != len(tt.wantID) {
+				t.Fatalf("Search(%q) 返回 %v (total=%d)，期望 %v", tt.query, hits, total, tt.wantID)
+			}
+			for i, id := range tt.wantID {
+				if hits[i].ID != id {
+					t.Errorf("Search(%q) 第%d个结果为 %s，期望 %s", tt.query, i+1, hits[i].ID, id)
+				}
+			}
+		})
+	}
+}
+
+func TestIndexSearchTotal(t *testing.T) {
+	index := NewIndex()
+	for i := 0; i < 30; i++ {
+		index.Add(fmt.Sprint(i), Field{Name: "brand", Text: "丰田", Pinyin: true})
+	}
+
+	tests := []struct {
+		limit    int
+		wantHits int
+	}{
+		{10, 10},
+		{30, 30},
+		{50, 30},
+	}
+	for _, tt := range tests {
+		hits, total := index.Search("丰田", tt.limit)
+		if len(hits) != tt.wantHits {
+			t.Errorf("limit=%d 返回 %d 条，期望 %d 条", tt.limit, len(hits), tt.wantHits)
+		}
+		if total != 30 {
+			t.Errorf("limit=%d 匹配总数 = %d，期望 30", tt.limit, total)
+		}
+	}
+}
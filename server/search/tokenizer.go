@@ -0,0 +1,163 @@
+// Package search 提供内存倒排索引，支持中文分词和拼音匹配
+package search
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/mozillazg/go-pinyin"
+)
+
+// maxPinyinCombinations 多音字读音组合的上限，超出时每个字只取第一个读音
+const maxPinyinCombinations = 16
+
+// pinyinArgs 返回所有读音、不带声调的拼音
+var pinyinArgs = pinyin.Args{Style: pinyin.Normal, Heteronym: true}
+
+// isHan 判断是否为汉字
+func isHan(r rune) bool {
+	return unicode.Is(unicode.Han, r)
+}
+
+// isWord 判断是否为字母或数字
+func isWord(r rune) bool {
+	return !isHan(r) && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// segment 将文本切分为连续的汉字串和字母数字串，字母转为小写，其他字符作为分隔符
+func segment(text string) (han [][]rune, words []string) {
+	var current []rune
+	currentHan := false
+	flush := func() {
+		if len(current) == 0 {
+			return
+		}
+		if currentHan {
+			han = append(han, current)
+		} else {
+			words = append(words, string(current))
+		}
+		current = nil
+	}
+
+	for _, r := range text {
+		switch {
+		case isHan(r):
+			if !currentHan {
+				flush()
+			}
+			currentHan = true
+			current = append(current, r)
+		case isWord(r):
+			if currentHan {
+				flush()
+			}
+			currentHan = false
+			current = append(current, unicode.ToLower(r))
+		default:
+			flush()
+		}
+	}
+	flush()
+	return han, words
+}
+
+// Tokenize 将文本切分为索引词
+// 汉字串按单字和相邻两字(二元组)切分，如 "划痕" 切分为 "划"、"痕"、"划痕"；字母数字串整体作为一个词
+func Tokenize(text string) []string {
+	han, words := segment(text)
+	tokens := words
+	for _, run := range han {
+		for i := range run {
+			tokens = append(tokens, string(run[i]))
+			if i+1 < len(run) {
+				tokens = append(tokens, string(run[i:i+2]))
+			}
+		}
+	}
+	return tokens
+}
+
+// queryTerms 将查询切分为检索词
+// 多个汉字只使用二元组，避免单字匹配到大量无关内容；单个汉字使用单字
+func queryTerms(query string) []string {
+	han, words := segment(query)
+	terms := words
+	for _, run := range han {
+		if len(run) == 1 {
+			terms = append(terms, string(run))
+			continue
+		}
+		for i := 0; i+1 < len(run); i++ {
+			terms = append(terms, string(run[i:i+2]))
+		}
+	}
+	return unique(terms)
+}
+
+// PinyinTerms 返回文本的全拼和拼音首字母，如 "宝马" 返回 "baoma" 和 "bm"，字母和数字原样保留
+// 多音字按所有读音组合，不含汉字时返回nil
+func PinyinTerms(text string) []string {
+	var readings [][]string
+	combinations := 1
+	hasHan := false
+	for _, r := range text {
+		switch {
+		case isHan(r):
+			options := pinyin.SinglePinyin(r, pinyinArgs)
+			if len(options) == 0 {
+				continue
+			}
+			hasHan = true
+			readings = append(readings, unique(options))
+			combinations *= len(readings[len(readings)-1])
+		case isWord(r):
+			readings = append(readings, []string{string(unicode.ToLower(r))})
+		}
+	}
+	if !hasHan {
+		return nil
+	}
+	if combinations > maxPinyinCombinations {
+		for i := range readings {
+			readings[i] = readings[i][:1]
+		}
+	}
+
+	full := []string{""}
+	initials := []string{""}
+	for _, options := range readings {
+		full = expand(full, options, func(s string) string { return s })
+		initials = expand(initials, options, func(s string) string { return s[:1] })
+	}
+	return unique(append(full, initials...))
+}
+
+// expand 将已有的前缀与每个读音组合
+func expand(prefixes []string, options []string, transform func(string) string) []string {
+	result := make([]string, 0, len(prefixes)*len(options))
+	for _, prefix := range prefixes {
+		for _, option := range options {
+			result = append(result, prefix+transform(option))
+		}
+	}
+	return result
+}
+
+// unique 去除重复的词，保持原有顺序
+func unique(terms []string) []string {
+	seen := make(map[string]bool, len(terms))
+	result := terms[:0]
+	for _, term := range terms {
+		if term != "" && !seen[term] {
+			seen[term] = true
+			result = append(result, term)
+		}
+	}
+	return result
+}
+
+// isASCIIWord 判断检索词是否由字母数字组成，这类词可以前缀匹配拼音和单词
+func isASCIIWord(term string) bool {
+	return strings.IndexFunc(term, func(r rune) bool { return r > unicode.MaxASCII }) < 0
+}
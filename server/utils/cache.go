@@ -0,0 +1,428 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jasonzheng/carrag/metrics"
+	"github.com/jasonzheng/carrag/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/singleflight"
+)
+
+var (
+	// ErrCacheMiss 缓存不存在
+	ErrCacheMiss = errors.New("缓存不存在")
+	// ErrCacheUnavailable 缓存后端暂时不可用，如Redis熔断期间
+	ErrCacheUnavailable = errors.New("缓存后端暂时不可用")
+)
+
+// Cache 缓存接口，由RedisCache、LRUCache和TieredCache实现
+type Cache interface {
+	// Get 获取缓存并解析到target，不存在时返回ErrCacheMiss
+	Get(ctx context.Context, key string, target interface{}) error
+	// Set 设置缓存
+	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
+	// Delete 删除缓存
+	Delete(ctx context.Context, key string) error
+	// GetWithFallback 获取缓存，未命中时使用回退函数获取数据并更新缓存
+	GetWithFallback(ctx context.Context, key string, target interface{}, fallback func(ctx context.Context) (interface{}, error), expiration time.Duration) error
+	// TaggedKey 返回附带标签当前版本号的缓存键
+	TaggedKey(ctx context.Context, key string, tags ...string) (string, error)
+	// InvalidateTags 使关联这些标签的缓存全部失效
+	InvalidateTags(ctx context.Context, tags ...string) error
+	// SetPolicy 设置缓存过期和回源策略
+	SetPolicy(policy CachePolicy)
+	// Flush 等待异步缓存更新完成
+	Flush(ctx context.Context) error
+}
+
+var (
+	_ Cache = (*RedisCache)(nil)
+	_ Cache = (*LRUCache)(nil)
+	_ Cache = (*TieredCache)(nil)
+
+	_ Evicter = (*LRUCache)(nil)
+	_ Evicter = (*TieredCache)(nil)
+)
+
+// cacheStore 缓存存储后端，保存序列化后的缓存条目和标签版本号
+type cacheStore interface {
+	load(ctx context.Context, key string) ([]byte, error) // 键不存在时返回ErrCacheMiss
+	save(ctx context.Context, key string, data []byte, ttl time.Duration) error
+	remove(ctx context.Context, key string) error
+	tagVersions(ctx context.Context, tags []string) ([]int64, error) // 不存在的标签版本号为0
+	bumpTags(ctx context.Context, tags []string) ([]int64, error)    // 递增并返回新版本号
+}
+
+// fallbackTimeout 回源加载的超时时间，回源不随单个请求取消
+const fallbackTimeout = 10 * time.Second
+
+// entryCache 基于cacheStore实现Cache接口，负责序列化、过期策略、合并回源和标签失效
+type entryCache struct {
+	store        cacheStore
+	backend      string // 后端名称，用于日志和链路追踪
+	LoggerHolder        // 日志记录器
+
+	pending    sync.WaitGroup              // 正在进行的异步缓存更新
+	policy     atomic.Pointer[CachePolicy] // 过期和回源策略，支持运行时调整
+	flights    singleflight.Group          // 合并同一键的并发回源
+	refreshing sync.Map                    // 正在后台刷新的键
+}
+
+// newEntryCache 创建基于指定存储后端的缓存
+func newEntryCache(store cacheStore, backend string, logger *Logger) *entryCache {
+	c := &entryCache{store: store, backend: backend, LoggerHolder: LoggerHolder{Logger: logger}}
+	c.SetPolicy(CachePolicy{})
+	return c
+}
+
+// SetPolicy 设置缓存过期和回源策略，对之后的读写生效
+func (c *entryCache) SetPolicy(policy CachePolicy) {
+	c.policy.Store(&policy)
+}
+
+// Policy 获取当前缓存过期和回源策略
+func (c *entryCache) Policy() CachePolicy {
+	return *c.policy.Load()
+}
+
+// Flush 等待异步缓存更新完成，超过ctx的截止时间时返回错误
+func (c *entryCache) Flush(ctx context.Context) error {
+	if err := WaitGroupContext(ctx, &c.pending); err != nil {
+		return fmt.Errorf("等待异步缓存更新完成超时: %w", err)
+	}
+	return nil
+}
+
+// logStoreError 记录缓存后端的错误，后端不可用期间每个请求都会失败，只记录调试日志，状态变化由后端自行记录
+func (c *entryCache) logStoreError(ctx context.Context, format string, err error) {
+	if errors.Is(err, ErrCacheUnavailable) {
+		c.LoggerFor(ctx).Debug(format, err)
+		return
+	}
+	c.LoggerFor(ctx).Error(format, err)
+}
+
+// startSpan 创建缓存操作的span
+func (c *entryCache) startSpan(ctx context.Context, op, key string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, "Cache."+op,
+		attribute.String("cache.backend", c.backend),
+		attribute.String("cache.key", key),
+	)
+}
+
+// Set 设置缓存
+func (c *entryCache) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) (err error) {
+	ctx, span := c.startSpan(ctx, "Set", key)
+	defer tracing.End(span, &err)
+
+	// 序列化值
+	data, err := json.Marshal(value)
+	if err != nil {
+		c.LoggerFor(ctx).Error("序列化缓存数据失败: %v", err)
+		return fmt.Errorf("序列化缓存数据失败: %w", err)
+	}
+
+	// 设置缓存
+	return c.write(ctx, key, cacheEntry{Value: data}, expiration)
+}
+
+// write 写入缓存条目，过期时间按策略随机浮动，启用stale-while-revalidate时额外保留StaleTTL
+func (c *entryCache) write(ctx context.Context, key string, entry cacheEntry, expiration time.Duration) error {
+	policy := c.Policy()
+	ttl := policy.jittered(expiration)
+	entry.FreshUntil = time.Now().Add(ttl).UnixMilli()
+	if !entry.NotFound {
+		ttl += policy.StaleTTL
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		c.LoggerFor(ctx).Error("序列化缓存数据失败: %v", err)
+		return fmt.Errorf("序列化缓存数据失败: %w", err)
+	}
+
+	if err := c.store.save(ctx, key, data, ttl); err != nil {
+		c.logStoreError(ctx, "设置缓存失败: %v", err)
+		return fmt.Errorf("设置缓存失败: %w", err)
+	}
+
+	c.LoggerFor(ctx).Debug("成功设置缓存: %s, 过期时间: %v", key, ttl)
+	return nil
+}
+
+// writeAsync 在后台写入缓存条目，不随请求取消，关闭时由Flush等待完成
+func (c *entryCache) writeAsync(ctx context.Context, key string, entry cacheEntry, expiration time.Duration) {
+	c.pending.Add(1)
+	go func() {
+		defer c.pending.Done()
+		// 不随请求结束而取消，但保留日志和追踪上下文以便关联到原请求
+		ctxTimeout, cancel := context.WithTimeout(WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+
+		if err := c.write(ctxTimeout, key, entry, expiration); err != nil {
+			c.logStoreError(ctx, "异步更新缓存失败: %v", err)
+		}
+	}()
+}
+
+// Get 获取缓存
+func (c *entryCache) Get(ctx context.Context, key string, target interface{}) (err error) {
+	ctx, span := c.startSpan(ctx, "Get", key)
+	defer func() {
+		// 缓存未命中和缓存的"不存在"结果是正常情况，不将span标记为失败
+		if errors.Is(err, ErrCacheMiss) || errors.Is(err, ErrNotFound) {
+			span.End()
+			return
+		}
+		tracing.End(span, &err)
+	}()
+
+	entry, err := c.lookup(ctx, key)
+	if err != nil {
+		return err
+	}
+	return c.decode(ctx, entry, target)
+}
+
+// lookup 读取缓存条目并记录命中情况，键不存在时返回ErrCacheMiss
+func (c *entryCache) lookup(ctx context.Context, key string) (*cacheEntry, error) {
+	data, err := c.store.load(ctx, key)
+	if err != nil {
+		if errors.Is(err, ErrCacheMiss) {
+			// 键不存在
+			metrics.CacheRequestsTotal.WithLabelValues(metrics.CacheMiss).Inc()
+			trace.SpanFromContext(ctx).SetAttributes(attribute.Bool("cache.hit", false))
+			c.LoggerFor(ctx).Debug("缓存不存在: %s", key)
+			return nil, err
+		}
+		metrics.CacheRequestsTotal.WithLabelValues(metrics.CacheError).Inc()
+		c.logStoreError(ctx, "获取缓存失败: %v", err)
+		return nil, fmt.Errorf("获取缓存失败: %w", err)
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || !entry.valid() {
+		metrics.CacheRequestsTotal.WithLabelValues(metrics.CacheError).Inc()
+		c.LoggerFor(ctx).Warning("缓存数据格式无效，视为未命中: %s", key)
+		return nil, fmt.Errorf("缓存数据格式无效: %s", key)
+	}
+
+	result := metrics.CacheHit
+	switch {
+	case entry.NotFound:
+		result = metrics.CacheNegative
+	case entry.stale(time.Now()):
+		result = metrics.CacheStale
+	}
+	metrics.CacheRequestsTotal.WithLabelValues(result).Inc()
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Bool("cache.hit", true), attribute.String("cache.result", result))
+	c.LoggerFor(ctx).Debug("成功获取缓存: %s (%s)", key, result)
+	return &entry, nil
+}
+
+// decode 将缓存条目解析到target，"不存在"条目返回满足 errors.Is(err, ErrNotFound) 的错误
+func (c *entryCache) decode(ctx context.Context, entry *cacheEntry, target interface{}) error {
+	if entry.NotFound {
+		return NotFoundError(entry.Message)
+	}
+	if err := json.Unmarshal(entry.Value, target); err != nil {
+		c.LoggerFor(ctx).Error("解析缓存数据失败: %v", err)
+		return fmt.Errorf("解析缓存数据失败: %w", err)
+	}
+	return nil
+}
+
+// Delete 删除缓存
+func (c *entryCache) Delete(ctx context.Context, key string) (err error) {
+	ctx, span := c.startSpan(ctx, "Delete", key)
+	defer tracing.End(span, &err)
+
+	if err := c.store.remove(ctx, key); err != nil {
+		c.logStoreError(ctx, "删除缓存失败: %v", err)
+		return fmt.Errorf("删除缓存失败: %w", err)
+	}
+
+	c.LoggerFor(ctx).Debug("成功删除缓存: %s", key)
+	return nil
+}
+
+// GetWithFallback 获取缓存，未命中时使用回退函数获取数据并更新缓存
+//
+// 同一键的并发未命中只执行一次回退函数，其余请求等待并共享结果；
+// 回退函数返回 ErrNotFound 时按策略缓存"不存在"的结果；
+// 启用stale-while-revalidate时，过期不超过StaleTTL的数据直接返回并在后台刷新。
+func (c *entryCache) GetWithFallback(ctx context.Context, key string, target interface{}, fallback func(ctx context.Context) (interface{}, error), expiration time.Duration) (err error) {
+	ctx, span := c.startSpan(ctx, "GetWithFallback", key)
+	defer func() {
+		// 数据不存在是正常的业务结果，不将span标记为失败
+		if errors.Is(err, ErrNotFound) {
+			span.End()
+			return
+		}
+		tracing.End(span, &err)
+	}()
+
+	// 尝试从缓存获取
+	entry, err := c.lookup(ctx, key)
+	if err == nil {
+		if !entry.stale(time.Now()) {
+			return c.decode(ctx, entry, target)
+		}
+		if c.Policy().StaleTTL > 0 {
+			// 已过新鲜期但仍在宽限期内，先返回旧数据，后台刷新
+			c.refreshAsync(ctx, key, fallback, expiration)
+			return c.decode(ctx, entry, target)
+		}
+	}
+
+	// 请求已取消或超时时不再回源
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+
+	// 缓存未命中，使用回退函数获取数据
+	data, err := c.load(ctx, key, fallback, expiration)
+	if err != nil {
+		return err
+	}
+
+	// 更新目标对象
+	if err := json.Unmarshal(data, target); err != nil {
+		c.LoggerFor(ctx).Error("解析回退数据失败: %v", err)
+		return fmt.Errorf("解析回退数据失败: %w", err)
+	}
+	return nil
+}
+
+// load 回源加载数据，同一键同时只执行一次回退函数
+// 回源使用不随请求取消的上下文，避免发起回源的请求断开导致等待中的请求一起失败；
+// 当前请求取消时不再等待结果
+func (c *entryCache) load(ctx context.Context, key string, fallback func(ctx context.Context) (interface{}, error), expiration time.Duration) (json.RawMessage, error) {
+	ch := c.flights.DoChan(key, func() (interface{}, error) {
+		loadCtx, cancel := context.WithTimeout(WithoutCancel(ctx), fallbackTimeout)
+		defer cancel()
+		return c.fetch(loadCtx, key, fallback, expiration)
+	})
+
+	select {
+	case result := <-ch:
+		metrics.CacheFallbacksTotal.WithLabelValues(strconv.FormatBool(result.Shared)).Inc()
+		if result.Shared {
+			c.LoggerFor(ctx).Debug("合并回源请求: %s", key)
+		}
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		return result.Val.(json.RawMessage), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// fetch 执行回退函数并在后台写入缓存，数据不存在时按策略写入"不存在"条目
+func (c *entryCache) fetch(ctx context.Context, key string, fallback func(ctx context.Context) (interface{}, error), expiration time.Duration) (json.RawMessage, error) {
+	data, err := fallback(ctx)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			if negativeTTL := c.Policy().NegativeTTL; negativeTTL > 0 {
+				c.writeAsync(ctx, key, cacheEntry{NotFound: true, Message: err.Error()}, negativeTTL)
+			}
+			return nil, err
+		}
+		c.LoggerFor(ctx).Error("回退函数执行失败: %v", err)
+		return nil, fmt.Errorf("回退函数执行失败: %w", err)
+	}
+
+	dataBytes, err := json.Marshal(data)
+	if err != nil {
+		c.LoggerFor(ctx).Error("序列化回退数据失败: %v", err)
+		return nil, fmt.Errorf("序列化回退数据失败: %w", err)
+	}
+
+	// 异步更新缓存
+	c.writeAsync(ctx, key, cacheEntry{Value: dataBytes}, expiration)
+	return dataBytes, nil
+}
+
+// refreshAsync 在后台回源刷新已过新鲜期的缓存，同一键同时只刷新一次
+func (c *entryCache) refreshAsync(ctx context.Context, key string, fallback func(ctx context.Context) (interface{}, error), expiration time.Duration) {
+	if _, running := c.refreshing.LoadOrStore(key, struct{}{}); running {
+		return
+	}
+
+	c.pending.Add(1)
+	go func() {
+		defer c.pending.Done()
+		defer c.refreshing.Delete(key)
+
+		refreshCtx, cancel := context.WithTimeout(WithoutCancel(ctx), fallbackTimeout)
+		defer cancel()
+		c.LoggerFor(ctx).Debug("后台刷新过期缓存: %s", key)
+		c.flights.Do(key, func() (interface{}, error) {
+			return c.fetch(refreshCtx, key, fallback, expiration)
+		})
+	}()
+}
+
+// 标签失效机制：每个标签保存一个版本号，缓存键附带所关联标签的当前版本号。
+// 标签失效时只需递增版本号，之后的读取会使用新键并回源加载，旧键不再被访问，随过期时间自动清理。
+// 数据写入完成后再递增版本号，因此写入前开始的读取即使把旧数据写入缓存，也只会写到旧键上。
+
+// TaggedKey 返回附带标签当前版本号的缓存键
+func (c *entryCache) TaggedKey(ctx context.Context, key string, tags ...string) (_ string, err error) {
+	ctx, span := c.startSpan(ctx, "TaggedKey", key)
+	defer tracing.End(span, &err)
+
+	if len(tags) == 0 {
+		return key, nil
+	}
+
+	versions, err := c.store.tagVersions(ctx, tags)
+	if err != nil {
+		c.logStoreError(ctx, "获取缓存标签版本失败: %v", err)
+		return "", fmt.Errorf("获取缓存标签版本失败: %w", err)
+	}
+
+	var b strings.Builder
+	b.WriteString(key)
+	for i, tag := range tags {
+		b.WriteString(":")
+		b.WriteString(tag)
+		b.WriteString("@")
+		b.WriteString(strconv.FormatInt(versions[i], 10))
+	}
+	return b.String(), nil
+}
+
+// InvalidateTags 递增标签版本号，使关联这些标签的缓存全部失效
+func (c *entryCache) InvalidateTags(ctx context.Context, tags ...string) (err error) {
+	ctx, span := c.startSpan(ctx, "InvalidateTags", strings.Join(tags, ","))
+	defer tracing.End(span, &err)
+
+	if len(tags) == 0 {
+		return nil
+	}
+
+	versions, err := c.store.bumpTags(ctx, tags)
+	if err != nil {
+		c.logStoreError(ctx, "使缓存标签失效失败: %v", err)
+		return fmt.Errorf("使缓存标签失效失败: %w", err)
+	}
+
+	changed := make([]string, len(tags))
+	for i, tag := range tags {
+		changed[i] = tag + "@" + strconv.FormatInt(versions[i], 10)
+	}
+	c.LoggerFor(ctx).Debug("缓存标签已失效: %s", strings.Join(changed, ", "))
+	return nil
+}
@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"encoding/json"
+	"math/rand"
+	"time"
+)
+
+// CachePolicy 缓存过期和回源策略
+type CachePolicy struct {
+	NegativeTTL time.Duration // 缓存"数据不存在"结果的时长，0表示不缓存
+	Jitter      float64       // 过期时间的随机浮动比例(0-1)，避免同时写入的大量缓存同时过期
+	StaleTTL    time.Duration // 过期后仍可返回旧数据的时长，期间在后台回源刷新，0表示不启用
+}
+
+// jittered 返回在ttl基础上随机浮动±Jitter比例的过期时间
+func (p CachePolicy) jittered(ttl time.Duration) time.Duration {
+	if p.Jitter <= 0 || ttl <= 0 {
+		return ttl
+	}
+	delta := (rand.Float64()*2 - 1) * p.Jitter * float64(ttl)
+	return ttl + time.Duration(delta)
+}
+
+// cacheEntry 缓存中保存的数据
+// Redis中的过期时间为新鲜期加上StaleTTL，新鲜期结束后的数据只在启用stale-while-revalidate时返回
+type cacheEntry struct {
+	Value      json.RawMessage `json:"v,omitempty"`  // 序列化后的数据
+	NotFound   bool            `json:"nf,omitempty"` // 数据不存在
+	Message    string          `json:"m,omitempty"`  // 数据不存在时的错误信息
+	FreshUntil int64           `json:"f"`            // 新鲜期截止时间(Unix毫秒)
+}
+
+// valid 检查条目是否为本格式写入的数据，旧格式或损坏的数据视为未命中
+func (e *cacheEntry) valid() bool {
+	return e.NotFound || len(e.Value) > 0
+}
+
+// stale 检查条目是否已过新鲜期
+func (e *cacheEntry) stale(now time.Time) bool {
+	return now.UnixMilli() >= e.FreshUntil
+}
@@ -0,0 +1,322 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestLRUCacheEviction(t *testing.T) {
+	ctx := context.Background()
+	cache := NewLRUCache(2, 0, newTestLogger(t))
+
+	for _, key := range []string{"a", "b"} {
+		if err := cache.Set(ctx, key, key, time.Minute); err != nil {
+			t.Fatalf("Set(%q) 失败: %v", key, err)
+		}
+	}
+	// 访问a后b成为最久未访问的条目，写入c时应淘汰b
+	var value string
+	if err := cache.Get(ctx, "a", &value); err != nil {
+		t.Fatalf("Get(a) 失败: %v", err)
+	}
+	if err := cache.Set(ctx, "c", "c", time.Minute); err != nil {
+		t.Fatalf("Set(c) 失败: %v", err)
+	}
+
+	tests := []struct {
+		key     string
+		wantHit bool
+	}{
+		{"a", true},
+		{"b", false},
+		{"c", true},
+	}
+	for _, tt := range tests {
+		err := cache.Get(ctx, tt.key, &value)
+		if hit := err == nil; hit != tt.wantHit {
+			t.Errorf("Get(%q) 命中=%v，期望 %v (err=%v)", tt.key, hit, tt.wantHit, err)
+		}
+		if !tt.wantHit && !errors.Is(err, ErrCacheMiss) {
+			t.Errorf("Get(%q) 错误为 %v，期望 ErrCacheMiss", tt.key, err)
+		}
+	}
+	if got := cache.Len(); got != 2 {
+		t.Errorf("Len() = %d，期望 2", got)
+	}
+}
+
+func TestLRUCacheExpiry(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		maxTTL     time.Duration
+		expiration time.Duration
+		wait       time.Duration
+		wantHit    bool
+	}{
+		{"未过期", 0, time.Minute, 0, true},
+		{"条目过期", 0, 20 * time.Millisecond, 40 * time.Millisecond, false},
+		{"maxTTL限制保留时间", 20 * time.Millisecond, time.Hour, 40 * time.Millisecond, false},
+		{"不过期的条目受maxTTL限制", 20 * time.Millisecond, 0, 40 * time.Millisecond, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewLRUCache(10, tt.maxTTL, newTestLogger(t))
+			if err := cache.Set(ctx, "key", 1, tt.expiration); err != nil {
+				t.Fatalf("Set 失败: %v", err)
+			}
+			time.Sleep(tt.wait)
+
+			var value int
+			err := cache.Get(ctx, "key", &value)
+			if hit := err == nil; hit != tt.wantHit {
+				t.Errorf("命中=%v，期望 %v (err=%v)", hit, tt.wantHit, err)
+			}
+		})
+	}
+}
+
+func TestTieredCache(t *testing.T) {
+	ctx := context.Background()
+	server, remote := newTestRedis(t)
+	cache := NewTieredCache(10, time.Minute, remote, newTestLogger(t))
+
+	t.Run("写入同时作用于两级", func(t *testing.T) {
+		if err := cache.Set(ctx, "car:1", "宝马", time.Minute); err != nil {
+			t.Fatalf("Set 失败: %v", err)
+		}
+		if !server.Exists(remote.Prefix + ":car:1") {
+			t.Errorf("Redis中不存在写入的键")
+		}
+		if cache.Len() != 1 {
+			t.Errorf("本地条目数 = %d，期望 1", cache.Len())
+		}
+	})
+
+	t.Run("本地命中时不访问Redis", func(t *testing.T) {
+		server.Del(remote.Prefix + ":car:1")
+		var value string
+		if err := cache.Get(ctx, "car:1", &value); err != nil || value != "宝马" {
+			t.Errorf("Get = %q, %v，期望 宝马", value, err)
+		}
+	})
+
+	t.Run("本地未命中时读取Redis并回填", func(t *testing.T) {
+		if err := remote.Set(ctx, "car:2", "奔驰", time.Minute); err != nil {
+			t.Fatalf("写入Redis失败: %v", err)
+		}
+		var value string
+		if err := cache.Get(ctx, "car:2", &value); err != nil || value != "奔驰" {
+			t.Fatalf("Get = %q, %v，期望 奔驰", value, err)
+		}
+		if cache.Len() != 2 {
+			t.Errorf("本地条目数 = %d，期望回填后为 2", cache.Len())
+		}
+	})
+
+	t.Run("删除同时作用于两级", func(t *testing.T) {
+		if err := cache.Delete(ctx, "car:2"); err != nil {
+			t.Fatalf("Delete 失败: %v", err)
+		}
+		var value string
+		if err := cache.Get(ctx, "car:2", &value); !errors.Is(err, ErrCacheMiss) {
+			t.Errorf("删除后 Get 错误为 %v，期望 ErrCacheMiss", err)
+		}
+	})
+
+	t.Run("标签版本号以Redis为准", func(t *testing.T) {
+		before, err := cache.TaggedKey(ctx, "cars", "brand:宝马")
+		if err != nil {
+			t.Fatalf("TaggedKey 失败: %v", err)
+		}
+		// 其他实例通过Redis使标签失效
+		if err := remote.InvalidateTags(ctx, "brand:宝马"); err != nil {
+			t.Fatalf("InvalidateTags 失败: %v", err)
+		}
+		after, err := cache.TaggedKey(ctx, "cars", "brand:宝马")
+		if err != nil {
+			t.Fatalf("TaggedKey 失败: %v", err)
+		}
+		if before == after {
+			t.Errorf("标签失效后缓存键未变化: %s", after)
+		}
+	})
+}
+
+func TestGetWithFallbackNegativeCaching(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		negTTL    time.Duration
+		wantCalls int32
+	}{
+		{"缓存不存在的结果", time.Minute, 1},
+		{"不缓存不存在的结果", 0, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewLRUCache(10, 0, newTestLogger(t))
+			cache.SetPolicy(CachePolicy{NegativeTTL: tt.negTTL})
+
+			var calls atomic.Int32
+			fallback := func(ctx context.Context) (interface{}, error) {
+				calls.Add(1)
+				return nil, NotFoundError("车辆信息不存在")
+			}
+
+			for i := 0; i < 2; i++ {
+				var value string
+				err := cache.GetWithFallback(ctx, "car:missing", &value, fallback, time.Minute)
+				if !errors.Is(err, ErrNotFound) {
+					t.Fatalf("第%d次读取错误为 %v，期望 ErrNotFound", i+1, err)
+				}
+				if err := cache.Flush(ctx); err != nil {
+					t.Fatalf("Flush 失败: %v", err)
+				}
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("回退函数执行 %d 次，期望 %d 次", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestGetWithFallbackStaleWhileRevalidate(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		staleTTL  time.Duration
+		wantFirst string // 过期后第一次读取的结果
+	}{
+		{"宽限期内返回旧数据并后台刷新", time.Minute, "v1"},
+		{"未启用时同步回源", 0, "v2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewLRUCache(10, 0, newTestLogger(t))
+			cache.SetPolicy(CachePolicy{StaleTTL: tt.staleTTL})
+
+			version := "v1"
+			fallback := func(ctx context.Context) (interface{}, error) {
+				return version, nil
+			}
+			if err := cache.Set(ctx, "key", version, 20*time.Millisecond); err != nil {
+				t.Fatalf("Set 失败: %v", err)
+			}
+			time.Sleep(40 * time.Millisecond)
+			version = "v2"
+
+			var value string
+			if err := cache.GetWithFallback(ctx, "key", &value, fallback, time.Minute); err != nil {
+				t.Fatalf("GetWithFallback 失败: %v", err)
+			}
+			if value != tt.wantFirst {
+				t.Errorf("过期后读取到 %q，期望 %q", value, tt.wantFirst)
+			}
+
+			if err := cache.Flush(ctx); err != nil {
+				t.Fatalf("Flush 失败: %v", err)
+			}
+			if err := cache.Get(ctx, "key", &value); err != nil || value != "v2" {
+				t.Errorf("刷新后读取到 %q, %v，期望 v2", value, err)
+			}
+		})
+	}
+}
+
+func TestGetWithFallbackSingleflight(t *testing.T) {
+	ctx := context.Background()
+	cache := NewLRUCache(10, 0, newTestLogger(t))
+
+	const callers = 20
+	var calls atomic.Int32
+	release := make(chan struct{})
+	fallback := func(ctx context.Context) (interface{}, error) {
+		calls.Add(1)
+		<-release
+		return "宝马", nil
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var value string
+			if err := cache.GetWithFallback(ctx, "car:1", &value, fallback, time.Minute); err != nil {
+				errs <- err
+				return
+			}
+			if value != "宝马" {
+				errs <- fmt.Errorf("读取到 %q", value)
+			}
+		}()
+	}
+	// 等待所有请求进入回源后再放行
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("回退函数执行 %d 次，期望并发请求合并为 1 次", got)
+	}
+}
+
+func TestCachePolicyJitter(t *testing.T) {
+	tests := []struct {
+		name   string
+		jitter float64
+		ttl    time.Duration
+	}{
+		{"不浮动", 0, time.Minute},
+		{"浮动10%", 0.1, time.Minute},
+		{"不过期的条目不浮动", 0.5, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := CachePolicy{Jitter: tt.jitter}
+			low := time.Duration(float64(tt.ttl) * (1 - tt.jitter))
+			high := time.Duration(float64(tt.ttl) * (1 + tt.jitter))
+			for i := 0; i < 100; i++ {
+				if got := policy.jittered(tt.ttl); got < low || got > high {
+					t.Fatalf("jittered(%v) = %v，超出范围 [%v, %v]", tt.ttl, got, low, high)
+				}
+			}
+		})
+	}
+}
+
+// newTestLogger 创建输出到临时目录的日志记录器，只输出致命错误
+func newTestLogger(t *testing.T) *Logger {
+	t.Helper()
+	logger, err := NewLogger(t.TempDir(), FATAL, RotateOptions{})
+	if err != nil {
+		t.Fatalf("创建日志记录器失败: %v", err)
+	}
+	t.Cleanup(func() { logger.Close() })
+	return logger
+}
+
+// newTestRedis 启动miniredis并创建连接到它的Redis缓存
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, *RedisCache) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	cache := NewRedisCache(server.Addr(), "", 0, "test", BreakerOptions{FailureThreshold: 3, ProbeInterval: time.Second}, newTestLogger(t))
+	t.Cleanup(func() { cache.Close() })
+	return server, cache
+}
@@ -0,0 +1,22 @@
+package utils
+
+import (
+	"context"
+	"time"
+)
+
+// detachedContext 保留父上下文中的值（日志记录器、追踪信息等），但不继承取消和截止时间
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool)         { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}               { return nil }
+func (detachedContext) Err() error                          { return nil }
+func (c detachedContext) Value(key interface{}) interface{} { return c.parent.Value(key) }
+
+// WithoutCancel 返回不随ctx取消的上下文，用于请求结束后仍需完成的操作，
+// 如写入数据后的缓存失效和异步缓存更新
+func WithoutCancel(ctx context.Context) context.Context {
+	return detachedContext{parent: ctx}
+}
@@ -0,0 +1,36 @@
+package utils
+
+import "errors"
+
+// ErrNotFound 数据不存在
+// 回退函数返回的错误满足 errors.Is(err, ErrNotFound) 时，GetWithFallback 会短暂缓存"不存在"的结果
+var ErrNotFound = errors.New("数据不存在")
+
+// NotFoundError 某类数据不存在的错误，errors.Is(err, ErrNotFound) 成立
+type NotFoundError string
+
+// Error 返回错误信息
+func (e NotFoundError) Error() string {
+	return string(e)
+}
+
+// Is 使 errors.Is(err, ErrNotFound) 成立
+func (e NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// ErrInvalid 请求数据无效
+var ErrInvalid = errors.New("数据无效")
+
+// ValidationError 数据校验失败的错误，errors.Is(err, ErrInvalid) 成立
+type ValidationError string
+
+// Error 返回错误信息
+func (e ValidationError) Error() string {
+	return string(e)
+}
+
+// Is 使 errors.Is(err, ErrInvalid) 成立
+func (e ValidationError) Is(target error) bool {
+	return target == ErrInvalid
+}
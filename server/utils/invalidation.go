@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/jasonzheng/carrag/metrics"
+)
+
+// InvalidationEvent 缓存失效事件
+type InvalidationEvent struct {
+	Origin string   `json:"origin"`         // 发布事件的实例
+	Keys   []string `json:"keys,omitempty"` // 需要删除的缓存键
+	Tags   []string `json:"tags,omitempty"` // 已失效的标签
+}
+
+// InvalidationPublisher 缓存失效事件发布器
+type InvalidationPublisher interface {
+	Publish(ctx context.Context, keys []string, tags []string) error
+}
+
+// Evicter 持有本地数据、需要响应其他实例失效事件的缓存，由LRUCache和TieredCache实现
+type Evicter interface {
+	// Evict 删除本地缓存的键，并使本地保存的标签失效
+	Evict(ctx context.Context, keys []string, tags []string)
+	// Purge 清空本地缓存，用于可能错过失效事件之后
+	Purge(ctx context.Context)
+}
+
+// Invalidator 通过Redis发布订阅在多个实例之间传播缓存失效事件
+// 每个实例写入数据后发布事件，其他实例收到后清理本地缓存中的旧数据；
+// 与Redis的连接断开期间可能错过事件，因此重新订阅成功后会清空本地缓存。
+type Invalidator struct {
+	client  *redis.Client
+	channel string // 发布订阅频道
+	origin  string // 当前实例标识，忽略自己发布的事件
+	logger  *Logger
+
+	mu     sync.Mutex
+	pubsub *redis.PubSub
+	done   chan struct{}
+}
+
+var _ InvalidationPublisher = (*Invalidator)(nil)
+
+// NewInvalidator 创建缓存失效事件的发布订阅器，频道名称带有缓存的键前缀
+func NewInvalidator(cache *RedisCache, logger *Logger) *Invalidator {
+	return &Invalidator{
+		client:  cache.Client,
+		channel: cache.Prefix + ":invalidate",
+		origin:  uuid.NewString(),
+		logger:  logger,
+	}
+}
+
+// Publish 发布缓存失效事件
+func (i *Invalidator) Publish(ctx context.Context, keys []string, tags []string) error {
+	data, err := json.Marshal(InvalidationEvent{Origin: i.origin, Keys: keys, Tags: tags})
+	if err != nil {
+		return fmt.Errorf("序列化缓存失效事件失败: %w", err)
+	}
+	if err := i.client.Publish(ctx, i.channel, data).Err(); err != nil {
+		return fmt.Errorf("发布缓存失效事件失败: %w", err)
+	}
+
+	metrics.CacheInvalidationsTotal.WithLabelValues(metrics.InvalidationPublished).Inc()
+	LoggerFromContext(ctx, i.logger).Debug("已发布缓存失效事件: keys=%v, tags=%v", keys, tags)
+	return nil
+}
+
+// Subscribe 订阅其他实例发布的缓存失效事件并作用于evicter，只能调用一次
+func (i *Invalidator) Subscribe(evicter Evicter) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.pubsub = i.client.Subscribe(context.Background(), i.channel)
+	i.done = make(chan struct{})
+	go i.receive(i.pubsub.ChannelWithSubscriptions(context.Background(), 100), evicter)
+}
+
+// receive 处理订阅收到的消息，直到订阅关闭
+func (i *Invalidator) receive(messages <-chan interface{}, evicter Evicter) {
+	defer close(i.done)
+
+	ctx := context.Background()
+	subscribed := false
+	for message := range messages {
+		switch message := message.(type) {
+		case *redis.Subscription:
+			if message.Kind != "subscribe" {
+				continue
+			}
+			if subscribed {
+				// 连接断开后重新订阅，期间的失效事件可能已丢失
+				evicter.Purge(ctx)
+				i.logger.Warning("已重新订阅缓存失效事件，清空本地缓存")
+				continue
+			}
+			subscribed = true
+			i.logger.Info("已订阅缓存失效事件: %s", i.channel)
+
+		case *redis.Message:
+			var event InvalidationEvent
+			if err := json.Unmarshal([]byte(message.Payload), &event); err != nil {
+				i.logger.Warning("缓存失效事件格式无效: %v", err)
+				continue
+			}
+			if event.Origin == i.origin {
+				continue
+			}
+			evicter.Evict(ctx, event.Keys, event.Tags)
+			metrics.CacheInvalidationsTotal.WithLabelValues(metrics.InvalidationReceived).Inc()
+			i.logger.Debug("已处理其他实例的缓存失效事件: keys=%v, tags=%v", event.Keys, event.Tags)
+		}
+	}
+}
+
+// Close 取消订阅并等待消息处理结束
+func (i *Invalidator) Close() error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.pubsub == nil {
+		return nil
+	}
+	err := i.pubsub.Close()
+	<-i.done
+	i.pubsub = nil
+	return err
+}
@@ -0,0 +1,168 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// testInstance 共享同一个Redis的服务实例，使用LRU缓存和失效事件订阅
+type testInstance struct {
+	cache       *LRUCache
+	invalidator *Invalidator
+}
+
+// newTestInstance 创建连接到server的实例并订阅失效事件
+func newTestInstance(t *testing.T, server *miniredis.Miniredis) *testInstance {
+	t.Helper()
+	logger := newTestLogger(t)
+	remote := NewRedisCache(server.Addr(), "", 0, "test", BreakerOptions{FailureThreshold: 3, ProbeInterval: time.Second}, logger)
+	instance := &testInstance{
+		cache:       NewLRUCache(10, 0, logger),
+		invalidator: NewInvalidator(remote, logger),
+	}
+	instance.invalidator.Subscribe(instance.cache)
+	t.Cleanup(func() {
+		instance.invalidator.Close()
+		remote.Close()
+	})
+	return instance
+}
+
+// has 检查实例的本地缓存中是否存在key
+func (i *testInstance) has(key string) bool {
+	var value string
+	return !errors.Is(i.cache.Get(context.Background(), key, &value), ErrCacheMiss)
+}
+
+// waitFor 等待条件成立，超时后测试失败
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("等待%s超时", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// waitSubscribed 等待所有实例完成订阅，避免之前发布的事件丢失
+func waitSubscribed(t *testing.T, server *miniredis.Miniredis, instances int) {
+	t.Helper()
+	waitFor(t, "订阅完成", func() bool {
+		return server.PubSubNumSub("test:invalidate")["test:invalidate"] == instances
+	})
+}
+
+func TestInvalidator(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		keys      []string
+		tags      []string
+		wantLocal bool // 发布者本地的键是否保留
+		wantPeer  bool // 其他实例本地的键是否保留
+	}{
+		{"发布后其他实例删除键", []string{"car:1"}, nil, true, false},
+		{"只使标签失效时保留键", nil, []string{"cars"}, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := miniredis.RunT(t)
+			a := newTestInstance(t, server)
+			b := newTestInstance(t, server)
+			waitSubscribed(t, server, 2)
+
+			for _, instance := range []*testInstance{a, b} {
+				if err := instance.cache.Set(ctx, "car:1", "宝马", time.Minute); err != nil {
+					t.Fatalf("Set 失败: %v", err)
+				}
+			}
+			tagKey, err := b.cache.TaggedKey(ctx, "cars", "cars")
+			if err != nil {
+				t.Fatalf("TaggedKey 失败: %v", err)
+			}
+
+			if err := a.invalidator.Publish(ctx, tt.keys, tt.tags); err != nil {
+				t.Fatalf("Publish 失败: %v", err)
+			}
+			// 随后发布的事件处理完成后，之前的事件一定已经处理
+			b.cache.Set(ctx, "sentinel", "", time.Minute)
+			if err := a.invalidator.Publish(ctx, []string{"sentinel"}, nil); err != nil {
+				t.Fatalf("Publish 失败: %v", err)
+			}
+			waitFor(t, "事件送达", func() bool { return !b.has("sentinel") })
+
+			if got := a.has("car:1"); got != tt.wantLocal {
+				t.Errorf("发布者本地保留键=%v，期望 %v", got, tt.wantLocal)
+			}
+			if got := b.has("car:1"); got != tt.wantPeer {
+				t.Errorf("其他实例本地保留键=%v，期望 %v", got, tt.wantPeer)
+			}
+
+			newTagKey, err := b.cache.TaggedKey(ctx, "cars", "cars")
+			if err != nil {
+				t.Fatalf("TaggedKey 失败: %v", err)
+			}
+			if changed := newTagKey != tagKey; changed != (len(tt.tags) > 0) {
+				t.Errorf("标签失效后缓存键变化=%v，期望 %v", changed, len(tt.tags) > 0)
+			}
+		})
+	}
+}
+
+func TestInvalidatorIgnoresOwnEvents(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	a := newTestInstance(t, server)
+	waitSubscribed(t, server, 1)
+
+	if err := a.cache.Set(ctx, "car:1", "宝马", time.Minute); err != nil {
+		t.Fatalf("Set 失败: %v", err)
+	}
+	tagKey, _ := a.cache.TaggedKey(ctx, "cars", "cars")
+
+	// 其他实例发布的事件，用于确认自己发布的事件已经处理完毕
+	logger := newTestLogger(t)
+	remote := NewRedisCache(server.Addr(), "", 0, "test", BreakerOptions{FailureThreshold: 3, ProbeInterval: time.Second}, logger)
+	t.Cleanup(func() { remote.Close() })
+	other := NewInvalidator(remote, logger)
+	if err := a.invalidator.Publish(ctx, []string{"car:1"}, []string{"cars"}); err != nil {
+		t.Fatalf("Publish 失败: %v", err)
+	}
+	a.cache.Set(ctx, "sentinel", "", time.Minute)
+	if err := other.Publish(ctx, []string{"sentinel"}, nil); err != nil {
+		t.Fatalf("Publish 失败: %v", err)
+	}
+	waitFor(t, "事件送达", func() bool { return !a.has("sentinel") })
+
+	if !a.has("car:1") {
+		t.Errorf("实例处理了自己发布的失效事件")
+	}
+	if newTagKey, _ := a.cache.TaggedKey(ctx, "cars", "cars"); newTagKey != tagKey {
+		t.Errorf("实例使自己发布的标签重复失效: %s -> %s", tagKey, newTagKey)
+	}
+}
+
+func TestInvalidatorPurgesOnResubscribe(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	a := newTestInstance(t, server)
+	waitSubscribed(t, server, 1)
+
+	if err := a.cache.Set(ctx, "car:1", "宝马", time.Minute); err != nil {
+		t.Fatalf("Set 失败: %v", err)
+	}
+
+	// 断开连接期间的事件可能丢失，重新订阅后应清空本地缓存
+	server.Close()
+	if err := server.Restart(); err != nil {
+		t.Fatalf("重启Redis失败: %v", err)
+	}
+	waitFor(t, "重新订阅后清空本地缓存", func() bool { return a.cache.Len() == 0 })
+}
@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// shutdownHook 关闭时执行的操作
+type shutdownHook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// Lifecycle 管理资源的关闭顺序
+type Lifecycle struct {
+	logger *Logger
+	mu     sync.Mutex
+	hooks  []shutdownHook
+}
+
+// NewLifecycle 创建生命周期管理器
+func NewLifecycle(logger *Logger) *Lifecycle {
+	return &Lifecycle{logger: logger}
+}
+
+// OnShutdown 注册关闭时执行的操作，关闭时按注册顺序依次执行
+func (l *Lifecycle) OnShutdown(name string, fn func(ctx context.Context) error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.hooks = append(l.hooks, shutdownHook{name: name, fn: fn})
+}
+
+// Shutdown 按注册顺序执行关闭操作，所有操作共享ctx的截止时间
+// 某个操作失败或超时不影响后续操作执行，返回所有错误
+func (l *Lifecycle) Shutdown(ctx context.Context) error {
+	l.mu.Lock()
+	hooks := append([]shutdownHook{}, l.hooks...)
+	l.mu.Unlock()
+
+	var errs []error
+	for _, hook := range hooks {
+		start := time.Now()
+		if err := hook.fn(ctx); err != nil {
+			l.logger.Error("关闭 %s 失败: %v", hook.name, err)
+			errs = append(errs, fmt.Errorf("%s: %w", hook.name, err))
+			continue
+		}
+		l.logger.Info("已关闭 %s，耗时 %v", hook.name, time.Since(start))
+	}
+	return errors.Join(errs...)
+}
+
+// WaitGroupContext 等待WaitGroup完成，ctx结束时提前返回ctx的错误
+func WaitGroupContext(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -1,12 +1,18 @@
 package utils
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -34,50 +40,189 @@ var levelNames = map[LogLevel]string{
 	FATAL:   "FATAL",
 }
 
-// Logger 自定义日志记录器
-type Logger struct {
-	logFile    *os.File
+// String 返回日志级别名称
+func (l LogLevel) String() string {
+	if name, ok := levelNames[l]; ok {
+		return name
+	}
+	return fmt.Sprintf("LogLevel(%d)", int(l))
+}
+
+// ParseLogLevel 解析日志级别名称，不区分大小写
+func ParseLogLevel(name string) (LogLevel, error) {
+	upper := strings.ToUpper(strings.TrimSpace(name))
+	if upper == "WARN" {
+		upper = "WARNING"
+	}
+	for level, levelName := range levelNames {
+		if levelName == upper {
+			return level, nil
+		}
+	}
+	return INFO, fmt.Errorf("未知的日志级别: %s", name)
+}
+
+// MarshalText 实现encoding.TextMarshaler，配置文件中以小写名称表示日志级别
+func (l LogLevel) MarshalText() ([]byte, error) {
+	return []byte(strings.ToLower(l.String())), nil
+}
+
+// UnmarshalText 实现encoding.TextUnmarshaler
+func (l *LogLevel) UnmarshalText(text []byte) error {
+	level, err := ParseLogLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*l = level
+	return nil
+}
+
+// LogFormat 日志输出格式
+type LogFormat string
+
+const (
+	// LogFormatText 文本格式，便于人工阅读
+	LogFormatText LogFormat = "text"
+	// LogFormatJSON JSON格式，每行一条日志，便于日志系统解析
+	LogFormatJSON LogFormat = "json"
+)
+
+// ParseLogFormat 解析日志输出格式
+func ParseLogFormat(name string) (LogFormat, error) {
+	switch format := LogFormat(strings.ToLower(strings.TrimSpace(name))); format {
+	case LogFormatText, LogFormatJSON:
+		return format, nil
+	}
+	return LogFormatText, fmt.Errorf("未知的日志格式: %s", name)
+}
+
+// UnmarshalText 实现encoding.TextUnmarshaler
+func (f *LogFormat) UnmarshalText(text []byte) error {
+	format, err := ParseLogFormat(string(text))
+	if err != nil {
+		return err
+	}
+	*f = format
+	return nil
+}
+
+// Fields 附加在日志中的结构化字段
+type Fields map[string]interface{}
+
+// loggerCore 同一日志记录器派生出的所有记录器共享的输出和配置
+type loggerCore struct {
+	logFile    *RotatingFile
+	writer     io.Writer
 	debugLog   *log.Logger
 	infoLog    *log.Logger
 	warningLog *log.Logger
 	errorLog   *log.Logger
 	fatalLog   *log.Logger
-	minLevel   LogLevel
+	minLevel   atomic.Int32 // 最低输出级别，支持运行时调整
+	format     atomic.Value // 输出格式(LogFormat)，支持运行时调整
+	mu         sync.Mutex   // 保证JSON日志逐行完整写入
 }
 
-// NewLogger 创建一个新的日志记录器
-func NewLogger(logDir string, minLevel LogLevel) (*Logger, error) {
-	// 确保日志目录存在
-	if err := os.MkdirAll(logDir, 0755); err != nil {
-		return nil, fmt.Errorf("创建日志目录失败: %w", err)
-	}
+// Logger 自定义日志记录器
+type Logger struct {
+	*loggerCore
+	fields Fields // 附加的结构化字段
+}
 
-	// 创建日志文件，使用当前日期作为文件名
-	logFileName := filepath.Join(logDir, time.Now().Format("2006-01-02")+".log")
-	logFile, err := os.OpenFile(logFileName, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+// NewLogger 创建一个新的日志记录器
+func NewLogger(logDir string, minLevel LogLevel, rotate RotateOptions) (*Logger, error) {
+	// 创建日志文件，使用当前日期作为文件名，按日期和大小轮转
+	logFile, err := OpenRotatingFile(logDir, rotate)
 	if err != nil {
-		return nil, fmt.Errorf("打开日志文件失败: %w", err)
+		return nil, err
 	}
 
 	// 创建多输出目标，同时输出到控制台和文件
 	multiWriter := io.MultiWriter(os.Stdout, logFile)
 
 	// 创建不同级别的日志记录器
-	debugLog := log.New(multiWriter, "DEBUG: ", log.Ldate|log.Ltime)
-	infoLog := log.New(multiWriter, "INFO: ", log.Ldate|log.Ltime)
-	warningLog := log.New(multiWriter, "WARNING: ", log.Ldate|log.Ltime)
-	errorLog := log.New(multiWriter, "ERROR: ", log.Ldate|log.Ltime)
-	fatalLog := log.New(multiWriter, "FATAL: ", log.Ldate|log.Ltime)
-
-	return &Logger{
+	core := &loggerCore{
 		logFile:    logFile,
-		debugLog:   debugLog,
-		infoLog:    infoLog,
-		warningLog: warningLog,
-		errorLog:   errorLog,
-		fatalLog:   fatalLog,
-		minLevel:   minLevel,
-	}, nil
+		writer:     multiWriter,
+		debugLog:   log.New(multiWriter, "DEBUG: ", log.Ldate|log.Ltime),
+		infoLog:    log.New(multiWriter, "INFO: ", log.Ldate|log.Ltime),
+		warningLog: log.New(multiWriter, "WARNING: ", log.Ldate|log.Ltime),
+		errorLog:   log.New(multiWriter, "ERROR: ", log.Ldate|log.Ltime),
+		fatalLog:   log.New(multiWriter, "FATAL: ", log.Ldate|log.Ltime),
+	}
+
+	logger := &Logger{loggerCore: core}
+	logger.SetLevel(minLevel)
+	logger.SetFormat(LogFormatText)
+	return logger, nil
+}
+
+// SetLevel 设置最低输出级别，可在运行时并发调用
+func (l *Logger) SetLevel(level LogLevel) {
+	l.minLevel.Store(int32(level))
+}
+
+// Level 获取当前最低输出级别
+func (l *Logger) Level() LogLevel {
+	return LogLevel(l.minLevel.Load())
+}
+
+// SetFormat 设置输出格式，可在运行时并发调用
+func (l *Logger) SetFormat(format LogFormat) {
+	l.format.Store(format)
+}
+
+// Format 获取当前输出格式
+func (l *Logger) Format() LogFormat {
+	return l.format.Load().(LogFormat)
+}
+
+// WithField 返回附加了一个字段的日志记录器，与原记录器共享输出和配置
+func (l *Logger) WithField(key string, value interface{}) *Logger {
+	return l.WithFields(Fields{key: value})
+}
+
+// WithFields 返回附加了多个字段的日志记录器，与原记录器共享输出和配置
+func (l *Logger) WithFields(fields Fields) *Logger {
+	merged := make(Fields, len(l.fields)+len(fields))
+	for k, v := range l.fields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return &Logger{loggerCore: l.loggerCore, fields: merged}
+}
+
+// loggerContextKey 日志记录器在context.Context中的键
+type loggerContextKey struct{}
+
+// ContextWithLogger 返回携带日志记录器的上下文，用于让请求处理链路上的日志附带请求ID等字段
+func ContextWithLogger(ctx context.Context, logger *Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, logger)
+}
+
+// LoggerFromContext 获取上下文中的日志记录器，上下文未携带时返回fallback
+func LoggerFromContext(ctx context.Context, fallback *Logger) *Logger {
+	if logger, ok := ctx.Value(loggerContextKey{}).(*Logger); ok && logger != nil {
+		return logger
+	}
+	return fallback
+}
+
+// LoggerHolder 嵌入到服务和仓库中，持有默认日志记录器，处理请求时优先使用请求上下文携带的日志记录器
+type LoggerHolder struct {
+	Logger *Logger // 默认日志记录器
+}
+
+// LoggerFor 返回上下文中的请求日志记录器，未携带时使用默认记录器
+func (h LoggerHolder) LoggerFor(ctx context.Context) *Logger {
+	return LoggerFromContext(ctx, h.Logger)
+}
+
+// Reopen 重新打开日志文件，供外部logrotate移动日志文件后调用
+func (l *Logger) Reopen() error {
+	return l.logFile.Reopen()
 }
 
 // Close 关闭日志文件
@@ -90,7 +235,7 @@ func (l *Logger) Close() error {
 
 // getCallerInfo 获取调用者信息
 func getCallerInfo() string {
-	_, file, line, ok := runtime.Caller(3) // 跳过两层调用栈
+	_, file, line, ok := runtime.Caller(3) // 跳过getCallerInfo、output和Debug等日志方法三层调用栈
 	if !ok {
 		return ""
 	}
@@ -99,48 +244,110 @@ func getCallerInfo() string {
 	return fmt.Sprintf("%s:%d", shortFile, line)
 }
 
-// formatMessage 格式化日志消息，添加调用者信息
-func formatMessage(format string, args ...interface{}) string {
-	callerInfo := getCallerInfo()
+// output 按当前格式输出一条日志
+func (l *Logger) output(level LogLevel, format string, args ...interface{}) {
+	caller := getCallerInfo()
 	message := fmt.Sprintf(format, args...)
-	if callerInfo != "" {
-		return fmt.Sprintf("%s - %s", callerInfo, message)
+
+	if l.Format() == LogFormatJSON {
+		l.writeJSON(level, caller, message)
+		return
+	}
+
+	// 文本格式：调用者 - 消息 字段=值
+	if caller != "" {
+		message = fmt.Sprintf("%s - %s", caller, message)
+	}
+	for _, key := range l.sortedFieldKeys() {
+		message += fmt.Sprintf(" %s=%v", key, l.fields[key])
+	}
+
+	switch level {
+	case DEBUG:
+		l.debugLog.Print(message)
+	case INFO:
+		l.infoLog.Print(message)
+	case WARNING:
+		l.warningLog.Print(message)
+	case ERROR:
+		l.errorLog.Print(message)
+	default:
+		l.fatalLog.Print(message)
+	}
+}
+
+// writeJSON 输出一行JSON格式的日志
+func (l *Logger) writeJSON(level LogLevel, caller, message string) {
+	entry := make(map[string]interface{}, len(l.fields)+4)
+	for k, v := range l.fields {
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		entry[k] = v
+	}
+	entry["level"] = level.String()
+	entry["time"] = time.Now().Format(time.RFC3339Nano)
+	entry["caller"] = caller
+	entry["msg"] = message
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		data, _ = json.Marshal(map[string]interface{}{
+			"level":  level.String(),
+			"time":   time.Now().Format(time.RFC3339Nano),
+			"caller": caller,
+			"msg":    message,
+			"error":  "序列化日志字段失败: " + err.Error(),
+		})
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.writer.Write(append(data, '\n'))
+}
+
+// sortedFieldKeys 返回按名称排序的字段名，保证文本日志中字段顺序稳定
+func (l *Logger) sortedFieldKeys() []string {
+	keys := make([]string, 0, len(l.fields))
+	for key := range l.fields {
+		keys = append(keys, key)
 	}
-	return message
+	sort.Strings(keys)
+	return keys
 }
 
 // Debug 记录调试级别日志
 func (l *Logger) Debug(format string, args ...interface{}) {
-	if l.minLevel <= DEBUG {
-		l.debugLog.Printf(formatMessage(format, args...))
+	if l.Level() <= DEBUG {
+		l.output(DEBUG, format, args...)
 	}
 }
 
 // Info 记录信息级别日志
 func (l *Logger) Info(format string, args ...interface{}) {
-	if l.minLevel <= INFO {
-		l.infoLog.Printf(formatMessage(format, args...))
+	if l.Level() <= INFO {
+		l.output(INFO, format, args...)
 	}
 }
 
 // Warning 记录警告级别日志
 func (l *Logger) Warning(format string, args ...interface{}) {
-	if l.minLevel <= WARNING {
-		l.warningLog.Printf(formatMessage(format, args...))
+	if l.Level() <= WARNING {
+		l.output(WARNING, format, args...)
 	}
 }
 
 // Error 记录错误级别日志
 func (l *Logger) Error(format string, args ...interface{}) {
-	if l.minLevel <= ERROR {
-		l.errorLog.Printf(formatMessage(format, args...))
+	if l.Level() <= ERROR {
+		l.output(ERROR, format, args...)
 	}
 }
 
 // Fatal 记录致命错误级别日志并退出程序
 func (l *Logger) Fatal(format string, args ...interface{}) {
-	if l.minLevel <= FATAL {
-		l.fatalLog.Printf(formatMessage(format, args...))
+	if l.Level() <= FATAL {
+		l.output(FATAL, format, args...)
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,36 @@
+//go:build !windows
+
+package utils
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// ReopenOnSignal 收到SIGUSR1时重新打开日志文件，配合外部logrotate使用，返回停止监听的函数
+func (l *Logger) ReopenOnSignal() func() {
+	usr1 := make(chan os.Signal, 1)
+	signal.Notify(usr1, syscall.SIGUSR1)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-usr1:
+				if err := l.Reopen(); err != nil {
+					l.Error("重新打开日志文件失败: %v", err)
+					continue
+				}
+				l.Info("收到SIGUSR1信号，已重新打开日志文件")
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(usr1)
+		close(done)
+	}
+}
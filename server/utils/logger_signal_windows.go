@@ -0,0 +1,8 @@
+//go:build windows
+
+package utils
+
+// ReopenOnSignal Windows不支持SIGUSR1，不做任何处理
+func (l *Logger) ReopenOnSignal() func() {
+	return func() {}
+}
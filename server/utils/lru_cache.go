@@ -0,0 +1,172 @@
+package utils
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// LRUCache 进程内缓存，按条目数和过期时间限制占用，Redis不可用时仍可提供缓存
+type LRUCache struct {
+	*entryCache
+
+	lru *lruStore
+}
+
+// NewLRUCache 创建进程内缓存，maxEntries为最大条目数，maxTTL大于0时限制单个条目的最长保留时间
+func NewLRUCache(maxEntries int, maxTTL time.Duration, logger *Logger) *LRUCache {
+	store := newLRUStore(maxEntries, maxTTL)
+	return &LRUCache{
+		entryCache: newEntryCache(store, "lru", logger),
+		lru:        store,
+	}
+}
+
+// Len 返回当前缓存的条目数
+func (c *LRUCache) Len() int {
+	return c.lru.len()
+}
+
+// Evict 删除其他实例已失效的缓存键，并递增本地保存的标签版本号
+func (c *LRUCache) Evict(ctx context.Context, keys []string, tags []string) {
+	for _, key := range keys {
+		c.lru.remove(ctx, key)
+	}
+	if len(tags) > 0 {
+		c.lru.bumpTags(ctx, tags)
+	}
+}
+
+// Purge 清空缓存
+func (c *LRUCache) Purge(ctx context.Context) {
+	c.lru.purge()
+}
+
+// lruItem LRU缓存条目
+type lruItem struct {
+	key      string
+	data     []byte
+	expireAt time.Time // 零值表示不过期
+}
+
+// lruStore 基于最近最少使用淘汰策略的进程内缓存存储
+type lruStore struct {
+	maxEntries int
+	maxTTL     time.Duration
+
+	mu    sync.Mutex
+	items map[string]*list.Element // 键到链表节点的映射
+	order *list.List               // 按最近访问排序，队首为最近访问
+	tags  map[string]int64         // 标签版本号
+}
+
+// newLRUStore 创建进程内缓存存储
+func newLRUStore(maxEntries int, maxTTL time.Duration) *lruStore {
+	return &lruStore{
+		maxEntries: maxEntries,
+		maxTTL:     maxTTL,
+		items:      make(map[string]*list.Element),
+		order:      list.New(),
+		tags:       make(map[string]int64),
+	}
+}
+
+func (s *lruStore) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.order.Len()
+}
+
+func (s *lruStore) load(ctx context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	element, ok := s.items[key]
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+	item := element.Value.(*lruItem)
+	if !item.expireAt.IsZero() && time.Now().After(item.expireAt) {
+		s.removeElement(element)
+		return nil, ErrCacheMiss
+	}
+	s.order.MoveToFront(element)
+	return item.data, nil
+}
+
+func (s *lruStore) save(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	if s.maxTTL > 0 && (ttl <= 0 || ttl > s.maxTTL) {
+		ttl = s.maxTTL
+	}
+	var expireAt time.Time
+	if ttl > 0 {
+		expireAt = time.Now().Add(ttl)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if element, ok := s.items[key]; ok {
+		item := element.Value.(*lruItem)
+		item.data = data
+		item.expireAt = expireAt
+		s.order.MoveToFront(element)
+		return nil
+	}
+
+	s.items[key] = s.order.PushFront(&lruItem{key: key, data: data, expireAt: expireAt})
+	// 超出容量时淘汰最久未访问的条目
+	for s.maxEntries > 0 && s.order.Len() > s.maxEntries {
+		s.removeElement(s.order.Back())
+	}
+	return nil
+}
+
+func (s *lruStore) remove(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if element, ok := s.items[key]; ok {
+		s.removeElement(element)
+	}
+	return nil
+}
+
+// purge 删除所有条目，标签版本号保持不变
+func (s *lruStore) purge() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.items = make(map[string]*list.Element)
+	s.order.Init()
+}
+
+// removeElement 删除链表节点，调用者需持有锁
+func (s *lruStore) removeElement(element *list.Element) {
+	s.order.Remove(element)
+	delete(s.items, element.Value.(*lruItem).key)
+}
+
+func (s *lruStore) tagVersions(ctx context.Context, tags []string) ([]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	versions := make([]int64, len(tags))
+	for i, tag := range tags {
+		versions[i] = s.tags[tag]
+	}
+	return versions, nil
+}
+
+func (s *lruStore) bumpTags(ctx context.Context, tags []string) ([]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	versions := make([]int64, len(tags))
+	for i, tag := range tags {
+		s.tags[tag]++
+		versions[i] = s.tags[tag]
+	}
+	return versions, nil
+}
@@ -2,8 +2,8 @@ package utils
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -11,13 +11,19 @@ import (
 
 // RedisCache Redis缓存管理器
 type RedisCache struct {
+	*entryCache
+
 	Client *redis.Client // Redis客户端
 	Logger *Logger       // 日志记录器
 	Prefix string        // 键前缀，用于区分不同应用的缓存
+
+	redis   *redisStore
+	breaker *redisBreaker
 }
 
 // NewRedisCache 创建新的Redis缓存管理器
-func NewRedisCache(addr string, password string, db int, prefix string, logger *Logger) (*RedisCache, error) {
+// 启动时连接失败不会返回错误，缓存以熔断状态启动，由后台协程定期重连
+func NewRedisCache(addr string, password string, db int, prefix string, breaker BreakerOptions, logger *Logger) *RedisCache {
 	// 创建Redis客户端
 	client := redis.NewClient(&redis.Options{
 		Addr:         addr,            // Redis服务器地址
@@ -29,108 +35,60 @@ func NewRedisCache(addr string, password string, db int, prefix string, logger *
 		PoolSize:     10,              // 连接池大小
 		MinIdleConns: 2,               // 最小空闲连接数
 	})
+	store := &redisStore{client: client, prefix: prefix}
+	cache := &RedisCache{
+		entryCache: newEntryCache(store, "redis", logger),
+		Client:     client,
+		Logger:     logger,
+		Prefix:     prefix,
+		redis:      store,
+		breaker:    newRedisBreaker(client, breaker, logger),
+	}
 
 	// 测试连接
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), probeKey{}, true), 5*time.Second)
 	defer cancel()
 
 	pong, err := client.Ping(ctx).Result()
 	if err != nil {
-		return nil, fmt.Errorf("Redis连接失败: %w", err)
+		cache.breaker.trip(fmt.Errorf("Redis连接失败: %w", err))
+	} else {
+		logger.Info("Redis连接成功: %s", pong)
 	}
-
-	logger.Info("Redis连接成功: %s", pong)
-
-	return &RedisCache{
-		Client: client,
-		Logger: logger,
-		Prefix: prefix,
-	}, nil
+	cache.breaker.start()
+	return cache
 }
 
-// Close 关闭Redis连接
+// Close 停止重连并关闭Redis连接
 func (r *RedisCache) Close() error {
+	r.breaker.close()
 	if r.Client != nil {
 		return r.Client.Close()
 	}
 	return nil
 }
 
-// formatKey 格式化缓存键名
-func (r *RedisCache) formatKey(key string) string {
-	return fmt.Sprintf("%s:%s", r.Prefix, key)
+// Status 返回Redis连接和熔断器状态
+func (r *RedisCache) Status() RedisStatus {
+	return r.breaker.status()
 }
 
-// Set 设置缓存
-func (r *RedisCache) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
-	// 序列化值
-	data, err := json.Marshal(value)
-	if err != nil {
-		r.Logger.Error("序列化缓存数据失败: %v", err)
-		return fmt.Errorf("序列化缓存数据失败: %w", err)
-	}
-
-	// 设置缓存
-	formattedKey := r.formatKey(key)
-	err = r.Client.Set(ctx, formattedKey, data, expiration).Err()
-	if err != nil {
-		r.Logger.Error("设置缓存失败: %v", err)
-		return fmt.Errorf("设置缓存失败: %w", err)
-	}
-
-	r.Logger.Debug("成功设置缓存: %s, 过期时间: %v", formattedKey, expiration)
-	return nil
-}
-
-// Get 获取缓存
-func (r *RedisCache) Get(ctx context.Context, key string, target interface{}) error {
-	// 获取缓存
-	formattedKey := r.formatKey(key)
-	data, err := r.Client.Get(ctx, formattedKey).Bytes()
-	if err != nil {
-		if err == redis.Nil {
-			// 键不存在
-			r.Logger.Debug("缓存不存在: %s", formattedKey)
-			return fmt.Errorf("缓存不存在: %w", err)
-		}
-		r.Logger.Error("获取缓存失败: %v", err)
-		return fmt.Errorf("获取缓存失败: %w", err)
-	}
-
-	// 反序列化
-	if err := json.Unmarshal(data, target); err != nil {
-		r.Logger.Error("解析缓存数据失败: %v", err)
-		return fmt.Errorf("解析缓存数据失败: %w", err)
-	}
-
-	r.Logger.Debug("成功获取缓存: %s", formattedKey)
-	return nil
-}
-
-// Delete 删除缓存
-func (r *RedisCache) Delete(ctx context.Context, key string) error {
-	formattedKey := r.formatKey(key)
-	err := r.Client.Del(ctx, formattedKey).Err()
-	if err != nil {
-		r.Logger.Error("删除缓存失败: %v", err)
-		return fmt.Errorf("删除缓存失败: %w", err)
-	}
-
-	r.Logger.Debug("成功删除缓存: %s", formattedKey)
-	return nil
+// Ping 检查Redis连接是否可用
+func (r *RedisCache) Ping(ctx context.Context) error {
+	return r.Client.Ping(ctx).Err()
 }
 
 // Exists 检查缓存是否存在
 func (r *RedisCache) Exists(ctx context.Context, key string) (bool, error) {
-	formattedKey := r.formatKey(key)
+	formattedKey := r.redis.key(key)
 	val, err := r.Client.Exists(ctx, formattedKey).Result()
 	if err != nil {
-		r.Logger.Error("检查缓存是否存在失败: %v", err)
+		r.LoggerFor(ctx).Error("检查缓存是否存在失败: %v", err)
 		return false, fmt.Errorf("检查缓存是否存在失败: %w", err)
 	}
 
 	exists := val > 0
-	r.Logger.Debug("检查缓存是否存在: %s, 结果: %v", formattedKey, exists)
+	r.LoggerFor(ctx).Debug("检查缓存是否存在: %s, 结果: %v", formattedKey, exists)
 	return exists, nil
 }
 
@@ -146,7 +104,7 @@ func (r *RedisCache) SetWithRetry(ctx context.Context, key string, value interfa
 
 		lastErr = err
 		if i < retries {
-			r.Logger.Warning("设置缓存失败，将在 %v 后重试: %v", retryDelay, err)
+			r.LoggerFor(ctx).Warning("设置缓存失败，将在 %v 后重试: %v", retryDelay, err)
 			time.Sleep(retryDelay)
 		}
 	}
@@ -154,43 +112,72 @@ func (r *RedisCache) SetWithRetry(ctx context.Context, key string, value interfa
 	return lastErr
 }
 
-// GetWithFallback 获取缓存，失败时使用回退函数获取数据并更新缓存
-func (r *RedisCache) GetWithFallback(ctx context.Context, key string, target interface{}, fallback func() (interface{}, error), expiration time.Duration) error {
-	// 尝试从缓存获取
-	err := r.Get(ctx, key, target)
-	if err == nil {
-		// 缓存命中
-		return nil
-	}
+// redisStore 基于Redis的缓存存储，多个实例共享
+type redisStore struct {
+	client *redis.Client
+	prefix string
+}
 
-	// 缓存未命中，使用回退函数获取数据
-	data, err := fallback()
-	if err != nil {
-		r.Logger.Error("回退函数执行失败: %v", err)
-		return fmt.Errorf("回退函数执行失败: %w", err)
+// key 格式化缓存键名
+func (s *redisStore) key(key string) string {
+	return fmt.Sprintf("%s:%s", s.prefix, key)
+}
+
+// tagKey 返回标签版本号的缓存键
+func (s *redisStore) tagKey(tag string) string {
+	return s.key("tag:" + tag)
+}
+
+func (s *redisStore) load(ctx context.Context, key string) ([]byte, error) {
+	data, err := s.client.Get(ctx, s.key(key)).Bytes()
+	if err == redis.Nil {
+		return nil, ErrCacheMiss
 	}
+	return data, err
+}
+
+func (s *redisStore) save(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	return s.client.Set(ctx, s.key(key), data, ttl).Err()
+}
 
-	// 更新目标对象
-	dataBytes, err := json.Marshal(data)
+func (s *redisStore) remove(ctx context.Context, key string) error {
+	return s.client.Del(ctx, s.key(key)).Err()
+}
+
+func (s *redisStore) tagVersions(ctx context.Context, tags []string) ([]int64, error) {
+	keys := make([]string, len(tags))
+	for i, tag := range tags {
+		keys[i] = s.tagKey(tag)
+	}
+	values, err := s.client.MGet(ctx, keys...).Result()
 	if err != nil {
-		r.Logger.Error("序列化回退数据失败: %v", err)
-		return fmt.Errorf("序列化回退数据失败: %w", err)
+		return nil, err
 	}
 
-	if err := json.Unmarshal(dataBytes, target); err != nil {
-		r.Logger.Error("解析回退数据失败: %v", err)
-		return fmt.Errorf("解析回退数据失败: %w", err)
+	versions := make([]int64, len(values))
+	for i, value := range values {
+		if str, ok := value.(string); ok {
+			versions[i], _ = strconv.ParseInt(str, 10, 64)
+		}
 	}
+	return versions, nil
+}
 
-	// 异步更新缓存
-	go func() {
-		ctxTimeout, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-
-		if err := r.Set(ctxTimeout, key, data, expiration); err != nil {
-			r.Logger.Error("异步更新缓存失败: %v", err)
+func (s *redisStore) bumpTags(ctx context.Context, tags []string) ([]int64, error) {
+	cmds := make([]*redis.IntCmd, len(tags))
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, tag := range tags {
+			cmds[i] = pipe.Incr(ctx, s.tagKey(tag))
 		}
-	}()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	return nil
+	versions := make([]int64, len(cmds))
+	for i, cmd := range cmds {
+		versions[i] = cmd.Val()
+	}
+	return versions, nil
 }
@@ -0,0 +1,251 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/jasonzheng/carrag/metrics"
+)
+
+// 熔断器状态
+const (
+	BreakerClosed = "closed" // 正常访问Redis
+	BreakerOpen   = "open"   // 已熔断，不访问Redis，后台定期探测是否恢复
+)
+
+// probeTimeout 单次探测Redis的超时时间
+const probeTimeout = 2 * time.Second
+
+// BreakerOptions Redis熔断器选项
+type BreakerOptions struct {
+	FailureThreshold int           // 连续失败多少次后熔断
+	ProbeInterval    time.Duration // 熔断期间探测Redis是否恢复的间隔
+}
+
+// RedisStatus Redis连接状态
+type RedisStatus struct {
+	State     string    // 熔断器状态
+	Since     time.Time // 进入当前状态的时间
+	Failures  int       // 连续失败次数
+	LastError string    // 最近一次失败的原因
+}
+
+// probeKey 标记探测请求的上下文键，探测请求不受熔断限制
+type probeKey struct{}
+
+// redisBreaker Redis熔断器，以go-redis钩子的形式作用于客户端的所有命令
+// 连续失败达到阈值后熔断，熔断期间命令直接返回ErrCacheUnavailable而不等待超时；
+// 后台协程按间隔探测Redis，探测成功后恢复访问，启动时连接失败也由它负责重连。
+type redisBreaker struct {
+	opts   BreakerOptions
+	client *redis.Client
+	logger *Logger
+
+	mu       sync.Mutex
+	open     bool
+	since    time.Time
+	failures int
+	lastErr  error
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// newRedisBreaker 创建熔断器并注册到客户端
+func newRedisBreaker(client *redis.Client, opts BreakerOptions, logger *Logger) *redisBreaker {
+	if opts.FailureThreshold <= 0 {
+		opts.FailureThreshold = 1
+	}
+	b := &redisBreaker{
+		opts:   opts,
+		client: client,
+		logger: logger,
+		since:  time.Now(),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	client.AddHook(b)
+	metrics.RedisCircuitOpen.Set(0)
+	return b
+}
+
+// start 启动后台探测协程
+func (b *redisBreaker) start() {
+	go b.probeLoop()
+}
+
+// close 停止后台探测协程
+func (b *redisBreaker) close() {
+	b.stopOnce.Do(func() {
+		close(b.stop)
+	})
+	<-b.done
+}
+
+// status 返回熔断器当前状态
+func (b *redisBreaker) status() RedisStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := RedisStatus{State: BreakerClosed, Since: b.since, Failures: b.failures}
+	if b.open {
+		status.State = BreakerOpen
+	}
+	if b.lastErr != nil {
+		status.LastError = b.lastErr.Error()
+	}
+	return status
+}
+
+func (b *redisBreaker) isOpen() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.open
+}
+
+// trip 熔断
+func (b *redisBreaker) trip(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastErr = err
+	if b.open {
+		return
+	}
+	b.open = true
+	b.since = time.Now()
+	metrics.RedisCircuitOpen.Set(1)
+	b.logger.Warning("Redis不可用，已熔断，将每隔 %v 尝试重连: %v", b.opts.ProbeInterval, err)
+}
+
+// reset 恢复访问
+func (b *redisBreaker) reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	if !b.open {
+		return
+	}
+	b.open = false
+	b.logger.Info("Redis已恢复，熔断持续了 %v", time.Since(b.since).Round(time.Millisecond))
+	b.since = time.Now()
+	metrics.RedisCircuitOpen.Set(0)
+}
+
+// record 记录一次命令的执行结果
+func (b *redisBreaker) record(ctx context.Context, err error) {
+	if !isRedisFailure(ctx, err) {
+		if err == nil || errors.Is(err, redis.Nil) || isRedisReply(err) {
+			b.mu.Lock()
+			b.failures = 0
+			b.mu.Unlock()
+		}
+		return
+	}
+
+	b.mu.Lock()
+	b.failures++
+	b.lastErr = err
+	tripped := b.failures >= b.opts.FailureThreshold
+	b.mu.Unlock()
+
+	if tripped {
+		b.trip(err)
+	}
+}
+
+// probeLoop 熔断期间定期探测Redis，探测成功后恢复访问
+func (b *redisBreaker) probeLoop() {
+	defer close(b.done)
+
+	ticker := time.NewTicker(b.opts.ProbeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C:
+			if !b.isOpen() {
+				continue
+			}
+			ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), probeKey{}, true), probeTimeout)
+			err := b.client.Ping(ctx).Err()
+			cancel()
+			if err != nil {
+				b.trip(err)
+				b.logger.Debug("Redis重连失败: %v", err)
+				continue
+			}
+			b.reset()
+		}
+	}
+}
+
+// isProbe 判断是否为探测请求
+func isProbe(ctx context.Context) bool {
+	probe, _ := ctx.Value(probeKey{}).(bool)
+	return probe
+}
+
+// isRedisReply 判断错误是否为Redis服务端返回的错误回复，这类错误说明连接正常
+func isRedisReply(err error) bool {
+	var reply redis.Error
+	return errors.As(err, &reply)
+}
+
+// isRedisFailure 判断错误是否说明Redis不可用
+// 熔断返回的错误、空值、服务端错误回复以及调用方取消或超时都不计入失败
+func isRedisFailure(ctx context.Context, err error) bool {
+	switch {
+	case err == nil, errors.Is(err, redis.Nil), errors.Is(err, ErrCacheUnavailable), isRedisReply(err):
+		return false
+	case ctx.Err() != nil:
+		return false
+	}
+	return true
+}
+
+// BeforeProcess 熔断期间直接拒绝命令
+func (b *redisBreaker) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	if !isProbe(ctx) && b.isOpen() {
+		return ctx, ErrCacheUnavailable
+	}
+	return ctx, nil
+}
+
+// AfterProcess 记录命令执行结果
+func (b *redisBreaker) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	if !isProbe(ctx) {
+		b.record(ctx, cmd.Err())
+	}
+	return nil
+}
+
+// BeforeProcessPipeline 熔断期间直接拒绝管道命令
+func (b *redisBreaker) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	if !isProbe(ctx) && b.isOpen() {
+		return ctx, ErrCacheUnavailable
+	}
+	return ctx, nil
+}
+
+// AfterProcessPipeline 以管道中第一个错误记录执行结果
+func (b *redisBreaker) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	if isProbe(ctx) {
+		return nil
+	}
+	var err error
+	for _, cmd := range cmds {
+		if err = cmd.Err(); err != nil {
+			break
+		}
+	}
+	b.record(ctx, err)
+	return nil
+}
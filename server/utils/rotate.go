@@ -0,0 +1,294 @@
+package utils
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// logDateLayout 日志文件名中的日期格式
+const logDateLayout = "2006-01-02"
+
+// RotateOptions 日志轮转配置
+type RotateOptions struct {
+	MaxSize    int64         // 单个日志文件的最大字节数，超过后轮转，0表示不按大小轮转
+	MaxBackups int           // 保留的历史日志文件数，0表示不限制
+	MaxAge     time.Duration // 历史日志文件的保留时长，0表示不限制
+	Compress   bool          // 是否使用gzip压缩历史日志文件
+}
+
+// RotatingFile 按日期和大小轮转的日志文件
+//
+// 当前日志文件名为 <日期>.log；日期变化时开始写入新日期的文件，
+// 单个文件超过MaxSize时重命名为 <日期>.<序号>.log 后重新创建。
+// 轮转出的历史文件在后台压缩为 .gz 并按数量和时长清理。
+type RotatingFile struct {
+	dir  string
+	opts RotateOptions
+
+	mu   sync.Mutex
+	file *os.File
+	date string // 当前文件对应的日期
+	size int64  // 当前文件大小
+
+	background sync.WaitGroup // 后台压缩和清理任务
+	bgMu       sync.Mutex     // 保证后台任务依次执行
+}
+
+// OpenRotatingFile 打开日志目录下当天的日志文件
+func OpenRotatingFile(dir string, opts RotateOptions) (*RotatingFile, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建日志目录失败: %w", err)
+	}
+
+	r := &RotatingFile{dir: dir, opts: opts}
+	if err := r.open(time.Now().Format(logDateLayout)); err != nil {
+		return nil, err
+	}
+	r.runBackground("")
+	return r, nil
+}
+
+// Write 写入日志，必要时先轮转
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	today := time.Now().Format(logDateLayout)
+	if today != r.date {
+		if err := r.rotate(today, false); err != nil {
+			return 0, err
+		}
+	} else if r.opts.MaxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.opts.MaxSize {
+		if err := r.rotate(today, true); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Reopen 关闭并重新打开当前日志文件，供外部logrotate移动文件后调用
+func (r *RotatingFile) Reopen() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file != nil {
+		r.file.Close()
+	}
+	return r.open(time.Now().Format(logDateLayout))
+}
+
+// Close 关闭日志文件并等待后台压缩和清理完成
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	var err error
+	if r.file != nil {
+		err = r.file.Close()
+		r.file = nil
+	}
+	r.mu.Unlock()
+
+	r.background.Wait()
+	return err
+}
+
+// currentPath 返回指定日期的日志文件路径
+func (r *RotatingFile) currentPath(date string) string {
+	return filepath.Join(r.dir, date+".log")
+}
+
+// open 打开指定日期的日志文件，调用者需持有锁
+func (r *RotatingFile) open(date string) error {
+	file, err := os.OpenFile(r.currentPath(date), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("打开日志文件失败: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("获取日志文件信息失败: %w", err)
+	}
+
+	r.file = file
+	r.date = date
+	r.size = info.Size()
+	return nil
+}
+
+// rotate 轮转当前日志文件，bySize为true时将当前文件重命名为带序号的历史文件
+// 调用者需持有锁
+func (r *RotatingFile) rotate(date string, bySize bool) error {
+	oldPath := r.currentPath(r.date)
+	if err := r.file.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "关闭日志文件失败: %v\n", err)
+	}
+
+	if bySize {
+		backupPath := r.backupPath(r.date)
+		if err := os.Rename(oldPath, backupPath); err != nil {
+			fmt.Fprintf(os.Stderr, "重命名日志文件失败: %v\n", err)
+		} else {
+			oldPath = backupPath
+		}
+	}
+
+	if err := r.open(date); err != nil {
+		return err
+	}
+	r.runBackground(oldPath)
+	return nil
+}
+
+// backupPath 返回下一个可用的历史文件路径
+func (r *RotatingFile) backupPath(date string) string {
+	for i := 1; ; i++ {
+		path := filepath.Join(r.dir, fmt.Sprintf("%s.%d.log", date, i))
+		if !fileExists(path) && !fileExists(path+".gz") {
+			return path
+		}
+	}
+}
+
+// runBackground 在后台压缩轮转出的文件并清理过期文件
+func (r *RotatingFile) runBackground(rotated string) {
+	r.background.Add(1)
+	go func() {
+		defer r.background.Done()
+		r.bgMu.Lock()
+		defer r.bgMu.Unlock()
+
+		if rotated != "" && r.opts.Compress {
+			// 文件可能已被之前的清理任务删除
+			if err := compressFile(rotated); err != nil && !os.IsNotExist(err) {
+				fmt.Fprintf(os.Stderr, "压缩日志文件失败: %v\n", err)
+			}
+		}
+		if err := r.cleanup(); err != nil {
+			fmt.Fprintf(os.Stderr, "清理日志文件失败: %v\n", err)
+		}
+	}()
+}
+
+// cleanup 按数量和时长删除历史日志文件
+func (r *RotatingFile) cleanup() error {
+	if r.opts.MaxBackups <= 0 && r.opts.MaxAge <= 0 {
+		return nil
+	}
+
+	entries, err := os.ReadDir(r.dir)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	current := filepath.Base(r.currentPath(r.date))
+	r.mu.Unlock()
+
+	type backup struct {
+		path    string
+		modTime time.Time
+	}
+	var backups []backup
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || name == current || !isLogFileName(name) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{path: filepath.Join(r.dir, name), modTime: info.ModTime()})
+	}
+
+	// 按修改时间从新到旧排序
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].modTime.After(backups[j].modTime)
+	})
+
+	for i, b := range backups {
+		expired := r.opts.MaxAge > 0 && time.Since(b.modTime) > r.opts.MaxAge
+		exceeded := r.opts.MaxBackups > 0 && i >= r.opts.MaxBackups
+		if expired || exceeded {
+			if err := os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+				fmt.Fprintf(os.Stderr, "删除历史日志文件失败: %v\n", err)
+			}
+		}
+	}
+	return nil
+}
+
+// isLogFileName 判断是否为本日志记录器生成的文件，避免误删目录中的其他文件
+func isLogFileName(name string) bool {
+	if !strings.HasSuffix(name, ".log") && !strings.HasSuffix(name, ".log.gz") {
+		return false
+	}
+	if len(name) < len(logDateLayout) {
+		return false
+	}
+	_, err := time.Parse(logDateLayout, name[:len(logDateLayout)])
+	return err == nil
+}
+
+// compressFile 将文件压缩为 .gz 后删除原文件
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(dst)
+	gz.Name = filepath.Base(path)
+	gz.ModTime = info.ModTime()
+	if _, err := io.Copy(gz, src); err != nil {
+		gz.Close()
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(path + ".gz")
+		return err
+	}
+
+	// 保留原文件的修改时间，使清理时按日志实际时间判断
+	os.Chtimes(path+".gz", info.ModTime(), info.ModTime())
+	src.Close()
+	return os.Remove(path)
+}
+
+// fileExists 检查文件是否存在
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
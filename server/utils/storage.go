@@ -1,18 +1,26 @@
 package utils
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
+
+	"github.com/jasonzheng/carrag/metrics"
+	"github.com/jasonzheng/carrag/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // Storage 文件存储管理器
 type Storage struct {
-	DataDir  string       // 数据目录路径
-	FileLock sync.RWMutex // 读写锁，用于并发控制
-	Logger   *Logger      // 日志记录器
+	DataDir      string       // 数据目录路径
+	FileLock     sync.RWMutex // 读写锁，用于并发控制
+	LoggerHolder              // 日志记录器
+
+	pending sync.WaitGroup // 正在进行的写操作，关闭时等待其完成
 }
 
 // NewStorage 创建新的存储管理器
@@ -23,32 +31,54 @@ func NewStorage(dataDir string, logger *Logger) (*Storage, error) {
 	}
 
 	return &Storage{
-		DataDir:  dataDir,
-		FileLock: sync.RWMutex{},
-		Logger:   logger,
+		DataDir:      dataDir,
+		FileLock:     sync.RWMutex{},
+		LoggerHolder: LoggerHolder{Logger: logger},
 	}, nil
 }
 
 // SaveJSON 将数据保存为JSON文件
-func (s *Storage) SaveJSON(filename string, data interface{}) error {
+func (s *Storage) SaveJSON(ctx context.Context, filename string, data interface{}) (err error) {
+	s.pending.Add(1)
+	defer s.pending.Done()
+
+	ctx, span := tracing.Start(ctx, "Storage.SaveJSON", attribute.String("file", filename))
+	defer tracing.End(span, &err)
+
+	start := time.Now()
+	err = s.saveJSON(ctx, filename, data)
+	metrics.ObserveStorage("save", filename, start, err)
+	return err
+}
+
+// saveJSON 将数据保存为JSON文件
+func (s *Storage) saveJSON(ctx context.Context, filename string, data interface{}) error {
 	// 获取写锁
 	s.FileLock.Lock()
 	defer s.FileLock.Unlock()
 
+	// 等待锁期间请求可能已取消；开始写入后不再中断，保证文件完整
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	// 构建完整文件路径
 	filePath := filepath.Join(s.DataDir, filename)
 
 	// 确保目录存在
 	dir := filepath.Dir(filePath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
-		s.Logger.Error("创建目录失败: %v", err)
+		s.LoggerFor(ctx).Error("创建目录失败: %v", err)
 		return fmt.Errorf("创建目录失败: %w", err)
 	}
 
 	// 将数据转换为格式化的JSON
+	_, marshalSpan := tracing.Start(ctx, "Storage.MarshalJSON")
 	jsonData, err := json.MarshalIndent(data, "", "  ")
+	marshalSpan.SetAttributes(attribute.Int("file.size", len(jsonData)))
+	tracing.End(marshalSpan, &err)
 	if err != nil {
-		s.Logger.Error("序列化数据失败: %v", err)
+		s.LoggerFor(ctx).Error("序列化数据失败: %v", err)
 		return fmt.Errorf("序列化数据失败: %w", err)
 	}
 
@@ -56,16 +86,19 @@ func (s *Storage) SaveJSON(filename string, data interface{}) error {
 	if s.FileExists(filename) {
 		backupFile := filePath + ".bak"
 		if err := os.Rename(filePath, backupFile); err != nil {
-			s.Logger.Warning("创建备份文件失败: %v，继续保存新文件", err)
+			s.LoggerFor(ctx).Warning("创建备份文件失败: %v，继续保存新文件", err)
 		} else {
-			s.Logger.Debug("已创建备份文件: %s", backupFile)
+			s.LoggerFor(ctx).Debug("已创建备份文件: %s", backupFile)
 		}
 	}
 
 	// 先写入临时文件
 	tempFile := filePath + ".tmp"
-	if err := os.WriteFile(tempFile, jsonData, 0644); err != nil {
-		s.Logger.Error("写入临时文件失败: %v", err)
+	_, writeSpan := tracing.Start(ctx, "Storage.WriteFile")
+	err = os.WriteFile(tempFile, jsonData, 0644)
+	tracing.End(writeSpan, &err)
+	if err != nil {
+		s.LoggerFor(ctx).Error("写入临时文件失败: %v", err)
 		return fmt.Errorf("写入临时文件失败: %w", err)
 	}
 
@@ -73,45 +106,67 @@ func (s *Storage) SaveJSON(filename string, data interface{}) error {
 	if err := os.Rename(tempFile, filePath); err != nil {
 		// 重命名失败时尝试删除临时文件
 		os.Remove(tempFile)
-		s.Logger.Error("重命名文件失败: %v", err)
+		s.LoggerFor(ctx).Error("重命名文件失败: %v", err)
 		return fmt.Errorf("重命名文件失败: %w", err)
 	}
 
-	s.Logger.Info("成功保存数据到文件: %s", filename)
+	s.LoggerFor(ctx).Info("成功保存数据到文件: %s", filename)
 	return nil
 }
 
 // LoadJSON 从JSON文件加载数据
-func (s *Storage) LoadJSON(filename string, target interface{}) error {
+func (s *Storage) LoadJSON(ctx context.Context, filename string, target interface{}) (err error) {
+	ctx, span := tracing.Start(ctx, "Storage.LoadJSON", attribute.String("file", filename))
+	defer tracing.End(span, &err)
+
+	start := time.Now()
+	err = s.loadJSON(ctx, filename, target)
+	metrics.ObserveStorage("load", filename, start, err)
+	return err
+}
+
+// loadJSON 从JSON文件加载数据
+func (s *Storage) loadJSON(ctx context.Context, filename string, target interface{}) error {
 	// 获取读锁
 	s.FileLock.RLock()
 	defer s.FileLock.RUnlock()
 
+	// 等待锁期间请求可能已取消
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	// 构建完整文件路径
 	filePath := filepath.Join(s.DataDir, filename)
 
 	// 检查文件是否存在
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		s.Logger.Warning("文件不存在: %s", filename)
+		s.LoggerFor(ctx).Warning("文件不存在: %s", filename)
 		return nil // 文件不存在不视为错误，由调用者处理空数据情况
 	}
 
 	// 读取文件内容
+	_, readSpan := tracing.Start(ctx, "Storage.ReadFile")
 	fileData, err := os.ReadFile(filePath)
+	readSpan.SetAttributes(attribute.Int("file.size", len(fileData)))
+	tracing.End(readSpan, &err)
 	if err != nil {
-		s.Logger.Error("读取文件失败: %v", err)
+		s.LoggerFor(ctx).Error("读取文件失败: %v", err)
 		return fmt.Errorf("读取文件失败: %w", err)
 	}
 
 	// 文件为空时直接返回
 	if len(fileData) == 0 {
-		s.Logger.Warning("文件为空: %s", filename)
+		s.LoggerFor(ctx).Warning("文件为空: %s", filename)
 		return nil
 	}
 
-	// 尝试从备份文件恢复
-	if err := json.Unmarshal(fileData, target); err != nil {
-		s.Logger.Error("解析JSON数据失败: %v，尝试从备份恢复", err)
+	// 解析失败时尝试从备份文件恢复
+	_, parseSpan := tracing.Start(ctx, "Storage.UnmarshalJSON")
+	err = json.Unmarshal(fileData, target)
+	tracing.End(parseSpan, &err)
+	if err != nil {
+		s.LoggerFor(ctx).Error("解析JSON数据失败: %v，尝试从备份恢复", err)
 
 		// 检查是否存在备份文件
 		backupFile := filePath + ".bak"
@@ -121,7 +176,7 @@ func (s *Storage) LoadJSON(filename string, target interface{}) error {
 			if backupErr == nil && len(backupData) > 0 {
 				// 尝试解析备份数据
 				if backupErr := json.Unmarshal(backupData, target); backupErr == nil {
-					s.Logger.Info("成功从备份文件恢复数据: %s", backupFile)
+					s.LoggerFor(ctx).Info("成功从备份文件恢复数据: %s", backupFile)
 					return nil
 				}
 			}
@@ -130,7 +185,39 @@ func (s *Storage) LoadJSON(filename string, target interface{}) error {
 		return fmt.Errorf("解析JSON数据失败: %w", err)
 	}
 
-	s.Logger.Info("成功从文件加载数据: %s", filename)
+	s.LoggerFor(ctx).Info("成功从文件加载数据: %s", filename)
+	return nil
+}
+
+// CheckWritable 检查数据目录是否可写
+func (s *Storage) CheckWritable() error {
+	file, err := os.CreateTemp(s.DataDir, ".healthcheck-*")
+	if err != nil {
+		return fmt.Errorf("数据目录不可写: %w", err)
+	}
+	name := file.Name()
+	file.Close()
+	if err := os.Remove(name); err != nil {
+		return fmt.Errorf("删除检查文件失败: %w", err)
+	}
+	return nil
+}
+
+// ValidateJSON 检查JSON文件能否被解析，文件不存在或为空视为正常
+func (s *Storage) ValidateJSON(filename string) error {
+	s.FileLock.RLock()
+	defer s.FileLock.RUnlock()
+
+	fileData, err := os.ReadFile(filepath.Join(s.DataDir, filename))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("读取文件失败: %w", err)
+	}
+	if len(fileData) > 0 && !json.Valid(fileData) {
+		return fmt.Errorf("文件不是有效的JSON: %s", filename)
+	}
 	return nil
 }
 
@@ -143,6 +230,9 @@ func (s *Storage) FileExists(filename string) bool {
 
 // DeleteFile 删除文件
 func (s *Storage) DeleteFile(filename string) error {
+	s.pending.Add(1)
+	defer s.pending.Done()
+
 	// 获取写锁
 	s.FileLock.Lock()
 	defer s.FileLock.Unlock()
@@ -163,3 +253,11 @@ func (s *Storage) DeleteFile(filename string) error {
 	s.Logger.Info("成功删除文件: %s", filename)
 	return nil
 }
+
+// Flush 等待正在进行的写操作完成，超过ctx的截止时间时返回错误
+func (s *Storage) Flush(ctx context.Context) error {
+	if err := WaitGroupContext(ctx, &s.pending); err != nil {
+		return fmt.Errorf("等待文件写入完成超时: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"context"
+	"time"
+)
+
+// TieredCache 两级缓存，进程内LRU缓存在前，Redis在后
+// 读取时先查本地，未命中再查Redis并回填本地；写入和删除同时作用于两级。
+// 标签版本号以Redis为准，保证多个实例看到一致的失效状态；
+// 本地条目最多保留localTTL，其他实例写入的单条数据最多在这段时间内不可见。
+type TieredCache struct {
+	*entryCache
+
+	local  *lruStore
+	remote *RedisCache
+}
+
+// NewTieredCache 创建两级缓存，maxEntries和localTTL限制本地缓存的条目数和保留时间
+func NewTieredCache(maxEntries int, localTTL time.Duration, remote *RedisCache, logger *Logger) *TieredCache {
+	local := newLRUStore(maxEntries, localTTL)
+	return &TieredCache{
+		entryCache: newEntryCache(&tieredStore{local: local, remote: remote.redis}, "tiered", logger),
+		local:      local,
+		remote:     remote,
+	}
+}
+
+// Len 返回本地缓存的条目数
+func (c *TieredCache) Len() int {
+	return c.local.len()
+}
+
+// Evict 删除本地缓存中其他实例已失效的键，标签版本号保存在Redis中，无需处理
+func (c *TieredCache) Evict(ctx context.Context, keys []string, tags []string) {
+	for _, key := range keys {
+		c.local.remove(ctx, key)
+	}
+}
+
+// Purge 清空本地缓存
+func (c *TieredCache) Purge(ctx context.Context) {
+	c.local.purge()
+}
+
+// tieredStore 两级缓存存储
+type tieredStore struct {
+	local  *lruStore
+	remote *redisStore
+}
+
+func (s *tieredStore) load(ctx context.Context, key string) ([]byte, error) {
+	if data, err := s.local.load(ctx, key); err == nil {
+		return data, nil
+	}
+
+	data, err := s.remote.load(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	// 回填本地缓存，保留时间受本地缓存的maxTTL限制
+	s.local.save(ctx, key, data, s.local.maxTTL)
+	return data, nil
+}
+
+func (s *tieredStore) save(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	s.local.save(ctx, key, data, ttl)
+	return s.remote.save(ctx, key, data, ttl)
+}
+
+func (s *tieredStore) remove(ctx context.Context, key string) error {
+	s.local.remove(ctx, key)
+	return s.remote.remove(ctx, key)
+}
+
+func (s *tieredStore) tagVersions(ctx context.Context, tags []string) ([]int64, error) {
+	return s.remote.tagVersions(ctx, tags)
+}
+
+func (s *tieredStore) bumpTags(ctx context.Context, tags []string) ([]int64, error) {
+	return s.remote.bumpTags(ctx, tags)
+}
@@ -30,6 +30,11 @@ const carApi = {
   // 删除车辆
   deleteCar(id) {
     return axios.delete(`/api/cars/${id}`);
+  },
+  
+  // 全文搜索车辆，结果按相关度排序，最多返回limit条
+  searchCars(q, limit) {
+    return axios.get('/api/search', { params: { q, limit } });
   }
 };
 